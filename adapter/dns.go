@@ -3,12 +3,15 @@ package adapter
 import (
 	"context"
 	"net/netip"
+	"time"
 
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json/badoption"
 	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/common/observable"
 	"github.com/sagernet/sing/service"
 
 	"github.com/miekg/dns"
@@ -19,8 +22,11 @@ type DNSRouter interface {
 	Exchange(ctx context.Context, message *dns.Msg, options DNSQueryOptions) (*dns.Msg, error)
 	Lookup(ctx context.Context, domain string, options DNSQueryOptions) ([]netip.Addr, error)
 	ClearCache()
+	CacheStatistics() map[string]DNSCacheStatistics
 	LookupReverseMapping(ip netip.Addr) (string, bool)
+	LookupDNS64(ip netip.Addr) (netip.Addr, bool)
 	ResetNetwork()
+	AppendTracker(tracker DNSQueryTracker)
 }
 
 type DNSClient interface {
@@ -28,6 +34,48 @@ type DNSClient interface {
 	Exchange(ctx context.Context, transport DNSTransport, message *dns.Msg, options DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) (*dns.Msg, error)
 	Lookup(ctx context.Context, transport DNSTransport, domain string, options DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) ([]netip.Addr, error)
 	ClearCache()
+	CacheStatistics() map[string]DNSCacheStatistics
+	AppendTracker(tracker DNSQueryTracker)
+}
+
+// DNSCacheStatistics counts cache lookups of a DNS transport.
+type DNSCacheStatistics struct {
+	Hit   uint64
+	Miss  uint64
+	Stale uint64
+}
+
+// DNSQueryTracker observes queries answered by the DNS client,
+// either from cache or by exchanging with a transport.
+type DNSQueryTracker interface {
+	DNSQueryCached(ctx context.Context, transport DNSTransport, question dns.Question)
+	DNSQueryExchanged(ctx context.Context, transport DNSTransport, question dns.Question, latency time.Duration, err error)
+}
+
+// DNSQueryLog keeps the most recent queries handled by the DNS router.
+type DNSQueryLog interface {
+	Record(entry *DNSQueryLogEntry)
+	Entries() []*DNSQueryLogEntry
+	Clear()
+	// Subscribe streams new entries, a nil entry means the log has been cleared.
+	Subscribe() (subscription observable.Subscription[*DNSQueryLogEntry], done <-chan struct{}, err error)
+	UnSubscribe(subscription observable.Subscription[*DNSQueryLogEntry])
+}
+
+type DNSQueryLogEntry struct {
+	ID        uint64             `json:"id"`
+	Time      time.Time          `json:"time"`
+	Inbound   string             `json:"inbound,omitempty"`
+	Source    string             `json:"source,omitempty"`
+	Domain    string             `json:"domain"`
+	QueryType string             `json:"query_type"`
+	Rule      string             `json:"rule,omitempty"`
+	Transport string             `json:"transport,omitempty"`
+	Rcode     string             `json:"rcode,omitempty"`
+	Answers   []string           `json:"answers,omitempty"`
+	Latency   badoption.Duration `json:"latency"`
+	Cached    bool               `json:"cached"`
+	Error     string             `json:"error,omitempty"`
 }
 
 type DNSQueryOptions struct {
@@ -37,6 +85,25 @@ type DNSQueryOptions struct {
 	DisableCache   bool
 	RewriteTTL     *uint32
 	ClientSubnet   netip.Prefix
+	ValidateDNSSEC bool
+	DNS64          bool
+	DNS64Prefix    netip.Prefix
+	Rewrite        []DNSResponseRewriter
+	FastestIP      *FastestIPOptions
+}
+
+// FastestIPOptions configures probes ordering resolved addresses by latency.
+type FastestIPOptions struct {
+	Method       string
+	Port         uint16
+	Timeout      time.Duration
+	MaxAddresses int
+}
+
+type DNSResponseRewriter interface {
+	RewriteResponse(response *dns.Msg)
+	// RewriteTTL returns the rewritten TTL, which also bounds the lifetime of the cached response.
+	RewriteTTL(ttl uint32) uint32
 }
 
 func DNSQueryOptionsFrom(ctx context.Context, options *option.DomainResolveOptions) (*DNSQueryOptions, error) {
@@ -63,6 +130,15 @@ type RDRCStore interface {
 	SaveRDRCAsync(transportName string, qName string, qType uint16, logger logger.Logger)
 }
 
+// DNSCacheStore persists answers of the DNS client cache,
+// the shared cache uses an empty transport name.
+type DNSCacheStore interface {
+	LoadDNSCache(loadFunc func(transportName string, question dns.Question, message *dns.Msg, expireAt time.Time) bool)
+	SaveDNSCacheAsync(transportName string, question dns.Question, message *dns.Msg, expireAt time.Time, logger logger.Logger)
+	DeleteDNSCacheAsync(transportName string, question dns.Question, logger logger.Logger)
+	ClearDNSCache() error
+}
+
 type DNSTransport interface {
 	Lifecycle
 	Type() string
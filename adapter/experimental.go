@@ -22,6 +22,7 @@ type ClashServer interface {
 type URLTestHistory struct {
 	Time  time.Time `json:"time"`
 	Delay uint16    `json:"delay"`
+	Score uint8     `json:"score"`
 }
 
 type URLTestHistoryStorage interface {
@@ -29,9 +30,16 @@ type URLTestHistoryStorage interface {
 	LoadURLTestHistory(tag string) *URLTestHistory
 	DeleteURLTestHistory(tag string)
 	StoreURLTestHistory(tag string, history *URLTestHistory)
+	ReportConnection(tag string, success bool) *URLTestHistory
 	Close() error
 }
 
+// ConnectionHealthReporter is implemented by connections opened by outbound groups,
+// so that results of real traffic are scored for the member that carried them.
+type ConnectionHealthReporter interface {
+	ReportHealth(success bool)
+}
+
 type V2RayServer interface {
 	LifecycleService
 	StatsService() ConnectionTracker
@@ -46,6 +54,9 @@ type CacheFile interface {
 	StoreRDRC() bool
 	RDRCStore
 
+	StoreDNS() bool
+	DNSCacheStore
+
 	LoadMode() string
 	StoreMode(mode string) error
 	LoadSelected(group string) string
@@ -54,6 +65,10 @@ type CacheFile interface {
 	StoreGroupExpand(group string, expand bool) error
 	LoadRuleSet(tag string) *SavedBinary
 	SaveRuleSet(tag string, set *SavedBinary) error
+	LoadOutboundProvider(tag string) *SavedBinary
+	SaveOutboundProvider(tag string, provider *SavedBinary) error
+	LoadTrafficQuota(inbound string, user string) *SavedTrafficQuota
+	SaveTrafficQuota(inbound string, user string, quota *SavedTrafficQuota) error
 }
 
 type SavedBinary struct {
@@ -2,6 +2,8 @@ package adapter
 
 import (
 	"context"
+	"net"
+	"net/http"
 	"net/netip"
 	"time"
 
@@ -52,12 +54,18 @@ type InboundContext struct {
 
 	// sniffer
 
-	Protocol     string
-	Domain       string
-	Client       string
-	SniffContext any
-	SnifferNames []string
-	SniffError   error
+	Protocol      string
+	Domain        string
+	Client        string
+	ClientJA3     string
+	ClientJA4     string
+	HTTPMethod    string
+	HTTPPath      string
+	HTTPUserAgent string
+	HTTPHeader    http.Header
+	SniffContext  any
+	SnifferNames  []string
+	SniffError    error
 
 	// cache
 
@@ -75,6 +83,10 @@ type InboundContext struct {
 	TLSFragment               bool
 	TLSFragmentFallbackDelay  time.Duration
 	TLSRecordFragment         bool
+	SpeedLimiter              SpeedLimiter
+	UserSpeedLimiter          SpeedLimiter
+	ConnectionLimiter         ConnectionLimiter
+	UserConnectionLimiter     ConnectionLimiter
 
 	NetworkStrategy     *C.NetworkStrategy
 	NetworkType         []C.InterfaceType
@@ -82,11 +94,15 @@ type InboundContext struct {
 	FallbackDelay       time.Duration
 
 	DestinationAddresses []netip.Addr
+	FastestIP            *FastestIPOptions
 	SourceGeoIPCode      string
 	GeoIPCode            string
 	ProcessInfo          *ConnectionOwner
+	SourceMACAddress     net.HardwareAddr
+	SourceHostname       string
 	QueryType            uint16
 	FakeIP               bool
+	DNS64                bool
 
 	// rule cache
 
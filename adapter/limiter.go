@@ -0,0 +1,33 @@
+package adapter
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// SpeedLimiter caps upload and download throughput in bytes per second,
+// shared by all connections it is applied to.
+type SpeedLimiter interface {
+	fmt.Stringer
+	// Upload returns the bucket for uploaded bytes, or nil if upload is unlimited.
+	Upload() SpeedBucket
+	// Download returns the bucket for downloaded bytes, or nil if download is unlimited.
+	Download() SpeedBucket
+}
+
+// SpeedBucket is a token bucket of bytes.
+type SpeedBucket interface {
+	// Reserve takes n bytes from the bucket at now, and returns how long to wait before they are available.
+	Reserve(now time.Time, n int) time.Duration
+}
+
+// ConnectionLimiter caps concurrent connections and distinct source addresses,
+// shared by all connections it is applied to.
+type ConnectionLimiter interface {
+	fmt.Stringer
+	// Acquire counts a connection from source, the returned function must be called once it is closed.
+	Acquire(source netip.Addr) (func(), error)
+	Connections() int
+	Devices() int
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -227,6 +228,10 @@ func (m *Manager) Remove(tag string) error {
 	if !found {
 		return os.ErrInvalid
 	}
+	dependBy := m.dependByTag[tag]
+	if len(dependBy) > 0 {
+		return E.New("outbound[", tag, "] is depended by ", strings.Join(dependBy, ", "))
+	}
 	delete(m.outboundByTag, tag)
 	index := common.Index(m.outbounds, func(it adapter.Outbound) bool {
 		return it == outbound
@@ -244,12 +249,16 @@ func (m *Manager) Remove(tag string) error {
 			m.defaultOutbound = nil
 		}
 	}
-	dependBy := m.dependByTag[tag]
-	if len(dependBy) > 0 {
-		return E.New("outbound[", tag, "] is depended by ", strings.Join(dependBy, ", "))
+	m.removeDependencies(outbound)
+	if started {
+		return common.Close(outbound)
 	}
-	dependencies := outbound.Dependencies()
-	for _, dependency := range dependencies {
+	return nil
+}
+
+func (m *Manager) removeDependencies(outbound adapter.Outbound) {
+	tag := outbound.Tag()
+	for _, dependency := range outbound.Dependencies() {
 		if len(m.dependByTag[dependency]) == 1 {
 			delete(m.dependByTag, dependency)
 		} else {
@@ -258,10 +267,13 @@ func (m *Manager) Remove(tag string) error {
 			})
 		}
 	}
-	if started {
-		return common.Close(outbound)
-	}
-	return nil
+}
+
+// DependedBy returns tags of outbounds that depend on the given outbound.
+func (m *Manager) DependedBy(tag string) []string {
+	m.access.RLock()
+	defer m.access.RUnlock()
+	return slices.Clone(m.dependByTag[tag])
 }
 
 func (m *Manager) Create(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, inboundType string, options any) error {
@@ -272,6 +284,14 @@ func (m *Manager) Create(ctx context.Context, router adapter.Router, logger log.
 	if err != nil {
 		return err
 	}
+	return m.Add(outbound)
+}
+
+// Add registers an outbound created outside the manager, replacing the existing one with the same tag.
+// The outbound is started immediately if the manager is already started.
+func (m *Manager) Add(outbound adapter.Outbound) error {
+	tag := outbound.Tag()
+	var err error
 	if m.started {
 		name := "outbound/" + outbound.Type() + "[" + outbound.Tag() + "]"
 		for _, stage := range adapter.ListStartStages {
@@ -300,6 +320,10 @@ func (m *Manager) Create(ctx context.Context, router adapter.Router, logger log.
 			panic("invalid inbound index")
 		}
 		m.outbounds = append(m.outbounds[:existsIndex], m.outbounds[existsIndex+1:]...)
+		m.removeDependencies(existsOutbound)
+		if m.defaultOutbound == existsOutbound {
+			m.defaultOutbound = outbound
+		}
 	}
 	m.outbounds = append(m.outbounds, outbound)
 	m.outboundByTag[tag] = outbound
@@ -0,0 +1,25 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/sagernet/sing/common/x/list"
+)
+
+type OutboundProvider interface {
+	Tag() string
+	Outbounds() []Outbound
+	UpdatedAt() time.Time
+	Update(ctx context.Context) error
+	RegisterCallback(callback OutboundProviderUpdateCallback) *list.Element[OutboundProviderUpdateCallback]
+	UnregisterCallback(element *list.Element[OutboundProviderUpdateCallback])
+}
+
+type OutboundProviderUpdateCallback func(it OutboundProvider)
+
+type OutboundProviderManager interface {
+	Lifecycle
+	OutboundProviders() []OutboundProvider
+	OutboundProvider(tag string) (OutboundProvider, bool)
+}
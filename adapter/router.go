@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"net/netip"
 	"sync"
 	"time"
 
@@ -24,17 +25,31 @@ type Router interface {
 	PreMatch(metadata InboundContext, context tun.DirectRouteContext, timeout time.Duration, supportBypass bool) (tun.DirectRouteDestination, error)
 	ConnectionRouterEx
 	RuleSet(tag string) (RuleSet, bool)
+	RuleSets() []RuleSet
 	Rules() []Rule
 	NeedFindProcess() bool
+	NeedSniffHTTP() bool
+	ASNReader() ASNReader
 	AppendTracker(tracker ConnectionTracker)
 	ResetNetwork()
 }
 
+// ASNReader looks up autonomous system numbers of addresses.
+type ASNReader interface {
+	Lookup(addr netip.Addr) (uint32, bool)
+}
+
 type ConnectionTracker interface {
 	RoutedConnection(ctx context.Context, conn net.Conn, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) net.Conn
 	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext, matchedRule Rule, matchOutbound Outbound) N.PacketConn
 }
 
+// ConnectionCloseHandler is implemented by connections returned by a ConnectionTracker
+// to be notified of the error the routed connection finished with.
+type ConnectionCloseHandler interface {
+	RoutedConnectionClosed(err error)
+}
+
 // Deprecated: Use ConnectionRouterEx instead.
 type ConnectionRouter interface {
 	RouteConnection(ctx context.Context, conn net.Conn, metadata InboundContext) error
@@ -64,6 +79,13 @@ type RuleSet interface {
 
 type RuleSetUpdateCallback func(it RuleSet)
 
+// RemoteRuleSet is implemented by rule-sets updated from a remote URL.
+type RemoteRuleSet interface {
+	RuleSet
+	LastUpdated() time.Time
+	LastUpdateError() error
+}
+
 type RuleSetMetadata struct {
 	ContainsProcessRule bool
 	ContainsWIFIRule    bool
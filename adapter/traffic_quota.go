@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/sagernet/sing-box/option"
+	N "github.com/sagernet/sing/common/network"
+)
+
+type TrafficQuotaManager interface {
+	Lifecycle
+	Register(inbound string, user string, options option.TrafficQuotaOptions) error
+	Unregister(inbound string, user string)
+	RoutedConnection(ctx context.Context, conn net.Conn, metadata InboundContext) (net.Conn, error)
+	RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata InboundContext) (N.PacketConn, error)
+	TrafficQuotas() []TrafficQuotaStatus
+	TrafficQuota(inbound string, user string) (TrafficQuotaStatus, bool)
+	ResetTrafficQuota(inbound string, user string) error
+	SetTrafficQuotaLimit(inbound string, user string, limit uint64) error
+	SetTrafficQuotaUsed(inbound string, user string, used uint64) error
+}
+
+type TrafficQuotaStatus struct {
+	Inbound     string    `json:"inbound"`
+	User        string    `json:"user"`
+	Limit       uint64    `json:"limit"`
+	Used        uint64    `json:"used"`
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+}
+
+// SavedTrafficQuota is the accounting of a traffic quota for the current period.
+// Limit is only set if the quota was adjusted for the current period.
+type SavedTrafficQuota struct {
+	PeriodStart time.Time
+	Used        uint64
+	Limit       uint64
+}
+
+func (s *SavedTrafficQuota) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	err := binary.Write(&buffer, binary.BigEndian, uint8(1))
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.PeriodStart.Unix())
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.Used)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.BigEndian, s.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (s *SavedTrafficQuota) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	var version uint8
+	err := binary.Read(reader, binary.BigEndian, &version)
+	if err != nil {
+		return err
+	}
+	var periodStart int64
+	err = binary.Read(reader, binary.BigEndian, &periodStart)
+	if err != nil {
+		return err
+	}
+	s.PeriodStart = time.Unix(periodStart, 0)
+	err = binary.Read(reader, binary.BigEndian, &s.Used)
+	if err != nil {
+		return err
+	}
+	err = binary.Read(reader, binary.BigEndian, &s.Limit)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
@@ -17,14 +18,19 @@ import (
 	"github.com/sagernet/sing-box/common/dialer"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/common/trafficquota"
+	"github.com/sagernet/sing-box/common/urltest"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/dns"
 	"github.com/sagernet/sing-box/dns/transport/local"
 	"github.com/sagernet/sing-box/experimental"
 	"github.com/sagernet/sing-box/experimental/cachefile"
+	"github.com/sagernet/sing-box/experimental/connexport"
+	"github.com/sagernet/sing-box/experimental/metrics"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-box/protocol/direct"
+	"github.com/sagernet/sing-box/provider"
 	"github.com/sagernet/sing-box/route"
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
@@ -37,6 +43,9 @@ import (
 var _ adapter.SimpleLifecycle = (*Box)(nil)
 
 type Box struct {
+	ctx             context.Context
+	options         option.Options
+	reloadAccess    sync.Mutex
 	createdAt       time.Time
 	logFactory      log.Factory
 	logger          log.ContextLogger
@@ -44,6 +53,8 @@ type Box struct {
 	endpoint        *endpoint.Manager
 	inbound         *inbound.Manager
 	outbound        *outbound.Manager
+	provider        *provider.Manager
+	trafficQuota    *trafficquota.Manager
 	service         *boxService.Manager
 	dnsTransport    *dns.TransportManager
 	dnsRouter       *dns.Router
@@ -129,6 +140,7 @@ func New(options Options) (*Box, error) {
 	var needCacheFile bool
 	var needClashAPI bool
 	var needV2RayAPI bool
+	var needMetrics bool
 	if experimentalOptions.CacheFile != nil && experimentalOptions.CacheFile.Enabled || options.PlatformLogWriter != nil {
 		needCacheFile = true
 	}
@@ -138,6 +150,13 @@ func New(options Options) (*Box, error) {
 	if experimentalOptions.V2RayAPI != nil && experimentalOptions.V2RayAPI.Listen != "" {
 		needV2RayAPI = true
 	}
+	if experimentalOptions.Metrics != nil && experimentalOptions.Metrics.Listen != "" {
+		needMetrics = true
+		if service.PtrFromContext[urltest.HistoryStorage](ctx) == nil {
+			// share URL test results of groups with the exporter
+			service.MustRegisterPtr(ctx, urltest.NewHistoryStorage())
+		}
+	}
 	platformInterface := service.FromContext[adapter.PlatformInterface](ctx)
 	var defaultLogWriter io.Writer
 	if platformInterface != nil {
@@ -181,6 +200,11 @@ func New(options Options) (*Box, error) {
 	service.MustRegister[adapter.OutboundManager](ctx, outboundManager)
 	service.MustRegister[adapter.DNSTransportManager](ctx, dnsTransportManager)
 	service.MustRegister[adapter.ServiceManager](ctx, serviceManager)
+	if dnsOptions.QueryLog != nil && dnsOptions.QueryLog.Enabled {
+		queryLog := dns.NewQueryLog(ctx, logFactory.NewLogger("dns/query-log"), *dnsOptions.QueryLog)
+		service.MustRegister[adapter.DNSQueryLog](ctx, queryLog)
+		internalServices = append(internalServices, queryLog)
+	}
 	dnsRouter := dns.NewRouter(ctx, logFactory, dnsOptions)
 	service.MustRegister[adapter.DNSRouter](ctx, dnsRouter)
 	networkManager, err := route.NewNetworkManager(ctx, logFactory.NewLogger("network"), routeOptions, dnsOptions)
@@ -190,6 +214,8 @@ func New(options Options) (*Box, error) {
 	service.MustRegister[adapter.NetworkManager](ctx, networkManager)
 	connectionManager := route.NewConnectionManager(logFactory.NewLogger("connection"))
 	service.MustRegister[adapter.ConnectionManager](ctx, connectionManager)
+	trafficQuotaManager := trafficquota.NewManager(ctx, logFactory.NewLogger("traffic-quota"))
+	service.MustRegister[adapter.TrafficQuotaManager](ctx, trafficQuotaManager)
 	router := route.NewRouter(ctx, logFactory, routeOptions, dnsOptions)
 	service.MustRegister[adapter.Router](ctx, router)
 	err = router.Initialize(routeOptions.Rules, routeOptions.RuleSet)
@@ -198,6 +224,23 @@ func New(options Options) (*Box, error) {
 	}
 	ntpOptions := common.PtrValueOrDefault(options.NTP)
 	var timeService *tls.TimeServiceWrapper
+	if needMetrics {
+		metricsServer, err := metrics.NewServer(ctx, logFactory.NewLogger("metrics"), common.PtrValueOrDefault(experimentalOptions.Metrics))
+		if err != nil {
+			return nil, E.Cause(err, "create metrics-server")
+		}
+		router.AppendTracker(metricsServer)
+		dnsRouter.AppendTracker(metricsServer)
+		internalServices = append(internalServices, metricsServer)
+	}
+	if experimentalOptions.ConnectionExport != nil {
+		connectionExporter, err := connexport.NewExporter(ctx, logFactory.NewLogger("connection-export"), *experimentalOptions.ConnectionExport)
+		if err != nil {
+			return nil, E.Cause(err, "create connection exporter")
+		}
+		router.AppendTracker(connectionExporter)
+		internalServices = append(internalServices, connectionExporter)
+	}
 	if ntpOptions.Enabled {
 		timeService = new(tls.TimeServiceWrapper)
 		service.MustRegister[ntp.TimeService](ctx, timeService)
@@ -295,6 +338,11 @@ func New(options Options) (*Box, error) {
 			return nil, E.Cause(err, "initialize outbound[", i, "]")
 		}
 	}
+	providerManager, err := provider.NewManager(ctx, logFactory, options.OutboundProviders)
+	if err != nil {
+		return nil, E.Cause(err, "initialize outbound providers")
+	}
+	service.MustRegister[adapter.OutboundProviderManager](ctx, providerManager)
 	for i, serviceOptions := range options.Services {
 		var tag string
 		if serviceOptions.Tag != "" {
@@ -380,10 +428,14 @@ func New(options Options) (*Box, error) {
 		internalServices = append(internalServices, adapter.NewLifecycleService(ntpService, "ntp service"))
 	}
 	return &Box{
+		ctx:             ctx,
+		options:         options.Options,
 		network:         networkManager,
 		endpoint:        endpointManager,
 		inbound:         inboundManager,
 		outbound:        outboundManager,
+		provider:        providerManager,
+		trafficQuota:    trafficQuotaManager,
 		dnsTransport:    dnsTransportManager,
 		service:         serviceManager,
 		dnsRouter:       dnsRouter,
@@ -447,7 +499,7 @@ func (s *Box) preStart() error {
 	if err != nil {
 		return err
 	}
-	err = adapter.Start(s.logger, adapter.StartStateInitialize, s.network, s.dnsTransport, s.dnsRouter, s.connection, s.router, s.outbound, s.inbound, s.endpoint, s.service)
+	err = adapter.Start(s.logger, adapter.StartStateInitialize, s.network, s.dnsTransport, s.dnsRouter, s.connection, s.router, s.trafficQuota, s.provider, s.outbound, s.inbound, s.endpoint, s.service)
 	if err != nil {
 		return err
 	}
@@ -471,7 +523,7 @@ func (s *Box) start() error {
 	if err != nil {
 		return err
 	}
-	err = adapter.Start(s.logger, adapter.StartStatePostStart, s.outbound, s.network, s.dnsTransport, s.dnsRouter, s.connection, s.router, s.inbound, s.endpoint, s.service)
+	err = adapter.Start(s.logger, adapter.StartStatePostStart, s.outbound, s.provider, s.network, s.dnsTransport, s.dnsRouter, s.connection, s.router, s.inbound, s.endpoint, s.service)
 	if err != nil {
 		return err
 	}
@@ -505,6 +557,8 @@ func (s *Box) Close() error {
 		{"service", s.service},
 		{"endpoint", s.endpoint},
 		{"inbound", s.inbound},
+		{"traffic-quota", s.trafficQuota},
+		{"outbound-provider", s.provider},
 		{"outbound", s.outbound},
 		{"router", s.router},
 		{"connection", s.connection},
@@ -0,0 +1,492 @@
+package box
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/experimental"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/service"
+)
+
+var ErrReloadRequiresRestart = E.New("configuration change requires restart")
+
+// Reload applies the difference between the running configuration and the given one
+// without restarting unchanged inbounds, outbounds, endpoints, DNS servers, rule-sets and services.
+// ErrReloadRequiresRestart is returned if the change can only be applied by a full restart.
+func (s *Box) Reload(options option.Options) error {
+	s.reloadAccess.Lock()
+	defer s.reloadAccess.Unlock()
+	select {
+	case <-s.done:
+		return E.New("box closed")
+	default:
+	}
+	err := s.checkReload(options)
+	if err != nil {
+		return err
+	}
+	reloadAt := time.Now()
+	s.logger.Info("reloading configuration")
+	err = s.reload(options)
+	if err != nil {
+		return E.Cause(err, "reload configuration")
+	}
+	s.options = options
+	s.logger.Info("sing-box reloaded (", F.Seconds(time.Since(reloadAt).Seconds()), "s)")
+	return nil
+}
+
+func (s *Box) checkReload(options option.Options) error {
+	oldRouteOptions := common.PtrValueOrDefault(s.options.Route)
+	newRouteOptions := common.PtrValueOrDefault(options.Route)
+	oldRouteOptions.Rules, oldRouteOptions.RuleSet = nil, nil
+	newRouteOptions.Rules, newRouteOptions.RuleSet = nil, nil
+	oldDNSOptions := common.PtrValueOrDefault(s.options.DNS)
+	newDNSOptions := common.PtrValueOrDefault(options.DNS)
+	oldDNSOptions.Servers, oldDNSOptions.Rules = nil, nil
+	newDNSOptions.Servers, newDNSOptions.Rules = nil, nil
+	for _, item := range []struct {
+		name       string
+		oldOptions any
+		newOptions any
+	}{
+		{"log", s.options.Log, options.Log},
+		{"ntp", s.options.NTP, options.NTP},
+		{"certificate", s.options.Certificate, options.Certificate},
+		{"outbound providers", s.options.OutboundProviders, options.OutboundProviders},
+		{"experimental", s.options.Experimental, options.Experimental},
+		{"route", &oldRouteOptions, &newRouteOptions},
+		{"dns", &oldDNSOptions, &newDNSOptions},
+		{"clash modes", experimental.CalculateClashModeList(s.options), experimental.CalculateClashModeList(options)},
+	} {
+		equals, err := s.equalsOptions(item.oldOptions, item.newOptions)
+		if err != nil {
+			return err
+		}
+		if !equals {
+			return E.Extend(ErrReloadRequiresRestart, item.name, " options changed")
+		}
+	}
+	return nil
+}
+
+func (s *Box) equalsOptions(oldOptions any, newOptions any) (bool, error) {
+	oldContent, err := json.MarshalContext(s.ctx, oldOptions)
+	if err != nil {
+		return false, err
+	}
+	newContent, err := json.MarshalContext(s.ctx, newOptions)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(oldContent, newContent), nil
+}
+
+// diffOptions compares tagged options by their JSON form.
+// Tags of removed items are returned along with tags of added or changed items in configuration order.
+func diffOptions[T any](ctx context.Context, oldList []T, newList []T, tagOf func(index int, it *T) string) (removed []string, updated []string, err error) {
+	oldContent := make(map[string][]byte)
+	for i := range oldList {
+		it := oldList[i]
+		content, err := json.MarshalContext(ctx, &it)
+		if err != nil {
+			return nil, nil, err
+		}
+		oldContent[tagOf(i, &oldList[i])] = content
+	}
+	newTags := make(map[string]bool)
+	for i := range newList {
+		it := newList[i]
+		content, err := json.MarshalContext(ctx, &it)
+		if err != nil {
+			return nil, nil, err
+		}
+		tag := tagOf(i, &newList[i])
+		newTags[tag] = true
+		if !bytes.Equal(oldContent[tag], content) {
+			updated = append(updated, tag)
+		}
+	}
+	for i := range oldList {
+		tag := tagOf(i, &oldList[i])
+		if !newTags[tag] {
+			removed = append(removed, tag)
+		}
+	}
+	return
+}
+
+func optionsTag(index int, tag string) string {
+	if tag != "" {
+		return tag
+	}
+	return F.ToString(index)
+}
+
+// removeByDependencies removes dependents first, failing if the remaining tags depend on each other.
+func removeByDependencies(tags []string, dependedBy func(tag string) []string, remove func(tag string) error) error {
+	pending := common.Uniq(tags)
+	for len(pending) > 0 {
+		var next []string
+		for _, tag := range pending {
+			if len(dependedBy(tag)) > 0 {
+				next = append(next, tag)
+				continue
+			}
+			err := remove(tag)
+			if err != nil {
+				return err
+			}
+		}
+		if len(next) == len(pending) {
+			return E.New("unable to remove ", next[0], ": depended by ", dependedBy(next[0]))
+		}
+		pending = next
+	}
+	return nil
+}
+
+func (s *Box) reload(options option.Options) error {
+	ctx := s.ctx
+	logFactory := s.logFactory
+	routeOptions := common.PtrValueOrDefault(options.Route)
+	dnsOptions := common.PtrValueOrDefault(options.DNS)
+	oldRouteOptions := common.PtrValueOrDefault(s.options.Route)
+	oldDNSOptions := common.PtrValueOrDefault(s.options.DNS)
+
+	serverRemoved, serverUpdated, err := diffOptions(ctx, oldDNSOptions.Servers, dnsOptions.Servers, func(index int, it *option.DNSServerOptions) string {
+		return optionsTag(index, it.Tag)
+	})
+	if err != nil {
+		return err
+	}
+	endpointRemoved, endpointUpdated, err := diffOptions(ctx, s.options.Endpoints, options.Endpoints, func(index int, it *option.Endpoint) string {
+		return optionsTag(index, it.Tag)
+	})
+	if err != nil {
+		return err
+	}
+	outboundRemoved, outboundUpdated, err := diffOptions(ctx, s.options.Outbounds, options.Outbounds, func(index int, it *option.Outbound) string {
+		return optionsTag(index, it.Tag)
+	})
+	if err != nil {
+		return err
+	}
+	inboundRemoved, inboundUpdated, err := diffOptions(ctx, s.options.Inbounds, options.Inbounds, func(index int, it *option.Inbound) string {
+		return optionsTag(index, it.Tag)
+	})
+	if err != nil {
+		return err
+	}
+	serviceRemoved, serviceUpdated, err := diffOptions(ctx, s.options.Services, options.Services, func(index int, it *option.Service) string {
+		return optionsTag(index, it.Tag)
+	})
+	if err != nil {
+		return err
+	}
+
+	// DNS servers may use new outbounds as detour, so failed ones are retried after outbounds are updated
+	serverOptionsByTag := make(map[string]option.DNSServerOptions)
+	for i, serverOptions := range dnsOptions.Servers {
+		serverOptionsByTag[optionsTag(i, serverOptions.Tag)] = serverOptions
+	}
+	createServer := func(tag string) error {
+		serverOptions := serverOptionsByTag[tag]
+		return s.dnsTransport.Create(
+			ctx,
+			logFactory.NewLogger(F.ToString("dns/", serverOptions.Type, "[", tag, "]")),
+			tag,
+			serverOptions.Type,
+			serverOptions.Options,
+		)
+	}
+	var serverPending []string
+	for _, tag := range serverUpdated {
+		if createServer(tag) != nil {
+			serverPending = append(serverPending, tag)
+		}
+	}
+
+	for _, tag := range append(endpointRemoved, endpointUpdated...) {
+		err = s.endpoint.Remove(tag)
+		if err != nil && err != os.ErrInvalid {
+			return E.Cause(err, "remove endpoint[", tag, "]")
+		}
+	}
+	endpointOptionsByTag := make(map[string]option.Endpoint)
+	for i, endpointOptions := range options.Endpoints {
+		endpointOptionsByTag[optionsTag(i, endpointOptions.Tag)] = endpointOptions
+	}
+	for _, tag := range endpointUpdated {
+		endpointOptions := endpointOptionsByTag[tag]
+		err = s.endpoint.Create(
+			adapter.WithContext(ctx, &adapter.InboundContext{
+				Outbound: tag,
+			}),
+			s.router,
+			logFactory.NewLogger(F.ToString("endpoint/", endpointOptions.Type, "[", tag, "]")),
+			tag,
+			endpointOptions.Type,
+			endpointOptions.Options,
+		)
+		if err != nil {
+			return E.Cause(err, "initialize endpoint[", tag, "]")
+		}
+	}
+
+	// groups and detour chains hold references to the outbounds they depend on,
+	// so dependents of replaced outbounds are rebuilt as well
+	outboundOptionsByTag := make(map[string]option.Outbound)
+	for i, outboundOptions := range options.Outbounds {
+		outboundOptionsByTag[optionsTag(i, outboundOptions.Tag)] = outboundOptions
+	}
+	rebuildOutbound := make(map[string]bool)
+	for _, tag := range outboundUpdated {
+		rebuildOutbound[tag] = true
+	}
+	staleOutbounds := slices.Concat(outboundRemoved, outboundUpdated, endpointRemoved, endpointUpdated)
+	for len(staleOutbounds) > 0 {
+		tag := staleOutbounds[0]
+		staleOutbounds = staleOutbounds[1:]
+		for _, dependent := range s.outbound.DependedBy(tag) {
+			if rebuildOutbound[dependent] {
+				continue
+			}
+			if _, loaded := outboundOptionsByTag[dependent]; !loaded {
+				continue
+			}
+			rebuildOutbound[dependent] = true
+			staleOutbounds = append(staleOutbounds, dependent)
+		}
+	}
+	outboundRegistry := service.FromContext[adapter.OutboundRegistry](ctx)
+	var newOutbounds []adapter.Outbound
+	for i, outboundOptions := range options.Outbounds {
+		tag := optionsTag(i, outboundOptions.Tag)
+		if !rebuildOutbound[tag] {
+			continue
+		}
+		outbound, err := outboundRegistry.CreateOutbound(
+			adapter.WithContext(ctx, &adapter.InboundContext{
+				Outbound: tag,
+			}),
+			s.router,
+			logFactory.NewLogger(F.ToString("outbound/", outboundOptions.Type, "[", tag, "]")),
+			tag,
+			outboundOptions.Type,
+			outboundOptions.Options,
+		)
+		if err != nil {
+			for _, created := range newOutbounds {
+				common.Close(created)
+			}
+			return E.Cause(err, "initialize outbound[", tag, "]")
+		}
+		newOutbounds = append(newOutbounds, outbound)
+	}
+	newOutbounds, err = sortOutbounds(newOutbounds)
+	if err != nil {
+		return err
+	}
+	for _, outbound := range newOutbounds {
+		err = s.outbound.Add(outbound)
+		if err != nil {
+			return E.Cause(err, "initialize outbound[", outbound.Tag(), "]")
+		}
+	}
+	err = removeByDependencies(outboundRemoved, s.outbound.DependedBy, s.outbound.Remove)
+	if err != nil {
+		return E.Cause(err, "remove outbound")
+	}
+
+	for _, tag := range serverPending {
+		err = createServer(tag)
+		if err != nil {
+			return E.Cause(err, "initialize DNS server[", tag, "]")
+		}
+	}
+	err = removeByDependencies(serverRemoved, s.dnsTransport.DependedBy, s.dnsTransport.Remove)
+	if err != nil {
+		return E.Cause(err, "remove DNS server")
+	}
+
+	err = s.reloadRules(oldRouteOptions, routeOptions, oldDNSOptions, dnsOptions)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range append(inboundRemoved, inboundUpdated...) {
+		err = s.inbound.Remove(tag)
+		if err != nil && err != os.ErrInvalid {
+			return E.Cause(err, "remove inbound[", tag, "]")
+		}
+	}
+	inboundOptionsByTag := make(map[string]option.Inbound)
+	for i, inboundOptions := range options.Inbounds {
+		inboundOptionsByTag[optionsTag(i, inboundOptions.Tag)] = inboundOptions
+	}
+	for _, tag := range inboundUpdated {
+		inboundOptions := inboundOptionsByTag[tag]
+		err = s.inbound.Create(
+			ctx,
+			s.router,
+			logFactory.NewLogger(F.ToString("inbound/", inboundOptions.Type, "[", tag, "]")),
+			tag,
+			inboundOptions.Type,
+			inboundOptions.Options,
+		)
+		if err != nil {
+			return E.Cause(err, "initialize inbound[", tag, "]")
+		}
+	}
+
+	for _, tag := range append(serviceRemoved, serviceUpdated...) {
+		err = s.service.Remove(tag)
+		if err != nil && err != os.ErrInvalid {
+			return E.Cause(err, "remove service[", tag, "]")
+		}
+	}
+	serviceOptionsByTag := make(map[string]option.Service)
+	for i, serviceOptions := range options.Services {
+		serviceOptionsByTag[optionsTag(i, serviceOptions.Tag)] = serviceOptions
+	}
+	for _, tag := range serviceUpdated {
+		serviceOptions := serviceOptionsByTag[tag]
+		err = s.service.Create(
+			ctx,
+			logFactory.NewLogger(F.ToString("service/", serviceOptions.Type, "[", tag, "]")),
+			tag,
+			serviceOptions.Type,
+			serviceOptions.Options,
+		)
+		if err != nil {
+			return E.Cause(err, "initialize service[", tag, "]")
+		}
+	}
+	return nil
+}
+
+func (s *Box) reloadRules(oldRouteOptions option.RouteOptions, routeOptions option.RouteOptions, oldDNSOptions option.DNSOptions, dnsOptions option.DNSOptions) error {
+	ctx := s.ctx
+	rulesChanged, err := s.equalsOptions(oldRouteOptions.Rules, routeOptions.Rules)
+	if err != nil {
+		return err
+	}
+	rulesChanged = !rulesChanged
+	dnsRulesChanged, err := s.equalsOptions(oldDNSOptions.Rules, dnsOptions.Rules)
+	if err != nil {
+		return err
+	}
+	dnsRulesChanged = !dnsRulesChanged
+	ruleSetRemoved, ruleSetUpdated, err := diffOptions(ctx, oldRouteOptions.RuleSet, routeOptions.RuleSet, func(index int, it *option.RuleSet) string {
+		return it.Tag
+	})
+	if err != nil {
+		return err
+	}
+	if !rulesChanged && !dnsRulesChanged && len(ruleSetRemoved) == 0 && len(ruleSetUpdated) == 0 {
+		return nil
+	}
+	// unreferenced rule-sets are cleaned up after start, so they can not be reused
+	referencedRuleSets := make(map[string]bool)
+	for _, tag := range ruleSetTags(oldRouteOptions.Rules) {
+		referencedRuleSets[tag] = true
+	}
+	for _, tag := range dnsRuleSetTags(oldDNSOptions.Rules) {
+		referencedRuleSets[tag] = true
+	}
+	unchangedRuleSets := make(map[string]bool)
+	for _, ruleSetOptions := range routeOptions.RuleSet {
+		if referencedRuleSets[ruleSetOptions.Tag] && !common.Contains(ruleSetUpdated, ruleSetOptions.Tag) {
+			unchangedRuleSets[ruleSetOptions.Tag] = true
+		}
+	}
+	staleRuleSets, err := s.router.ReloadRuleSets(routeOptions.RuleSet, unchangedRuleSets)
+	if err != nil {
+		return E.Cause(err, "reload rule-set")
+	}
+	if dnsRulesChanged || len(staleRuleSets) > 0 {
+		err = s.dnsRouter.Reload(dnsOptions.Rules)
+		if err != nil {
+			return E.Cause(err, "reload dns rules")
+		}
+	}
+	if rulesChanged || len(staleRuleSets) > 0 {
+		err = s.router.ReloadRules(routeOptions.Rules)
+		if err != nil {
+			return E.Cause(err, "reload rules")
+		}
+	}
+	for _, ruleSet := range staleRuleSets {
+		err = ruleSet.Close()
+		if err != nil {
+			s.logger.Error(E.Cause(err, "close rule-set[", ruleSet.Name(), "]"))
+		}
+	}
+	return nil
+}
+
+func sortOutbounds(outbounds []adapter.Outbound) ([]adapter.Outbound, error) {
+	pending := make(map[string]bool)
+	for _, outbound := range outbounds {
+		pending[outbound.Tag()] = true
+	}
+	sorted := make([]adapter.Outbound, 0, len(outbounds))
+	for len(sorted) < len(outbounds) {
+		canContinue := false
+	sortOne:
+		for _, outbound := range outbounds {
+			if !pending[outbound.Tag()] {
+				continue
+			}
+			for _, dependency := range outbound.Dependencies() {
+				if pending[dependency] {
+					continue sortOne
+				}
+			}
+			delete(pending, outbound.Tag())
+			sorted = append(sorted, outbound)
+			canContinue = true
+		}
+		if !canContinue {
+			return nil, E.New("circular outbound dependency")
+		}
+	}
+	return sorted, nil
+}
+
+func ruleSetTags(rules []option.Rule) []string {
+	var tags []string
+	for _, rule := range rules {
+		switch rule.Type {
+		case C.RuleTypeDefault:
+			tags = append(tags, rule.DefaultOptions.RuleSet...)
+		case C.RuleTypeLogical:
+			tags = append(tags, ruleSetTags(rule.LogicalOptions.Rules)...)
+		}
+	}
+	return tags
+}
+
+func dnsRuleSetTags(rules []option.DNSRule) []string {
+	var tags []string
+	for _, rule := range rules {
+		switch rule.Type {
+		case C.RuleTypeDefault:
+			tags = append(tags, rule.DefaultOptions.RuleSet...)
+		case C.RuleTypeLogical:
+			tags = append(tags, dnsRuleSetTags(rule.LogicalOptions.Rules)...)
+		}
+	}
+	return tags
+}
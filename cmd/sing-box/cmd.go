@@ -22,6 +22,8 @@ var (
 	configDirectories []string
 	workingDir        string
 	disableColor      bool
+	sudoUID           int
+	sudoGID           int
 )
 
 var mainCommand = &cobra.Command{
@@ -37,10 +39,9 @@ func init() {
 }
 
 func preRun(cmd *cobra.Command, args []string) {
-	globalCtx = context.Background()
 	sudoUser := os.Getenv("SUDO_USER")
-	sudoUID, _ := strconv.Atoi(os.Getenv("SUDO_UID"))
-	sudoGID, _ := strconv.Atoi(os.Getenv("SUDO_GID"))
+	sudoUID, _ = strconv.Atoi(os.Getenv("SUDO_UID"))
+	sudoGID, _ = strconv.Atoi(os.Getenv("SUDO_GID"))
 	if sudoUID == 0 && sudoGID == 0 && sudoUser != "" {
 		sudoUserObject, _ := user.Lookup(sudoUser)
 		if sudoUserObject != nil {
@@ -48,12 +49,10 @@ func preRun(cmd *cobra.Command, args []string) {
 			sudoGID, _ = strconv.Atoi(sudoUserObject.Gid)
 		}
 	}
-	if sudoUID > 0 && sudoGID > 0 {
-		globalCtx = filemanager.WithDefault(globalCtx, "", "", sudoUID, sudoGID)
-	}
 	if disableColor {
 		log.SetStdLogger(log.NewDefaultFactory(context.Background(), log.Formatter{BaseTime: time.Now(), DisableColors: true}, os.Stderr, "", nil, false).Logger())
 	}
+	globalCtx = newContext()
 	if workingDir != "" {
 		_, err := os.Stat(workingDir)
 		if err != nil {
@@ -67,5 +66,14 @@ func preRun(cmd *cobra.Command, args []string) {
 	if len(configPaths) == 0 && len(configDirectories) == 0 {
 		configPaths = append(configPaths, "config.json")
 	}
-	globalCtx = include.Context(service.ContextWith(globalCtx, deprecated.NewStderrManager(log.StdLogger())))
+}
+
+// newContext returns a context with a service registry of its own,
+// boxes created from the same context replace each other's services.
+func newContext() context.Context {
+	ctx := context.Background()
+	if sudoUID > 0 && sudoGID > 0 {
+		ctx = filemanager.WithDefault(ctx, "", "", sudoUID, sudoGID)
+	}
+	return include.Context(service.ContextWith(ctx, deprecated.NewStderrManager(log.StdLogger())))
 }
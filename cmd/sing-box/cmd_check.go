@@ -30,7 +30,8 @@ func check() error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithCancel(globalCtx)
+	// a fresh context keeps the services of a running box intact on reload
+	ctx, cancel := context.WithCancel(newContext())
 	instance, err := box.New(box.Options{
 		Context: ctx,
 		Options: options,
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sagernet/sing-box/common/asn"
+	"github.com/sagernet/sing-box/common/srs"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagRuleSetCompileASNDatabase string
+	flagRuleSetCompileASNOutput   string
+)
+
+const flagRuleSetCompileASNDefaultOutput = "asn-<asn>.srs"
+
+var commandRuleSetCompileASN = &cobra.Command{
+	Use:   "compile-asn <asn>...",
+	Short: "Compile autonomous systems to IP CIDR rule-set",
+	Long:  "Compile networks of autonomous systems in a MaxMind-format ASN database to IP CIDR rule-set, output as source if the output path ends with .json",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := compileASNRuleSet(args)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	commandRuleSet.AddCommand(commandRuleSetCompileASN)
+	commandRuleSetCompileASN.Flags().StringVarP(&flagRuleSetCompileASNDatabase, "database", "d", "GeoLite2-ASN.mmdb", "ASN database path")
+	commandRuleSetCompileASN.Flags().StringVarP(&flagRuleSetCompileASNOutput, "output", "o", flagRuleSetCompileASNDefaultOutput, "Output file")
+}
+
+func compileASNRuleSet(asnStrings []string) error {
+	asnList := make([]uint32, 0, len(asnStrings))
+	for _, asnString := range asnStrings {
+		number, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(asnString), "AS"), 10, 32)
+		if err != nil {
+			return E.New("invalid ASN: ", asnString)
+		}
+		asnList = append(asnList, uint32(number))
+	}
+	reader, err := asn.Open(flagRuleSetCompileASNDatabase)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	prefixes, err := reader.Prefixes(asnList)
+	if err != nil {
+		return err
+	}
+	if len(prefixes) == 0 {
+		return E.New("no networks found for ", strings.Join(asnStrings, " "))
+	}
+	var headlessRule option.DefaultHeadlessRule
+	headlessRule.IPCIDR = common.Map(prefixes, func(it netip.Prefix) string {
+		return it.String()
+	})
+	var plainRuleSet option.PlainRuleSetCompat
+	plainRuleSet.Version = C.RuleSetVersion2
+	plainRuleSet.Options.Rules = []option.HeadlessRule{
+		{
+			Type:           C.RuleTypeDefault,
+			DefaultOptions: headlessRule,
+		},
+	}
+	var (
+		outputFile   *os.File
+		outputWriter io.Writer
+		outputPath   string
+	)
+	outputSource := flagRuleSetCompileASNOutput == "stdout" || strings.HasSuffix(flagRuleSetCompileASNOutput, ".json")
+	if flagRuleSetCompileASNOutput == "stdout" {
+		outputWriter = os.Stdout
+	} else {
+		if flagRuleSetCompileASNOutput == flagRuleSetCompileASNDefaultOutput {
+			outputPath = "asn-" + strings.Join(F.MapToString(asnList), "-") + ".srs"
+		} else {
+			outputPath = flagRuleSetCompileASNOutput
+		}
+		outputFile, err = os.Create(outputPath)
+		if err != nil {
+			return err
+		}
+		defer outputFile.Close()
+		outputWriter = outputFile
+	}
+	if outputSource {
+		encoder := json.NewEncoder(outputWriter)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plainRuleSet)
+	}
+	err = srs.Write(outputWriter, plainRuleSet.Options, plainRuleSet.Version)
+	if err != nil {
+		outputFile.Close()
+		os.Remove(outputPath)
+		return err
+	}
+	return nil
+}
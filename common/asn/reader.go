@@ -0,0 +1,80 @@
+package asn
+
+import (
+	"net/netip"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/oschwald/maxminddb-golang"
+	"go4.org/netipx"
+)
+
+// Reader looks up autonomous system numbers in a MaxMind-format ASN database,
+// such as GeoLite2-ASN or DB-IP ASN Lite.
+type Reader struct {
+	reader *maxminddb.Reader
+}
+
+type record struct {
+	Number uint32 `maxminddb:"autonomous_system_number"`
+}
+
+func Open(path string) (*Reader, error) {
+	database, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(strings.ToUpper(database.Metadata.DatabaseType), "ASN") {
+		database.Close()
+		return nil, E.New("incorrect database type, expected an ASN database, got ", database.Metadata.DatabaseType)
+	}
+	return &Reader{database}, nil
+}
+
+func (r *Reader) Lookup(addr netip.Addr) (uint32, bool) {
+	var result record
+	err := r.reader.Lookup(addr.Unmap().AsSlice(), &result)
+	if err != nil || result.Number == 0 {
+		return 0, false
+	}
+	return result.Number, true
+}
+
+// Prefixes returns the merged networks announced by the autonomous systems.
+func (r *Reader) Prefixes(asnList []uint32) ([]netip.Prefix, error) {
+	asnMap := make(map[uint32]bool)
+	for _, number := range asnList {
+		asnMap[number] = true
+	}
+	var builder netipx.IPSetBuilder
+	networks := r.reader.Networks(maxminddb.SkipAliasedNetworks)
+	for networks.Next() {
+		var result record
+		ipNet, err := networks.Network(&result)
+		if err != nil {
+			return nil, err
+		}
+		if !asnMap[result.Number] {
+			continue
+		}
+		prefix, loaded := netipx.FromStdIPNet(ipNet)
+		if !loaded {
+			return nil, E.New("invalid network: ", ipNet)
+		}
+		builder.AddPrefix(prefix)
+	}
+	err := networks.Err()
+	if err != nil {
+		return nil, err
+	}
+	ipSet, err := builder.IPSet()
+	if err != nil {
+		return nil, err
+	}
+	return ipSet.Prefixes(), nil
+}
+
+func (r *Reader) Close() error {
+	return r.reader.Close()
+}
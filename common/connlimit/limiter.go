@@ -0,0 +1,87 @@
+package connlimit
+
+import (
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ adapter.ConnectionLimiter = (*Limiter)(nil)
+
+type Limiter struct {
+	maxConnections int
+	maxDevices     int
+	access         sync.Mutex
+	connections    int
+	devices        map[netip.Addr]int
+}
+
+// New returns nil if neither connections nor devices are limited.
+func New(maxConnections int, maxDevices int) adapter.ConnectionLimiter {
+	if maxConnections <= 0 && maxDevices <= 0 {
+		return nil
+	}
+	return &Limiter{
+		maxConnections: maxConnections,
+		maxDevices:     maxDevices,
+		devices:        make(map[netip.Addr]int),
+	}
+}
+
+func (l *Limiter) Acquire(source netip.Addr) (func(), error) {
+	source = source.Unmap()
+	l.access.Lock()
+	defer l.access.Unlock()
+	if l.maxConnections > 0 && l.connections >= l.maxConnections {
+		return nil, E.New("too many connections (max ", l.maxConnections, ")")
+	}
+	if l.maxDevices > 0 && l.devices[source] == 0 && len(l.devices) >= l.maxDevices {
+		return nil, E.New("too many devices (max ", l.maxDevices, ")")
+	}
+	l.connections++
+	l.devices[source]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.release(source)
+		})
+	}, nil
+}
+
+func (l *Limiter) release(source netip.Addr) {
+	l.access.Lock()
+	defer l.access.Unlock()
+	l.connections--
+	if l.devices[source] <= 1 {
+		delete(l.devices, source)
+	} else {
+		l.devices[source]--
+	}
+}
+
+func (l *Limiter) Connections() int {
+	l.access.Lock()
+	defer l.access.Unlock()
+	return l.connections
+}
+
+func (l *Limiter) Devices() int {
+	l.access.Lock()
+	defer l.access.Unlock()
+	return len(l.devices)
+}
+
+func (l *Limiter) String() string {
+	var descriptions []string
+	if l.maxConnections > 0 {
+		descriptions = append(descriptions, F.ToString("max-connections=", l.maxConnections))
+	}
+	if l.maxDevices > 0 {
+		descriptions = append(descriptions, F.ToString("max-devices=", l.maxDevices))
+	}
+	return strings.Join(descriptions, ",")
+}
@@ -0,0 +1,34 @@
+package connlimit
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, New(0, 0))
+	limiter := New(3, 2)
+	first := netip.MustParseAddr("10.0.0.1")
+	second := netip.MustParseAddr("10.0.0.2")
+	third := netip.MustParseAddr("10.0.0.3")
+	release, err := limiter.Acquire(first)
+	require.NoError(t, err)
+	_, err = limiter.Acquire(netip.MustParseAddr("::ffff:10.0.0.1"))
+	require.NoError(t, err)
+	_, err = limiter.Acquire(second)
+	require.NoError(t, err)
+	require.Equal(t, 3, limiter.Connections())
+	require.Equal(t, 2, limiter.Devices())
+	_, err = limiter.Acquire(second)
+	require.ErrorContains(t, err, "too many connections")
+	release()
+	release()
+	require.Equal(t, 2, limiter.Connections())
+	_, err = limiter.Acquire(third)
+	require.ErrorContains(t, err, "too many devices")
+	_, err = limiter.Acquire(second)
+	require.NoError(t, err)
+}
@@ -20,7 +20,6 @@ type DetourDialer struct {
 	outboundManager adapter.OutboundManager
 	detour          string
 	legacyDNSDialer bool
-	dialer          N.Dialer
 	initOnce        sync.Once
 	initErr         error
 }
@@ -43,7 +42,15 @@ func InitializeDetour(dialer N.Dialer) error {
 
 func (d *DetourDialer) Dialer() (N.Dialer, error) {
 	d.initOnce.Do(d.init)
-	return d.dialer, d.initErr
+	if d.initErr != nil {
+		return nil, d.initErr
+	}
+	// the outbound may be replaced by a configuration reload
+	dialer, loaded := d.outboundManager.Outbound(d.detour)
+	if !loaded {
+		return nil, E.New("outbound detour not found: ", d.detour)
+	}
+	return dialer, nil
 }
 
 func (d *DetourDialer) init() {
@@ -60,7 +67,6 @@ func (d *DetourDialer) init() {
 			}
 		}
 	}
-	d.dialer = dialer
 }
 
 func (d *DetourDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
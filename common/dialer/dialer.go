@@ -104,6 +104,7 @@ func NewWithOptions(options Options) (N.Dialer, error) {
 					return nil, E.New("default domain resolver not found: " + defaultOptions.DomainResolver)
 				}
 				dnsQueryOptions.Transport = transport
+				server = defaultOptions.DomainResolver
 				resolveFallbackDelay = time.Duration(dialOptions.FallbackDelay)
 			} else {
 				transports := dnsTransport.Transports()
@@ -3,10 +3,12 @@ package dialer
 import (
 	"context"
 	"net"
+	"net/netip"
 	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/fastestip"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing/common/bufio"
@@ -74,9 +76,19 @@ type resolveParallelNetworkDialer struct {
 	dialer ParallelInterfaceDialer
 }
 
-func (d *resolveDialer) initialize() error {
+func (d *resolveDialer) initialize() (adapter.DNSQueryOptions, error) {
 	d.initOnce.Do(d.initServer)
-	return d.initErr
+	if d.initErr != nil || d.server == "" {
+		return d.queryOptions, d.initErr
+	}
+	// the server may be replaced by a configuration reload
+	queryOptions := d.queryOptions
+	transport, loaded := d.transport.Transport(d.server)
+	if !loaded {
+		return queryOptions, E.New("domain resolver not found: " + d.server)
+	}
+	queryOptions.Transport = transport
+	return queryOptions, nil
 }
 
 func (d *resolveDialer) initServer() {
@@ -92,35 +104,36 @@ func (d *resolveDialer) initServer() {
 }
 
 func (d *resolveDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
-	err := d.initialize()
+	queryOptions, err := d.initialize()
 	if err != nil {
 		return nil, err
 	}
 	if !destination.IsFqdn() {
+		if addresses := dns64Addresses(ctx, destination); addresses != nil {
+			return N.DialSerial(ctx, d.dialer, network, destination, addresses)
+		}
 		return d.dialer.DialContext(ctx, network, destination)
 	}
-	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
-	addresses, err := d.router.Lookup(ctx, destination.Fqdn, d.queryOptions)
+	addresses, err := d.lookup(ctx, destination.Fqdn, queryOptions)
 	if err != nil {
 		return nil, err
 	}
 	if d.parallel {
-		return N.DialParallel(ctx, d.dialer, network, destination, addresses, d.queryOptions.Strategy == C.DomainStrategyPreferIPv6, d.fallbackDelay)
+		return N.DialParallel(ctx, d.dialer, network, destination, addresses, queryOptions.Strategy == C.DomainStrategyPreferIPv6, d.fallbackDelay)
 	} else {
 		return N.DialSerial(ctx, d.dialer, network, destination, addresses)
 	}
 }
 
 func (d *resolveDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
-	err := d.initialize()
+	queryOptions, err := d.initialize()
 	if err != nil {
 		return nil, err
 	}
 	if !destination.IsFqdn() {
 		return d.dialer.ListenPacket(ctx, destination)
 	}
-	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
-	addresses, err := d.router.Lookup(ctx, destination.Fqdn, d.queryOptions)
+	addresses, err := d.lookup(ctx, destination.Fqdn, queryOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -131,6 +144,16 @@ func (d *resolveDialer) ListenPacket(ctx context.Context, destination M.Socksadd
 	return bufio.NewNATPacketConn(bufio.NewPacketConn(conn), M.SocksaddrFrom(destinationAddress, destination.Port), destination), nil
 }
 
+func (d *resolveDialer) lookup(ctx context.Context, domain string, queryOptions adapter.DNSQueryOptions) ([]netip.Addr, error) {
+	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
+	var outboundTag string
+	if metadata := adapter.ContextFrom(ctx); metadata != nil {
+		outboundTag = metadata.Outbound
+	}
+	ctx = fastestip.ContextWithDialer(ctx, outboundTag, d.dialer)
+	return d.router.Lookup(ctx, domain, queryOptions)
+}
+
 func (d *resolveDialer) QueryOptions() adapter.DNSQueryOptions {
 	return d.queryOptions
 }
@@ -140,15 +163,17 @@ func (d *resolveDialer) Upstream() any {
 }
 
 func (d *resolveParallelNetworkDialer) DialParallelInterface(ctx context.Context, network string, destination M.Socksaddr, strategy *C.NetworkStrategy, interfaceType []C.InterfaceType, fallbackInterfaceType []C.InterfaceType, fallbackDelay time.Duration) (net.Conn, error) {
-	err := d.initialize()
+	queryOptions, err := d.initialize()
 	if err != nil {
 		return nil, err
 	}
 	if !destination.IsFqdn() {
+		if addresses := dns64Addresses(ctx, destination); addresses != nil {
+			return DialSerialNetwork(ctx, d.dialer, network, destination, addresses, strategy, interfaceType, fallbackInterfaceType, fallbackDelay)
+		}
 		return d.dialer.DialContext(ctx, network, destination)
 	}
-	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
-	addresses, err := d.router.Lookup(ctx, destination.Fqdn, d.queryOptions)
+	addresses, err := d.lookup(ctx, destination.Fqdn, queryOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -156,22 +181,21 @@ func (d *resolveParallelNetworkDialer) DialParallelInterface(ctx context.Context
 		fallbackDelay = d.fallbackDelay
 	}
 	if d.parallel {
-		return DialParallelNetwork(ctx, d.dialer, network, destination, addresses, d.queryOptions.Strategy == C.DomainStrategyPreferIPv6, strategy, interfaceType, fallbackInterfaceType, fallbackDelay)
+		return DialParallelNetwork(ctx, d.dialer, network, destination, addresses, queryOptions.Strategy == C.DomainStrategyPreferIPv6, strategy, interfaceType, fallbackInterfaceType, fallbackDelay)
 	} else {
 		return DialSerialNetwork(ctx, d.dialer, network, destination, addresses, strategy, interfaceType, fallbackInterfaceType, fallbackDelay)
 	}
 }
 
 func (d *resolveParallelNetworkDialer) ListenSerialInterfacePacket(ctx context.Context, destination M.Socksaddr, strategy *C.NetworkStrategy, interfaceType []C.InterfaceType, fallbackInterfaceType []C.InterfaceType, fallbackDelay time.Duration) (net.PacketConn, error) {
-	err := d.initialize()
+	queryOptions, err := d.initialize()
 	if err != nil {
 		return nil, err
 	}
 	if !destination.IsFqdn() {
 		return d.dialer.ListenPacket(ctx, destination)
 	}
-	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
-	addresses, err := d.router.Lookup(ctx, destination.Fqdn, d.queryOptions)
+	addresses, err := d.lookup(ctx, destination.Fqdn, queryOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -192,3 +216,13 @@ func (d *resolveParallelNetworkDialer) QueryOptions() adapter.DNSQueryOptions {
 func (d *resolveParallelNetworkDialer) Upstream() any {
 	return d.dialer
 }
+
+// dns64Addresses returns the destination mapped back from a DNS64 address followed by the synthesized address,
+// so that the NAT64 of the network is used when there is no IPv4 access.
+func dns64Addresses(ctx context.Context, destination M.Socksaddr) []netip.Addr {
+	metadata := adapter.ContextFrom(ctx)
+	if metadata == nil || !metadata.DNS64 || metadata.Destination != destination {
+		return nil
+	}
+	return []netip.Addr{destination.Addr, metadata.OriginDestination.Addr}
+}
@@ -0,0 +1,93 @@
+package dns64
+
+import (
+	"net/netip"
+)
+
+// IPv4-embedded IPv6 addresses (RFC 6052) and NAT64 prefix discovery (RFC 7050).
+
+var (
+	WellKnownPrefix = netip.MustParsePrefix("64:ff9b::/96")
+
+	wellKnownAddresses = []netip.Addr{
+		netip.AddrFrom4([4]byte{192, 0, 0, 170}),
+		netip.AddrFrom4([4]byte{192, 0, 0, 171}),
+	}
+	prefixLengths = []int{96, 64, 56, 48, 40, 32}
+)
+
+// WellKnownName is queried for AAAA records to discover the NAT64 prefix of the network.
+const WellKnownName = "ipv4only.arpa."
+
+func ValidPrefix(prefix netip.Prefix) bool {
+	if !prefix.IsValid() || !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return false
+	}
+	switch prefix.Bits() {
+	case 32, 40, 48, 56, 64, 96:
+		return true
+	default:
+		return false
+	}
+}
+
+// Synthesize embeds the IPv4 address into the prefix, skipping the reserved bits 64 to 71.
+func Synthesize(prefix netip.Prefix, address netip.Addr) netip.Addr {
+	content := prefix.Masked().Addr().As16()
+	ipv4 := address.As4()
+	position := prefix.Bits() / 8
+	for _, b := range ipv4 {
+		if position == 8 {
+			position++
+		}
+		content[position] = b
+		position++
+	}
+	return netip.AddrFrom16(content)
+}
+
+// Extract returns the IPv4 address embedded in an address of the prefix.
+func Extract(prefix netip.Prefix, address netip.Addr) (netip.Addr, bool) {
+	if !address.Is6() || address.Is4In6() || !prefix.Contains(address) {
+		return netip.Addr{}, false
+	}
+	content := address.As16()
+	var ipv4 [4]byte
+	position := prefix.Bits() / 8
+	for i := range ipv4 {
+		if position == 8 {
+			position++
+		}
+		ipv4[i] = content[position]
+		position++
+	}
+	return netip.AddrFrom4(ipv4), true
+}
+
+// DiscoverPrefixes finds the prefixes used to synthesize the AAAA records of WellKnownName.
+func DiscoverPrefixes(addresses []netip.Addr) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, address := range addresses {
+		if !address.Is6() || address.Is4In6() {
+			continue
+		}
+	findPrefix:
+		for _, bits := range prefixLengths {
+			prefix := netip.PrefixFrom(address, bits).Masked()
+			ipv4, _ := Extract(prefix, address)
+			for _, wellKnownAddress := range wellKnownAddresses {
+				if ipv4 != wellKnownAddress {
+					continue
+				}
+				for _, existing := range prefixes {
+					if existing == prefix {
+						break findPrefix
+					}
+				}
+				prefixes = append(prefixes, prefix)
+				break findPrefix
+			}
+		}
+	}
+	return prefixes
+}
@@ -0,0 +1,47 @@
+package dns64
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSynthesize(t *testing.T) {
+	t.Parallel()
+	ipv4 := netip.MustParseAddr("192.0.2.33")
+	// examples of RFC 6052 section 2.4
+	for prefix, expected := range map[string]string{
+		"2001:db8::/32":          "2001:db8:c000:221::",
+		"2001:db8:100::/40":      "2001:db8:1c0:2:21::",
+		"2001:db8:122::/48":      "2001:db8:122:c000:2:2100::",
+		"2001:db8:122:300::/56":  "2001:db8:122:3c0:0:221::",
+		"2001:db8:122:344::/64":  "2001:db8:122:344:c0:2:2100:0",
+		"2001:db8:122:344::/96":  "2001:db8:122:344::c000:221",
+		WellKnownPrefix.String(): "64:ff9b::c000:221",
+	} {
+		parsedPrefix := netip.MustParsePrefix(prefix)
+		require.True(t, ValidPrefix(parsedPrefix))
+		address := Synthesize(parsedPrefix, ipv4)
+		require.Equal(t, netip.MustParseAddr(expected), address, prefix)
+		extracted, loaded := Extract(parsedPrefix, address)
+		require.True(t, loaded)
+		require.Equal(t, ipv4, extracted)
+	}
+	_, loaded := Extract(WellKnownPrefix, netip.MustParseAddr("2001:db8::1"))
+	require.False(t, loaded)
+	require.False(t, ValidPrefix(netip.MustParsePrefix("2001:db8::/80")))
+	require.False(t, ValidPrefix(netip.MustParsePrefix("10.0.0.0/8")))
+}
+
+func TestDiscoverPrefixes(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, []netip.Prefix{WellKnownPrefix}, DiscoverPrefixes([]netip.Addr{
+		netip.MustParseAddr("64:ff9b::c000:aa"),
+		netip.MustParseAddr("64:ff9b::c000:ab"),
+	}))
+	require.Equal(t, []netip.Prefix{netip.MustParsePrefix("2001:db8:122::/48")}, DiscoverPrefixes([]netip.Addr{
+		Synthesize(netip.MustParsePrefix("2001:db8:122::/48"), netip.MustParseAddr("192.0.0.171")),
+	}))
+	require.Empty(t, DiscoverPrefixes([]netip.Addr{netip.MustParseAddr("2001:db8::1")}))
+}
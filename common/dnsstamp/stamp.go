@@ -0,0 +1,231 @@
+package dnsstamp
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+const prefix = "sdns://"
+
+type Protocol uint8
+
+const (
+	ProtocolPlain         Protocol = 0x00
+	ProtocolDNSCrypt      Protocol = 0x01
+	ProtocolDoH           Protocol = 0x02
+	ProtocolODoHTarget    Protocol = 0x05
+	ProtocolDNSCryptRelay Protocol = 0x81
+	ProtocolODoHRelay     Protocol = 0x85
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolPlain:
+		return "plain"
+	case ProtocolDNSCrypt:
+		return "dnscrypt"
+	case ProtocolDoH:
+		return "doh"
+	case ProtocolODoHTarget:
+		return "odoh-target"
+	case ProtocolDNSCryptRelay:
+		return "dnscrypt-relay"
+	case ProtocolODoHRelay:
+		return "odoh-relay"
+	default:
+		return "unknown"
+	}
+}
+
+// Stamp is a DNS stamp (https://dnscrypt.info/stamps-specifications) describing how to reach a resolver or a relay.
+type Stamp struct {
+	Protocol     Protocol
+	Props        uint64
+	Address      string
+	PublicKey    []byte
+	ProviderName string
+	Hashes       [][]byte
+	Hostname     string
+	Path         string
+	Bootstrap    []string
+}
+
+func Parse(content string) (*Stamp, error) {
+	if !strings.HasPrefix(content, prefix) {
+		return nil, E.New("missing ", prefix, " prefix")
+	}
+	binary, err := base64.RawURLEncoding.DecodeString(content[len(prefix):])
+	if err != nil {
+		return nil, E.Cause(err, "decode stamp")
+	}
+	if len(binary) == 0 {
+		return nil, E.New("empty stamp")
+	}
+	reader := &stampReader{content: binary[1:]}
+	stamp := &Stamp{Protocol: Protocol(binary[0])}
+	if stamp.Protocol != ProtocolDNSCryptRelay {
+		stamp.Props = reader.readProps()
+	}
+	switch stamp.Protocol {
+	case ProtocolPlain:
+		stamp.Address = reader.readString()
+	case ProtocolDNSCrypt:
+		stamp.Address = reader.readString()
+		stamp.PublicKey = reader.readBytes()
+		stamp.ProviderName = reader.readString()
+	case ProtocolDoH, ProtocolODoHRelay:
+		stamp.Address = reader.readString()
+		stamp.Hashes = reader.readSet()
+		stamp.Hostname = reader.readString()
+		stamp.Path = reader.readString()
+		if !reader.done() {
+			for _, bootstrap := range reader.readSet() {
+				stamp.Bootstrap = append(stamp.Bootstrap, string(bootstrap))
+			}
+		}
+	case ProtocolODoHTarget:
+		stamp.Hostname = reader.readString()
+		stamp.Path = reader.readString()
+	case ProtocolDNSCryptRelay:
+		stamp.Address = reader.readString()
+	default:
+		return nil, E.New("unsupported stamp protocol: ", uint8(stamp.Protocol))
+	}
+	if reader.err != nil {
+		return nil, E.Cause(reader.err, "parse ", stamp.Protocol, " stamp")
+	}
+	if !reader.done() {
+		return nil, E.New("parse ", stamp.Protocol, " stamp: trailing data")
+	}
+	return stamp, nil
+}
+
+func (s *Stamp) String() string {
+	writer := &stampWriter{content: []byte{byte(s.Protocol)}}
+	if s.Protocol != ProtocolDNSCryptRelay {
+		writer.content = binary.LittleEndian.AppendUint64(writer.content, s.Props)
+	}
+	switch s.Protocol {
+	case ProtocolPlain:
+		writer.writeString(s.Address)
+	case ProtocolDNSCrypt:
+		writer.writeString(s.Address)
+		writer.writeBytes(s.PublicKey)
+		writer.writeString(s.ProviderName)
+	case ProtocolDoH, ProtocolODoHRelay:
+		writer.writeString(s.Address)
+		writer.writeSet(s.Hashes)
+		writer.writeString(s.Hostname)
+		writer.writeString(s.Path)
+		if len(s.Bootstrap) > 0 {
+			bootstrap := make([][]byte, 0, len(s.Bootstrap))
+			for _, address := range s.Bootstrap {
+				bootstrap = append(bootstrap, []byte(address))
+			}
+			writer.writeSet(bootstrap)
+		}
+	case ProtocolODoHTarget:
+		writer.writeString(s.Hostname)
+		writer.writeString(s.Path)
+	case ProtocolDNSCryptRelay:
+		writer.writeString(s.Address)
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(writer.content)
+}
+
+type stampReader struct {
+	content []byte
+	err     error
+}
+
+func (r *stampReader) done() bool {
+	return len(r.content) == 0
+}
+
+func (r *stampReader) readProps() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.content) < 8 {
+		r.err = E.New("short props")
+		return 0
+	}
+	props := binary.LittleEndian.Uint64(r.content)
+	r.content = r.content[8:]
+	return props
+}
+
+// readBytes reads a length-prefixed value.
+func (r *stampReader) readBytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.content) < 1 || len(r.content) < 1+int(r.content[0]) {
+		r.err = E.New("short value")
+		return nil
+	}
+	length := int(r.content[0])
+	value := r.content[1 : 1+length]
+	r.content = r.content[1+length:]
+	return value
+}
+
+func (r *stampReader) readString() string {
+	return string(r.readBytes())
+}
+
+// readSet reads a variable length set, where the high bit of each length indicates that more values follow.
+func (r *stampReader) readSet() [][]byte {
+	var values [][]byte
+	for r.err == nil {
+		if len(r.content) < 1 {
+			r.err = E.New("short set")
+			return nil
+		}
+		length := int(r.content[0] &^ 0x80)
+		more := r.content[0]&0x80 != 0
+		if len(r.content) < 1+length {
+			r.err = E.New("short set")
+			return nil
+		}
+		if length > 0 {
+			values = append(values, r.content[1:1+length])
+		}
+		r.content = r.content[1+length:]
+		if !more {
+			break
+		}
+	}
+	return values
+}
+
+type stampWriter struct {
+	content []byte
+}
+
+func (w *stampWriter) writeBytes(value []byte) {
+	w.content = append(w.content, byte(len(value)))
+	w.content = append(w.content, value...)
+}
+
+func (w *stampWriter) writeString(value string) {
+	w.writeBytes([]byte(value))
+}
+
+func (w *stampWriter) writeSet(values [][]byte) {
+	if len(values) == 0 {
+		w.content = append(w.content, 0)
+		return
+	}
+	for i, value := range values {
+		length := byte(len(value))
+		if i < len(values)-1 {
+			length |= 0x80
+		}
+		w.content = append(w.content, length)
+		w.content = append(w.content, value...)
+	}
+}
@@ -0,0 +1,58 @@
+package dnsstamp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	stamp, err := Parse("sdns://AgcAAAAAAAAABzEuMC4wLjEAEmRucy5jbG91ZGZsYXJlLmNvbQovZG5zLXF1ZXJ5")
+	require.NoError(t, err)
+	require.Equal(t, &Stamp{
+		Protocol: ProtocolDoH,
+		Props:    7,
+		Address:  "1.0.0.1",
+		Hostname: "dns.cloudflare.com",
+		Path:     "/dns-query",
+	}, stamp)
+	_, err = Parse("https://dns.cloudflare.com/dns-query")
+	require.Error(t, err)
+	_, err = Parse("sdns://AQcAAAAAAAAA")
+	require.Error(t, err)
+}
+
+func TestStampRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, stamp := range []*Stamp{
+		{
+			Protocol:     ProtocolDNSCrypt,
+			Props:        1,
+			Address:      "127.0.0.1:5443",
+			PublicKey:    make([]byte, 32),
+			ProviderName: "2.dnscrypt-cert.example.com",
+		},
+		{
+			Protocol: ProtocolDNSCryptRelay,
+			Address:  "[::1]:443",
+		},
+		{
+			Protocol: ProtocolODoHTarget,
+			Hostname: "odoh.example.com",
+			Path:     "/dns-query",
+		},
+		{
+			Protocol:  ProtocolODoHRelay,
+			Address:   "127.0.0.1",
+			Hashes:    [][]byte{make([]byte, 32), make([]byte, 32)},
+			Hostname:  "relay.example.com",
+			Path:      "/proxy",
+			Bootstrap: []string{"1.1.1.1"},
+		},
+	} {
+		parsed, err := Parse(stamp.String())
+		require.NoError(t, err)
+		require.Equal(t, stamp, parsed)
+	}
+}
@@ -0,0 +1,270 @@
+package fastestip
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/netip"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-tun/ping"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/contrab/freelru"
+	"github.com/sagernet/sing/contrab/maphash"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	MethodTCP  = "tcp"
+	MethodICMP = "icmp"
+
+	DefaultPort    = 443
+	DefaultTimeout = 500 * time.Millisecond
+
+	resultCapacity        = 4096
+	resultLifetime        = 10 * time.Minute
+	failureResultLifetime = time.Minute
+)
+
+func NewOptions(method string, port uint16, timeout time.Duration, maxAddresses int) (*adapter.FastestIPOptions, error) {
+	switch method {
+	case "":
+		method = MethodTCP
+	case MethodTCP, MethodICMP:
+	default:
+		return nil, E.New("unknown probe method: ", method)
+	}
+	if port == 0 {
+		port = DefaultPort
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if maxAddresses < 0 {
+		return nil, E.New("invalid max_addresses: ", maxAddresses)
+	}
+	return &adapter.FastestIPOptions{
+		Method:       method,
+		Port:         port,
+		Timeout:      timeout,
+		MaxAddresses: maxAddresses,
+	}, nil
+}
+
+var errEarlyConn = E.New("outbound connects lazily")
+
+type resultKey struct {
+	Dialer  string
+	Method  string
+	Address netip.Addr
+	Port    uint16
+}
+
+// Prober orders addresses by the latency of probes sent through the dialer that will connect to them,
+// results are cached per dialer.
+type Prober struct {
+	results freelru.Cache[resultKey, time.Duration]
+}
+
+func NewProber() *Prober {
+	return &Prober{
+		results: common.Must1(freelru.NewSharded[resultKey, time.Duration](resultCapacity, maphash.NewHasher[resultKey]().Hash32)),
+	}
+}
+
+// Sort returns addresses ordered by latency with unreachable ones last in the original order,
+// only the fastest reachable addresses are kept if MaxAddresses is set.
+func (p *Prober) Sort(ctx context.Context, tag string, dialer N.Dialer, addresses []netip.Addr, options *adapter.FastestIPOptions) []netip.Addr {
+	if len(addresses) < 2 || ctx.Value((*probingKey)(nil)) != nil {
+		return addresses
+	}
+	// dialers returning early connections do not connect before the first write, so they can not be probed
+	dialerResultKey := resultKey{Dialer: tag, Method: options.Method}
+	if _, loaded := p.results.Get(dialerResultKey); loaded {
+		return addresses
+	}
+	ctx = context.WithValue(ctx, (*probingKey)(nil), struct{}{})
+	ctx = log.ContextWithOverrideLevel(ctx, log.LevelDebug)
+	latencies := make([]time.Duration, len(addresses))
+	var (
+		group     sync.WaitGroup
+		earlyConn atomic.Bool
+	)
+	for index, address := range addresses {
+		key := resultKey{tag, options.Method, address, options.Port}
+		if options.Method == MethodICMP {
+			key.Port = 0
+		}
+		latency, loaded := p.results.Get(key)
+		if loaded {
+			latencies[index] = latency
+			continue
+		}
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			latency, err := probe(ctx, dialer, address, options)
+			if errors.Is(err, errEarlyConn) {
+				earlyConn.Store(true)
+				latency = -1
+			} else if err != nil {
+				latency = -1
+				p.results.AddWithLifetime(key, latency, failureResultLifetime)
+			} else {
+				p.results.AddWithLifetime(key, latency, resultLifetime)
+			}
+			latencies[index] = latency
+		}()
+	}
+	group.Wait()
+	if earlyConn.Load() {
+		p.results.AddWithLifetime(dialerResultKey, -1, resultLifetime)
+		return addresses
+	}
+	indexes := make([]int, len(addresses))
+	var reachable int
+	for index := range indexes {
+		indexes[index] = index
+		if latencies[index] >= 0 {
+			reachable++
+		}
+	}
+	if reachable == 0 {
+		return addresses
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		latencyI, latencyJ := latencies[indexes[i]], latencies[indexes[j]]
+		if latencyI < 0 || latencyJ < 0 {
+			return latencyJ < 0 && latencyI >= 0
+		}
+		return latencyI < latencyJ
+	})
+	if options.MaxAddresses > 0 {
+		indexes = indexes[:min(options.MaxAddresses, reachable)]
+	}
+	return common.Map(indexes, func(it int) netip.Addr {
+		return addresses[it]
+	})
+}
+
+type probingKey struct{}
+
+type dialerContext struct {
+	tag    string
+	dialer N.Dialer
+}
+
+type dialerKey struct{}
+
+// ContextWithDialer sets the dialer used to probe addresses resolved in the context.
+func ContextWithDialer(ctx context.Context, tag string, dialer N.Dialer) context.Context {
+	return context.WithValue(ctx, (*dialerKey)(nil), &dialerContext{tag, dialer})
+}
+
+func DialerFromContext(ctx context.Context) (string, N.Dialer, bool) {
+	value, loaded := ctx.Value((*dialerKey)(nil)).(*dialerContext)
+	if !loaded {
+		return "", nil, false
+	}
+	return value.tag, value.dialer, true
+}
+
+type icmpDialer interface {
+	DialerForICMPDestination(destination netip.Addr) net.Dialer
+}
+
+func probe(ctx context.Context, dialer N.Dialer, address netip.Addr, options *adapter.FastestIPOptions) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+	if options.Method == MethodICMP {
+		// ICMP is only available for dialers connecting directly, others are probed by TCP
+		if directDialer, isDirect := common.Cast[icmpDialer](dialer); isDirect {
+			return probeICMP(ctx, directDialer, address)
+		}
+	}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, N.NetworkTCP, M.SocksaddrFrom(address, options.Port))
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	conn.Close()
+	if earlyConn, isEarlyConn := common.Cast[N.EarlyConn](conn); isEarlyConn && earlyConn.NeedHandshake() {
+		return 0, errEarlyConn
+	}
+	return latency, nil
+}
+
+func probeICMP(ctx context.Context, dialer icmpDialer, address netip.Addr) (time.Duration, error) {
+	controlFunc := dialer.DialerForICMPDestination(address).Control
+	conn, err := ping.Connect(ctx, false, controlFunc, address)
+	if err != nil {
+		conn, err = ping.Connect(ctx, true, controlFunc, address)
+		if err != nil {
+			return 0, err
+		}
+	}
+	defer conn.Close()
+	var (
+		protocol    int
+		requestType icmp.Type
+		replyType   icmp.Type
+	)
+	if address.Is4() {
+		protocol, requestType, replyType = 1, ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	} else {
+		protocol, requestType, replyType = 58, ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+	sequence := rand.Intn(0xffff)
+	// the checksum of ICMPv6 is filled by the kernel
+	request, err := (&icmp.Message{
+		Type: requestType,
+		Body: &icmp.Echo{
+			ID:  rand.Intn(0xffff),
+			Seq: sequence,
+		},
+	}).Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+	if deadline, loaded := ctx.Deadline(); loaded {
+		err = conn.SetReadDeadline(deadline)
+		if err != nil {
+			return 0, err
+		}
+	}
+	start := time.Now()
+	err = conn.WriteICMP(buf.As(request).ToOwned())
+	if err != nil {
+		return 0, err
+	}
+	response := buf.NewPacket()
+	defer response.Release()
+	for {
+		response.Reset()
+		err = conn.ReadICMP(response)
+		if err != nil {
+			return 0, err
+		}
+		message, err := icmp.ParseMessage(protocol, response.Bytes())
+		if err != nil || message.Type != replyType {
+			continue
+		}
+		if echo, isEcho := message.Body.(*icmp.Echo); isEcho && echo.Seq == sequence {
+			return time.Since(start), nil
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package fastestip
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+type delayDialer struct {
+	delays map[netip.Addr]time.Duration
+	dials  atomic.Int32
+}
+
+func (d *delayDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	d.dials.Add(1)
+	delay, loaded := d.delays[destination.Addr]
+	if !loaded {
+		return nil, E.New("unreachable")
+	}
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func (d *delayDialer) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	return nil, E.New("unsupported")
+}
+
+func TestProberSort(t *testing.T) {
+	t.Parallel()
+	addresses := []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("192.0.2.2"),
+		netip.MustParseAddr("192.0.2.3"),
+		netip.MustParseAddr("2001:db8::1"),
+	}
+	dialer := &delayDialer{delays: map[netip.Addr]time.Duration{
+		addresses[0]: 200 * time.Millisecond,
+		addresses[2]: 10 * time.Millisecond,
+		addresses[3]: 100 * time.Millisecond,
+	}}
+	options, err := NewOptions("", 0, time.Second, 0)
+	require.NoError(t, err)
+	prober := NewProber()
+	expected := []netip.Addr{addresses[2], addresses[3], addresses[0], addresses[1]}
+	require.Equal(t, expected, prober.Sort(context.Background(), "direct", dialer, addresses, options))
+	require.Equal(t, int32(4), dialer.dials.Load())
+	require.Equal(t, expected, prober.Sort(context.Background(), "direct", dialer, addresses, options))
+	require.Equal(t, int32(4), dialer.dials.Load())
+	options.MaxAddresses = 2
+	require.Equal(t, expected[:2], prober.Sort(context.Background(), "direct", dialer, addresses, options))
+	options.MaxAddresses = 10
+	require.Equal(t, expected[:3], prober.Sort(context.Background(), "direct", dialer, addresses, options))
+}
+
+func TestProberUnreachable(t *testing.T) {
+	t.Parallel()
+	addresses := []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("192.0.2.2"),
+	}
+	options, err := NewOptions(MethodTCP, 80, 50*time.Millisecond, 1)
+	require.NoError(t, err)
+	dialer := &delayDialer{delays: map[netip.Addr]time.Duration{
+		addresses[0]: time.Second,
+	}}
+	require.Equal(t, addresses, NewProber().Sort(context.Background(), "direct", dialer, addresses, options))
+}
+
+type earlyDialer struct {
+	delayDialer
+}
+
+type earlyConn struct {
+	net.Conn
+}
+
+func (c *earlyConn) NeedHandshake() bool {
+	return true
+}
+
+func (d *earlyDialer) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	conn, err := d.delayDialer.DialContext(ctx, network, destination)
+	if err != nil {
+		return nil, err
+	}
+	return &earlyConn{conn}, nil
+}
+
+func TestProberEarlyConn(t *testing.T) {
+	t.Parallel()
+	addresses := []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("192.0.2.2"),
+	}
+	options, err := NewOptions("", 0, time.Second, 0)
+	require.NoError(t, err)
+	dialer := &earlyDialer{delayDialer{delays: map[netip.Addr]time.Duration{
+		addresses[0]: 100 * time.Millisecond,
+		addresses[1]: 10 * time.Millisecond,
+	}}}
+	prober := NewProber()
+	require.Equal(t, addresses, prober.Sort(context.Background(), "shadowsocks", dialer, addresses, options))
+	require.Equal(t, int32(2), dialer.dials.Load())
+	require.Equal(t, addresses, prober.Sort(context.Background(), "shadowsocks", dialer, addresses, options))
+	require.Equal(t, int32(2), dialer.dials.Load())
+}
+
+func TestNewOptions(t *testing.T) {
+	t.Parallel()
+	options, err := NewOptions("", 0, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, &adapter.FastestIPOptions{Method: MethodTCP, Port: DefaultPort, Timeout: DefaultTimeout}, options)
+	_, err = NewOptions("udp", 0, 0, 0)
+	require.Error(t, err)
+	_, err = NewOptions("", 0, 0, -1)
+	require.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package ja3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ja4EmptyHash = "000000000000"
+	ja4HashLen   = 12
+)
+
+// JA4 returns the JA4 fingerprint of the ClientHello, see https://github.com/FoxIO-LLC/ja4.
+func (j *ClientHello) JA4(quic bool) string {
+	var builder strings.Builder
+	if quic {
+		builder.WriteByte('q')
+	} else {
+		builder.WriteByte('t')
+	}
+	builder.WriteString(ja4Version(j.Version, j.Versions))
+	if j.ServerName != "" {
+		builder.WriteByte('d')
+	} else {
+		builder.WriteByte('i')
+	}
+	cipherSuites := filterGrease(j.CipherSuites)
+	extensions := filterGrease(j.Extensions)
+	builder.WriteString(ja4Count(len(cipherSuites)))
+	builder.WriteString(ja4Count(len(extensions)))
+	builder.WriteString(ja4ALPN(j.ALPNProtocols))
+	builder.WriteByte('_')
+	slices.Sort(cipherSuites)
+	builder.WriteString(ja4Hash(ja4HexList(cipherSuites)))
+	builder.WriteByte('_')
+	extensions = slices.DeleteFunc(extensions, func(it uint16) bool {
+		return it == sniExtensionType || it == alpnExtensionType
+	})
+	if len(extensions) == 0 {
+		builder.WriteString(ja4EmptyHash)
+	} else {
+		slices.Sort(extensions)
+		extensionString := ja4HexList(extensions)
+		signatureAlgorithms := filterGrease(j.SignatureAlgorithms)
+		if len(signatureAlgorithms) > 0 {
+			extensionString += "_" + ja4HexList(signatureAlgorithms)
+		}
+		builder.WriteString(ja4Hash(extensionString))
+	}
+	return builder.String()
+}
+
+func filterGrease(values []uint16) []uint16 {
+	filtered := make([]uint16, 0, len(values))
+	for _, value := range values {
+		if value&GreaseBitmask != 0x0A0A {
+			filtered = append(filtered, value)
+		}
+	}
+	return filtered
+}
+
+func ja4Version(version uint16, versions []uint16) string {
+	for _, supportedVersion := range filterGrease(versions) {
+		if supportedVersion > version {
+			version = supportedVersion
+		}
+	}
+	switch version {
+	case tls13:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	default:
+		return "00"
+	}
+}
+
+func ja4Count(count int) string {
+	if count > 99 {
+		count = 99
+	}
+	if count < 10 {
+		return "0" + strconv.Itoa(count)
+	}
+	return strconv.Itoa(count)
+}
+
+func ja4ALPN(protocols []string) string {
+	if len(protocols) == 0 || protocols[0] == "" {
+		return "00"
+	}
+	protocol := protocols[0]
+	first, last := protocol[0], protocol[len(protocol)-1]
+	if !isAlphanumeric(first) || !isAlphanumeric(last) {
+		protocolHex := hex.EncodeToString([]byte(protocol))
+		return protocolHex[:1] + protocolHex[len(protocolHex)-1:]
+	}
+	return string([]byte{first, last})
+}
+
+func isAlphanumeric(char byte) bool {
+	return char >= '0' && char <= '9' || char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z'
+}
+
+func ja4HexList(values []uint16) string {
+	hexValues := make([]string, 0, len(values))
+	for _, value := range values {
+		hexValues = append(hexValues, hex.EncodeToString([]byte{byte(value >> 8), byte(value)}))
+	}
+	return strings.Join(hexValues, ",")
+}
+
+func ja4Hash(value string) string {
+	if value == "" {
+		return ja4EmptyHash
+	}
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])[:ja4HashLen]
+}
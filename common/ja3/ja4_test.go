@@ -0,0 +1,44 @@
+package ja3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+	clientHello := &ClientHello{
+		Version:             0x0303,
+		CipherSuites:        []uint16{0x0a0a, 0x1301, 0x1302, 0x1303, 0xc02b},
+		Extensions:          []uint16{0x2a2a, 0x0000, 0x0010, 0x000d, 0x002b, 0x000a, 0x0033},
+		EllipticCurves:      []uint16{0x1a1a, 29, 23},
+		EllipticCurvePF:     []uint8{0},
+		Versions:            []uint16{0x1a1a, 0x0304, 0x0303},
+		SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401},
+		ALPNProtocols:       []string{"h2", "http/1.1"},
+		ServerName:          "example.com",
+	}
+	require.Equal(t, "771,4865-4866-4867-49195,0-16-13-43-10-51,29-23,0", clientHello.String())
+	require.Equal(t, "cd9cff3a82ee6f04e210f4aa7e5021a1", clientHello.Hash())
+	require.Equal(t, "t13d0406h2_39e807bd56df_5d4d534e3685", clientHello.JA4(false))
+	require.Equal(t, "q13d0406h2_39e807bd56df_5d4d534e3685", clientHello.JA4(true))
+}
+
+func TestFingerprintMinimal(t *testing.T) {
+	t.Parallel()
+	clientHello := &ClientHello{
+		Version:      0x0303,
+		CipherSuites: []uint16{0x0035, 0x002f},
+		Extensions:   []uint16{0x0010},
+	}
+	require.Equal(t, "t12i020100_f54dd463d39b_000000000000", clientHello.JA4(false))
+}
+
+func TestJA4ALPN(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "00", ja4ALPN(nil))
+	require.Equal(t, "h1", ja4ALPN([]string{"http/1.1"}))
+	require.Equal(t, "33", ja4ALPN([]string{"3"}))
+	require.Equal(t, "c9", ja4ALPN([]string{"\xc0\x89"}))
+}
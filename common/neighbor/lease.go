@@ -0,0 +1,88 @@
+package neighbor
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+type lease struct {
+	Address      netip.Addr
+	HardwareAddr net.HardwareAddr
+	Hostname     string
+}
+
+// parseLeases parses dnsmasq and ISC dhcpd lease files, later leases override earlier ones.
+func parseLeases(content []byte) []lease {
+	if bytes.Contains(content, []byte("lease ")) && bytes.Contains(content, []byte("{")) {
+		return parseDHCPDLeases(content)
+	}
+	return parseDnsmasqLeases(content)
+}
+
+// dnsmasq: <expiry> <mac> <address> <hostname> <client id>
+func parseDnsmasqLeases(content []byte) []lease {
+	var leases []lease
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		address, err := netip.ParseAddr(fields[2])
+		if err != nil {
+			continue
+		}
+		var hardwareAddr net.HardwareAddr
+		// DHCPv6 leases have IAID instead of MAC address
+		if address.Is4() {
+			hardwareAddr, _ = net.ParseMAC(fields[1])
+		}
+		var hostname string
+		if fields[3] != "*" {
+			hostname = fields[3]
+		}
+		leases = append(leases, lease{address, hardwareAddr, hostname})
+	}
+	return leases
+}
+
+// ISC dhcpd: lease <address> { hardware ethernet <mac>; client-hostname "<hostname>"; }
+func parseDHCPDLeases(content []byte) []lease {
+	var (
+		leases  []lease
+		current *lease
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if current == nil {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "lease" {
+				address, err := netip.ParseAddr(fields[1])
+				if err == nil {
+					current = &lease{Address: address}
+				}
+			}
+			continue
+		}
+		if line == "}" {
+			leases = append(leases, *current)
+			current = nil
+			continue
+		}
+		line = strings.TrimSuffix(line, ";")
+		switch {
+		case strings.HasPrefix(line, "hardware ethernet "):
+			current.HardwareAddr, _ = net.ParseMAC(strings.TrimPrefix(line, "hardware ethernet "))
+		case strings.HasPrefix(line, "client-hostname "):
+			current.Hostname = strings.Trim(strings.TrimPrefix(line, "client-hostname "), `"`)
+		}
+	}
+	return leases
+}
@@ -0,0 +1,52 @@
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDnsmasqLeases(t *testing.T) {
+	t.Parallel()
+	content := `1760000000 aa:bb:cc:dd:ee:01 192.168.1.10 laptop 01:aa:bb:cc:dd:ee:01
+1760000000 aa:bb:cc:dd:ee:02 192.168.1.11 * *
+duid 00:01:00:01:2c:00:00:00:aa:bb:cc:dd:ee:01
+1760000000 1234 fd00::10 laptop 00:01:00:01:2c:00:00:00:aa:bb:cc:dd:ee:01
+`
+	leases := parseLeases([]byte(content))
+	require.Equal(t, []lease{
+		{netip.MustParseAddr("192.168.1.10"), mustParseMAC("aa:bb:cc:dd:ee:01"), "laptop"},
+		{netip.MustParseAddr("192.168.1.11"), mustParseMAC("aa:bb:cc:dd:ee:02"), ""},
+		{netip.MustParseAddr("fd00::10"), nil, "laptop"},
+	}, leases)
+}
+
+func TestParseDHCPDLeases(t *testing.T) {
+	t.Parallel()
+	content := `# The format of this file is documented in the dhcpd.leases(5) manual page.
+lease 192.168.1.20 {
+  starts 4 2026/10/15 08:00:00;
+  binding state active;
+  hardware ethernet aa:bb:cc:dd:ee:03;
+  client-hostname "phone";
+}
+lease 192.168.1.21 {
+  hardware ethernet aa:bb:cc:dd:ee:04;
+}
+`
+	leases := parseLeases([]byte(content))
+	require.Equal(t, []lease{
+		{netip.MustParseAddr("192.168.1.20"), mustParseMAC("aa:bb:cc:dd:ee:03"), "phone"},
+		{netip.MustParseAddr("192.168.1.21"), mustParseMAC("aa:bb:cc:dd:ee:04"), ""},
+	}, leases)
+}
+
+func mustParseMAC(s string) net.HardwareAddr {
+	hardwareAddr, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return hardwareAddr
+}
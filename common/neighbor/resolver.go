@@ -0,0 +1,153 @@
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sagernet/fswatch"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+)
+
+type Options struct {
+	Logger       logger.Logger
+	FindNeighbor bool
+	LeaseFiles   []string
+}
+
+// Resolver finds MAC addresses of LAN devices in the neighbor table and hostnames in DHCP lease files.
+type Resolver struct {
+	logger      logger.Logger
+	table       *neighborTable
+	leaseFiles  []string
+	leaseAccess sync.RWMutex
+	leases      map[string][]lease
+	watcher     *fswatch.Watcher
+}
+
+func NewResolver(options Options) (*Resolver, error) {
+	resolver := &Resolver{
+		logger: options.Logger,
+		leases: make(map[string][]lease),
+	}
+	if options.FindNeighbor {
+		table, err := newNeighborTable(options.Logger)
+		if err != nil {
+			return nil, E.Cause(err, "open neighbor table")
+		}
+		resolver.table = table
+	}
+	for _, path := range options.LeaseFiles {
+		path, _ = filepath.Abs(path)
+		resolver.leaseFiles = append(resolver.leaseFiles, path)
+		err := resolver.reloadLeases(path)
+		if err != nil && !os.IsNotExist(err) {
+			resolver.logger.Warn(E.Cause(err, "read DHCP lease file ", path))
+		}
+	}
+	if len(resolver.leaseFiles) > 0 {
+		watcher, err := fswatch.NewWatcher(fswatch.Options{
+			Path: resolver.leaseFiles,
+			Callback: func(path string) {
+				uErr := resolver.reloadLeases(path)
+				if uErr != nil {
+					resolver.logger.Error(E.Cause(uErr, "reload DHCP lease file ", path))
+				}
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		resolver.watcher = watcher
+	}
+	return resolver, nil
+}
+
+func (r *Resolver) Start() error {
+	if r.table != nil {
+		err := r.table.start()
+		if err != nil {
+			return E.Cause(err, "read neighbor table")
+		}
+	}
+	if r.watcher != nil {
+		err := r.watcher.Start()
+		if err != nil {
+			r.logger.Error(E.Cause(err, "watch DHCP lease files"))
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) Close() error {
+	return common.Close(
+		common.PtrOrNil(r.table),
+		common.PtrOrNil(r.watcher),
+	)
+}
+
+func (r *Resolver) reloadLeases(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	leases := parseLeases(content)
+	r.leaseAccess.Lock()
+	r.leases[path] = leases
+	r.leaseAccess.Unlock()
+	return nil
+}
+
+// LookupMAC returns the MAC address of the address from the neighbor table, or from DHCP leases if not found.
+func (r *Resolver) LookupMAC(address netip.Addr) (net.HardwareAddr, bool) {
+	address = address.Unmap()
+	if r.table != nil {
+		hardwareAddr, loaded := r.table.lookup(address)
+		if loaded {
+			return hardwareAddr, true
+		}
+	}
+	r.leaseAccess.RLock()
+	defer r.leaseAccess.RUnlock()
+	for _, path := range r.leaseFiles {
+		leases := r.leases[path]
+		for i := len(leases) - 1; i >= 0; i-- {
+			if leases[i].Address == address && leases[i].HardwareAddr != nil {
+				return leases[i].HardwareAddr, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// LookupHostname returns the hostname of the device leasing the address, or the MAC address if the address has changed.
+func (r *Resolver) LookupHostname(address netip.Addr, hardwareAddr net.HardwareAddr) (string, bool) {
+	address = address.Unmap()
+	r.leaseAccess.RLock()
+	defer r.leaseAccess.RUnlock()
+	for _, path := range r.leaseFiles {
+		leases := r.leases[path]
+		for i := len(leases) - 1; i >= 0; i-- {
+			if leases[i].Address == address && leases[i].Hostname != "" {
+				return leases[i].Hostname, true
+			}
+		}
+	}
+	if hardwareAddr == nil {
+		return "", false
+	}
+	for _, path := range r.leaseFiles {
+		leases := r.leases[path]
+		for i := len(leases) - 1; i >= 0; i-- {
+			if leases[i].Hostname != "" && strings.EqualFold(leases[i].HardwareAddr.String(), hardwareAddr.String()) {
+				return leases[i].Hostname, true
+			}
+		}
+	}
+	return "", false
+}
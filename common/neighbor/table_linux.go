@@ -0,0 +1,91 @@
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/sagernet/netlink"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+
+	"golang.org/x/sys/unix"
+)
+
+type neighborTable struct {
+	logger  logger.Logger
+	access  sync.RWMutex
+	entries map[netip.Addr]net.HardwareAddr
+	done    chan struct{}
+}
+
+func newNeighborTable(logger logger.Logger) (*neighborTable, error) {
+	return &neighborTable{
+		logger:  logger,
+		entries: make(map[netip.Addr]net.HardwareAddr),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+func (t *neighborTable) start() error {
+	updates := make(chan netlink.NeighUpdate, 64)
+	err := netlink.NeighSubscribeWithOptions(updates, t.done, netlink.NeighSubscribeOptions{
+		ErrorCallback: func(err error) {
+			select {
+			case <-t.done:
+			default:
+				t.logger.Error(E.Cause(err, "neighbor table subscription"))
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+	neighbors, err := netlink.NeighList(0, netlink.FAMILY_ALL)
+	if err != nil {
+		return err
+	}
+	for _, neighbor := range neighbors {
+		t.update(unix.RTM_NEWNEIGH, neighbor)
+	}
+	go t.loopUpdates(updates)
+	return nil
+}
+
+func (t *neighborTable) loopUpdates(updates <-chan netlink.NeighUpdate) {
+	for update := range updates {
+		t.update(update.Type, update.Neigh)
+	}
+}
+
+func (t *neighborTable) update(messageType uint16, neighbor netlink.Neigh) {
+	address, ok := netip.AddrFromSlice(neighbor.IP)
+	if !ok {
+		return
+	}
+	address = address.Unmap()
+	t.access.Lock()
+	defer t.access.Unlock()
+	if messageType == unix.RTM_DELNEIGH || len(neighbor.HardwareAddr) == 0 ||
+		neighbor.State&(netlink.NUD_INCOMPLETE|netlink.NUD_FAILED) != 0 {
+		delete(t.entries, address)
+		return
+	}
+	t.entries[address] = neighbor.HardwareAddr
+}
+
+func (t *neighborTable) lookup(address netip.Addr) (net.HardwareAddr, bool) {
+	t.access.RLock()
+	defer t.access.RUnlock()
+	hardwareAddr, loaded := t.entries[address]
+	return hardwareAddr, loaded
+}
+
+func (t *neighborTable) Close() error {
+	select {
+	case <-t.done:
+	default:
+		close(t.done)
+	}
+	return nil
+}
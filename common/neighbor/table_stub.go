@@ -0,0 +1,29 @@
+//go:build !linux
+
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+	"os"
+
+	"github.com/sagernet/sing/common/logger"
+)
+
+type neighborTable struct{}
+
+func newNeighborTable(logger logger.Logger) (*neighborTable, error) {
+	return nil, os.ErrInvalid
+}
+
+func (t *neighborTable) start() error {
+	return os.ErrInvalid
+}
+
+func (t *neighborTable) lookup(address netip.Addr) (net.HardwareAddr, bool) {
+	return nil, false
+}
+
+func (t *neighborTable) Close() error {
+	return nil
+}
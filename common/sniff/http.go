@@ -5,14 +5,28 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
+	std_http "net/http"
 
 	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common/buf"
+	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	M "github.com/sagernet/sing/common/metadata"
 	"github.com/sagernet/sing/protocol/http"
 )
 
+// headers recorded in addition to User-Agent
+var httpHeaderNames = []string{
+	"Accept",
+	"Content-Type",
+	"Origin",
+	"Referer",
+	"Upgrade",
+	"X-Requested-With",
+}
+
 func HTTPHost(_ context.Context, metadata *adapter.InboundContext, reader io.Reader) error {
 	request, err := http.ReadRequest(std_bufio.NewReader(reader))
 	if err != nil {
@@ -24,5 +38,29 @@ func HTTPHost(_ context.Context, metadata *adapter.InboundContext, reader io.Rea
 	}
 	metadata.Protocol = C.ProtocolHTTP
 	metadata.Domain = M.ParseSocksaddr(request.Host).AddrString()
+	metadata.HTTPMethod = request.Method
+	metadata.HTTPPath = request.URL.Path
+	metadata.HTTPUserAgent = request.UserAgent()
+	header := make(std_http.Header)
+	for _, name := range httpHeaderNames {
+		if values := request.Header.Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+	if len(header) > 0 {
+		metadata.HTTPHeader = header
+	}
 	return nil
 }
+
+// HTTPProxyRequest sniffs plain HTTP requests forwarded by HTTP proxy inbounds,
+// the returned connection replays the consumed request.
+func HTTPProxyRequest(ctx context.Context, metadata *adapter.InboundContext, conn net.Conn) (net.Conn, error) {
+	buffer := buf.NewPacket()
+	err := PeekStream(ctx, metadata, conn, nil, buffer, 0, HTTPHost)
+	if buffer.IsEmpty() {
+		buffer.Release()
+		return conn, err
+	}
+	return bufio.NewCachedConn(conn, buffer), err
+}
@@ -28,3 +28,16 @@ func TestSniffHTTP1WithPort(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, metadata.Domain, "www.gov.cn")
 }
+
+func TestSniffHTTP1Request(t *testing.T) {
+	t.Parallel()
+	pkt := "POST /api/v1/items?page=2 HTTP/1.1\r\nHost: api.example.com\r\nUser-Agent: okhttp/4.12.0\r\nContent-Type: application/json\r\nCookie: secret\r\nContent-Length: 2\r\n\r\n{}"
+	var metadata adapter.InboundContext
+	err := sniff.HTTPHost(context.Background(), &metadata, strings.NewReader(pkt))
+	require.NoError(t, err)
+	require.Equal(t, "POST", metadata.HTTPMethod)
+	require.Equal(t, "/api/v1/items", metadata.HTTPPath)
+	require.Equal(t, "okhttp/4.12.0", metadata.HTTPUserAgent)
+	require.Equal(t, "application/json", metadata.HTTPHeader.Get("Content-Type"))
+	require.Empty(t, metadata.HTTPHeader.Get("Cookie"))
+}
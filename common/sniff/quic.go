@@ -307,6 +307,8 @@ find:
 		return E.Cause1(ErrNeedMoreData, err)
 	}
 	metadata.Domain = fingerprint.ServerName
+	metadata.ClientJA3 = fingerprint.Hash()
+	metadata.ClientJA4 = fingerprint.JA4(true)
 	for metadata.Client == "" {
 		if len(frameTypeList) == 1 {
 			metadata.Client = C.ClientFirefox
@@ -0,0 +1,40 @@
+package sniff_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/sniff"
+	C "github.com/sagernet/sing-box/constant"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffTLS(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	go func() {
+		tls.Client(client, &tls.Config{
+			ServerName: "example.com",
+			NextProtos: []string{"h2", "http/1.1"},
+		}).Handshake()
+	}()
+	payload := make([]byte, 4096)
+	n, err := server.Read(payload)
+	require.NoError(t, err)
+	server.Close()
+	var metadata adapter.InboundContext
+	err = sniff.TLSClientHello(context.TODO(), &metadata, bytes.NewReader(payload[:n]))
+	require.NoError(t, err)
+	require.Equal(t, C.ProtocolTLS, metadata.Protocol)
+	require.Equal(t, "example.com", metadata.Domain)
+	require.Len(t, metadata.ClientJA3, 32)
+	require.True(t, strings.HasPrefix(metadata.ClientJA4, "t13d"), metadata.ClientJA4)
+	require.Equal(t, "h2", metadata.ClientJA4[8:10])
+}
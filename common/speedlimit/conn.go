@@ -0,0 +1,119 @@
+package speedlimit
+
+import (
+	"net"
+	"sync"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/buf"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+)
+
+// NewUploadConn limits reads from an inbound connection.
+func NewUploadConn(conn net.Conn, limiters []adapter.SpeedLimiter) net.Conn {
+	return newConn(conn, uploadBuckets(limiters))
+}
+
+// NewDownloadConn limits reads from an outbound connection.
+func NewDownloadConn(conn net.Conn, limiters []adapter.SpeedLimiter) net.Conn {
+	return newConn(conn, downloadBuckets(limiters))
+}
+
+// NewUploadPacketConn limits reads from an inbound packet connection.
+func NewUploadPacketConn(conn N.PacketConn, limiters []adapter.SpeedLimiter) N.PacketConn {
+	return newPacketConn(conn, uploadBuckets(limiters))
+}
+
+// NewDownloadPacketConn limits reads from an outbound packet connection.
+func NewDownloadPacketConn(conn N.PacketConn, limiters []adapter.SpeedLimiter) N.PacketConn {
+	return newPacketConn(conn, downloadBuckets(limiters))
+}
+
+type conn struct {
+	net.Conn
+	buckets   []adapter.SpeedBucket
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newConn(upstream net.Conn, buckets []adapter.SpeedBucket) net.Conn {
+	if len(buckets) == 0 {
+		return upstream
+	}
+	return &conn{
+		Conn:    upstream,
+		buckets: buckets,
+		done:    make(chan struct{}),
+	}
+}
+
+func (c *conn) Read(p []byte) (n int, err error) {
+	n, err = c.Conn.Read(p)
+	waitN(c.buckets, n, c.done)
+	return
+}
+
+func (c *conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return c.Conn.Close()
+}
+
+func (c *conn) ReaderReplaceable() bool {
+	return false
+}
+
+func (c *conn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *conn) Upstream() any {
+	return c.Conn
+}
+
+type packetConn struct {
+	N.PacketConn
+	buckets   []adapter.SpeedBucket
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPacketConn(upstream N.PacketConn, buckets []adapter.SpeedBucket) N.PacketConn {
+	if len(buckets) == 0 {
+		return upstream
+	}
+	return &packetConn{
+		PacketConn: upstream,
+		buckets:    buckets,
+		done:       make(chan struct{}),
+	}
+}
+
+func (c *packetConn) ReadPacket(buffer *buf.Buffer) (destination M.Socksaddr, err error) {
+	destination, err = c.PacketConn.ReadPacket(buffer)
+	if err == nil {
+		waitN(c.buckets, buffer.Len(), c.done)
+	}
+	return
+}
+
+func (c *packetConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return c.PacketConn.Close()
+}
+
+func (c *packetConn) ReaderReplaceable() bool {
+	return false
+}
+
+func (c *packetConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *packetConn) Upstream() any {
+	return c.PacketConn
+}
@@ -0,0 +1,37 @@
+package speedlimit
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadConn(t *testing.T) {
+	t.Parallel()
+	var options option.SpeedLimitOptions
+	require.NoError(t, json.Unmarshal([]byte(`{"download":"32 KB"}`), &options))
+	limiter := New(&options)
+	require.NotNil(t, limiter)
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		server.Write(make([]byte, 48*1000))
+		server.Close()
+	}()
+	conn := NewDownloadConn(client, []adapter.SpeedLimiter{limiter})
+	defer conn.Close()
+	require.Same(t, client, NewUploadConn(client, []adapter.SpeedLimiter{limiter}))
+	start := time.Now()
+	n, err := io.Copy(io.Discard, conn)
+	require.NoError(t, err)
+	require.Equal(t, int64(48*1000), n)
+	// the first 32 KB are allowed by the burst, the rest takes half a second
+	require.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
@@ -0,0 +1,113 @@
+package speedlimit
+
+import (
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/byteformats"
+	F "github.com/sagernet/sing/common/format"
+
+	"golang.org/x/time/rate"
+)
+
+var _ adapter.SpeedLimiter = (*Limiter)(nil)
+
+type Limiter struct {
+	upload   *tokenBucket
+	download *tokenBucket
+}
+
+// New returns nil if neither upload nor download is limited.
+func New(options *option.SpeedLimitOptions) adapter.SpeedLimiter {
+	if options == nil || (options.Upload.Value() == 0 && options.Download.Value() == 0) {
+		return nil
+	}
+	return &Limiter{
+		upload:   newTokenBucket(options.Upload.Value()),
+		download: newTokenBucket(options.Download.Value()),
+	}
+}
+
+func (l *Limiter) Upload() adapter.SpeedBucket {
+	if l.upload == nil {
+		return nil
+	}
+	return l.upload
+}
+
+func (l *Limiter) Download() adapter.SpeedBucket {
+	if l.download == nil {
+		return nil
+	}
+	return l.download
+}
+
+func (l *Limiter) String() string {
+	var upload, download string
+	if l.upload != nil {
+		upload = byteformats.FormatBytes(uint64(l.upload.limiter.Limit())) + "/s"
+	} else {
+		upload = "unlimited"
+	}
+	if l.download != nil {
+		download = byteformats.FormatBytes(uint64(l.download.limiter.Limit())) + "/s"
+	} else {
+		download = "unlimited"
+	}
+	return F.ToString("up ", upload, " down ", download)
+}
+
+var _ adapter.SpeedBucket = (*tokenBucket)(nil)
+
+type tokenBucket struct {
+	limiter *rate.Limiter
+}
+
+func newTokenBucket(bytesPerSecond uint64) *tokenBucket {
+	if bytesPerSecond == 0 {
+		return nil
+	}
+	return &tokenBucket{rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, 1<<30)))}
+}
+
+// Reserve splits reservations larger than the burst, which would never be allowed by the limiter.
+func (b *tokenBucket) Reserve(now time.Time, n int) time.Duration {
+	var delay time.Duration
+	for remaining := n; remaining > 0; {
+		chunk := min(remaining, b.limiter.Burst())
+		delay = max(delay, b.limiter.ReserveN(now, chunk).DelayFrom(now))
+		remaining -= chunk
+	}
+	return delay
+}
+
+func uploadBuckets(limiters []adapter.SpeedLimiter) []adapter.SpeedBucket {
+	return common.FilterNotDefault(common.Map(limiters, adapter.SpeedLimiter.Upload))
+}
+
+func downloadBuckets(limiters []adapter.SpeedLimiter) []adapter.SpeedBucket {
+	return common.FilterNotDefault(common.Map(limiters, adapter.SpeedLimiter.Download))
+}
+
+// waitN blocks until n bytes are allowed by all buckets, or done is closed.
+func waitN(buckets []adapter.SpeedBucket, n int, done <-chan struct{}) {
+	if n <= 0 {
+		return
+	}
+	now := time.Now()
+	var delay time.Duration
+	for _, bucket := range buckets {
+		delay = max(delay, bucket.Reserve(now, n))
+	}
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-done:
+	}
+}
@@ -0,0 +1,280 @@
+package trafficquota
+
+import (
+	"context"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+var _ adapter.TrafficQuotaManager = (*Manager)(nil)
+
+type quotaKey struct {
+	inbound string
+	user    string
+}
+
+type Manager struct {
+	ctx        context.Context
+	logger     log.ContextLogger
+	cacheFile  adapter.CacheFile
+	access     sync.RWMutex
+	quotas     []*quota
+	quotaByKey map[quotaKey]*quota
+	started    bool
+	saveTicker *time.Ticker
+	done       chan struct{}
+}
+
+func NewManager(ctx context.Context, logger log.ContextLogger) *Manager {
+	return &Manager{
+		ctx:        ctx,
+		logger:     logger,
+		quotaByKey: make(map[quotaKey]*quota),
+		done:       make(chan struct{}),
+	}
+}
+
+func (m *Manager) Register(inbound string, user string, options option.TrafficQuotaOptions) error {
+	if user == "" {
+		return E.New("missing user name for traffic quota")
+	}
+	q, err := newQuota(m, inbound, user, options)
+	if err != nil {
+		return E.Cause(err, "parse traffic quota of user ", user)
+	}
+	key := quotaKey{inbound, user}
+	m.access.Lock()
+	defer m.access.Unlock()
+	// quotas of closed inbounds are kept, so that usage survives reloads without a cache file
+	if existing, loaded := m.quotaByKey[key]; loaded {
+		existing.reconfigure(q)
+		existing.references++
+		existing.update(time.Now())
+		existing.checkExhausted()
+		return nil
+	}
+	q.references++
+	m.quotas = append(m.quotas, q)
+	m.quotaByKey[key] = q
+	if m.started {
+		m.loadQuota(q, time.Now())
+	}
+	return nil
+}
+
+func (m *Manager) Unregister(inbound string, user string) {
+	m.access.Lock()
+	q := m.quotaByKey[quotaKey{inbound, user}]
+	if q == nil || q.references == 0 {
+		m.access.Unlock()
+		return
+	}
+	q.references--
+	m.access.Unlock()
+	m.saveQuota(q)
+}
+
+func (m *Manager) loadQuota(q *quota, now time.Time) {
+	var saved *adapter.SavedTrafficQuota
+	if m.cacheFile != nil {
+		saved = m.cacheFile.LoadTrafficQuota(q.inbound, q.user)
+	}
+	q.load(saved, now)
+}
+
+func (m *Manager) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateInitialize {
+		return nil
+	}
+	m.cacheFile = service.FromContext[adapter.CacheFile](m.ctx)
+	m.access.Lock()
+	if m.cacheFile == nil && len(m.quotas) > 0 {
+		m.logger.Warn("cache file is disabled, traffic quota usage will be lost on restart")
+	}
+	now := time.Now()
+	for _, q := range m.quotas {
+		m.loadQuota(q, now)
+	}
+	m.started = true
+	m.access.Unlock()
+	m.saveTicker = time.NewTicker(time.Minute)
+	go m.loopUpdate()
+	return nil
+}
+
+func (m *Manager) loopUpdate() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-m.saveTicker.C:
+			quotas := m.quotaList()
+			for _, q := range quotas {
+				q.update(now)
+			}
+			m.save(quotas)
+		}
+	}
+}
+
+func (m *Manager) quotaList() []*quota {
+	m.access.RLock()
+	defer m.access.RUnlock()
+	return slices.Clone(m.quotas)
+}
+
+func (m *Manager) save(quotas []*quota) {
+	for _, q := range quotas {
+		m.saveQuota(q)
+	}
+}
+
+func (m *Manager) saveQuota(q *quota) {
+	if m.cacheFile == nil || !q.changed.Swap(false) {
+		return
+	}
+	err := m.cacheFile.SaveTrafficQuota(q.inbound, q.user, q.save())
+	if err != nil {
+		m.logger.Error(E.Cause(err, "save traffic quota of user ", q.user, " on inbound ", q.inbound))
+	}
+}
+
+func (m *Manager) Close() error {
+	if m.saveTicker == nil {
+		return nil
+	}
+	m.saveTicker.Stop()
+	close(m.done)
+	m.save(m.quotaList())
+	return nil
+}
+
+func (m *Manager) quota(inbound string, user string) *quota {
+	m.access.RLock()
+	defer m.access.RUnlock()
+	q := m.quotaByKey[quotaKey{inbound, user}]
+	if q == nil || q.references == 0 {
+		return nil
+	}
+	return q
+}
+
+func (m *Manager) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext) (net.Conn, error) {
+	q := m.quota(metadata.Inbound, metadata.User)
+	if q == nil {
+		return conn, nil
+	}
+	q.update(time.Now())
+	if q.exhausted.Load() {
+		return nil, E.New("traffic quota exceeded for user ", metadata.User)
+	}
+	return q.newConn(conn), nil
+}
+
+func (m *Manager) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext) (N.PacketConn, error) {
+	q := m.quota(metadata.Inbound, metadata.User)
+	if q == nil {
+		return conn, nil
+	}
+	q.update(time.Now())
+	if q.exhausted.Load() {
+		return nil, E.New("traffic quota exceeded for user ", metadata.User)
+	}
+	return q.newPacketConn(conn), nil
+}
+
+func (m *Manager) TrafficQuotas() []adapter.TrafficQuotaStatus {
+	m.access.RLock()
+	defer m.access.RUnlock()
+	return common.Map(common.Filter(m.quotas, func(it *quota) bool {
+		return it.references > 0
+	}), (*quota).status)
+}
+
+func (m *Manager) TrafficQuota(inbound string, user string) (adapter.TrafficQuotaStatus, bool) {
+	q := m.quota(inbound, user)
+	if q == nil {
+		return adapter.TrafficQuotaStatus{}, false
+	}
+	return q.status(), true
+}
+
+func (m *Manager) ResetTrafficQuota(inbound string, user string) error {
+	q := m.quota(inbound, user)
+	if q == nil {
+		return os.ErrNotExist
+	}
+	q.reset()
+	return nil
+}
+
+func (m *Manager) SetTrafficQuotaLimit(inbound string, user string, limit uint64) error {
+	q := m.quota(inbound, user)
+	if q == nil {
+		return os.ErrNotExist
+	}
+	if limit == 0 {
+		return E.New("invalid limit")
+	}
+	q.setLimit(limit)
+	return nil
+}
+
+func (m *Manager) SetTrafficQuotaUsed(inbound string, user string, used uint64) error {
+	q := m.quota(inbound, user)
+	if q == nil {
+		return os.ErrNotExist
+	}
+	q.setUsed(used)
+	return nil
+}
+
+// Registration tracks traffic quotas registered by an inbound,
+// which must be closed with the inbound.
+type Registration struct {
+	manager adapter.TrafficQuotaManager
+	inbound string
+	users   []string
+}
+
+func NewRegistration(ctx context.Context, inbound string) *Registration {
+	return &Registration{
+		manager: service.FromContext[adapter.TrafficQuotaManager](ctx),
+		inbound: inbound,
+	}
+}
+
+// Register registers the traffic quota of an inbound user, if configured.
+func (r *Registration) Register(user string, options *option.TrafficQuotaOptions) error {
+	if options == nil {
+		return nil
+	}
+	if r.manager == nil {
+		return E.New("traffic quota is not supported in this context")
+	}
+	err := r.manager.Register(r.inbound, user, *options)
+	if err != nil {
+		return err
+	}
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *Registration) Close() error {
+	for _, user := range r.users {
+		r.manager.Unregister(r.inbound, user)
+	}
+	r.users = nil
+	return nil
+}
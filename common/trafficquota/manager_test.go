@@ -0,0 +1,35 @@
+package trafficquota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/json"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerReregister(t *testing.T) {
+	t.Parallel()
+	manager := NewManager(context.Background(), log.NewNOPFactory().Logger())
+	var options option.TrafficQuotaOptions
+	require.NoError(t, json.Unmarshal([]byte(`{"limit":"1000 B"}`), &options))
+	require.Error(t, manager.Register("in", "", options))
+	require.NoError(t, manager.Register("in", "user", options))
+	require.NoError(t, manager.Start(adapter.StartStateInitialize))
+	defer manager.Close()
+	manager.quota("in", "user").add(600)
+	manager.Unregister("in", "user")
+	require.Nil(t, manager.quota("in", "user"))
+	require.Empty(t, manager.TrafficQuotas())
+	require.NoError(t, json.Unmarshal([]byte(`{"limit":"500 B"}`), &options))
+	require.NoError(t, manager.Register("in", "user", options))
+	status, loaded := manager.TrafficQuota("in", "user")
+	require.True(t, loaded)
+	require.Equal(t, uint64(600), status.Used)
+	require.Equal(t, uint64(500), status.Limit)
+	require.True(t, manager.quota("in", "user").exhausted.Load())
+}
@@ -0,0 +1,23 @@
+package trafficquota
+
+import "time"
+
+// monthlyPeriodStart returns the start of the monthly period containing now,
+// with periods starting at midnight of resetDay.
+func monthlyPeriodStart(now time.Time, resetDay int) time.Time {
+	year, month, day := now.Date()
+	if day < resetDay {
+		month--
+	}
+	return time.Date(year, month, resetDay, 0, 0, 0, 0, now.Location())
+}
+
+// rollingPeriodStart returns the start of the rolling period containing now,
+// with periods of interval starting back to back from start.
+func rollingPeriodStart(start time.Time, now time.Time, interval time.Duration) time.Time {
+	if start.IsZero() || now.Before(start) {
+		return now
+	}
+	elapsed := now.Sub(start)
+	return start.Add(elapsed - elapsed%interval)
+}
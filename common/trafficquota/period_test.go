@@ -0,0 +1,29 @@
+package trafficquota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonthlyPeriodStart(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), monthlyPeriodStart(now, 1))
+	require.Equal(t, time.Date(2024, time.March, 10, 0, 0, 0, 0, time.UTC), monthlyPeriodStart(now, 10))
+	require.Equal(t, time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC), monthlyPeriodStart(now, 15))
+	now = time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2023, time.December, 28, 0, 0, 0, 0, time.UTC), monthlyPeriodStart(now, 28))
+}
+
+func TestRollingPeriodStart(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	interval := 7 * 24 * time.Hour
+	require.Equal(t, start, rollingPeriodStart(start, start.Add(time.Hour), interval))
+	require.Equal(t, start.Add(interval), rollingPeriodStart(start, start.Add(interval), interval))
+	require.Equal(t, start.Add(2*interval), rollingPeriodStart(start, start.Add(3*interval-time.Second), interval))
+	now := time.Now()
+	require.Equal(t, now, rollingPeriodStart(time.Time{}, now, interval))
+}
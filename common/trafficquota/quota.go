@@ -0,0 +1,288 @@
+package trafficquota
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common/bufio"
+	"github.com/sagernet/sing/common/byteformats"
+	E "github.com/sagernet/sing/common/exceptions"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/x/list"
+)
+
+const defaultRollingInterval = 30 * 24 * time.Hour
+
+type quota struct {
+	manager     *Manager
+	inbound     string
+	user        string
+	period      string
+	resetDay    int
+	interval    time.Duration
+	configLimit uint64
+	references  int
+
+	access      sync.Mutex
+	periodStart time.Time
+	connections list.List[io.Closer]
+	limit       atomic.Uint64
+	used        atomic.Uint64
+	exhausted   atomic.Bool
+	changed     atomic.Bool
+}
+
+func newQuota(manager *Manager, inbound string, user string, options option.TrafficQuotaOptions) (*quota, error) {
+	q := &quota{
+		manager:     manager,
+		inbound:     inbound,
+		user:        user,
+		period:      options.Period,
+		resetDay:    options.ResetDay,
+		interval:    time.Duration(options.Interval),
+		configLimit: options.Limit.Value(),
+	}
+	if q.configLimit == 0 {
+		return nil, E.New("missing limit")
+	}
+	switch q.period {
+	case "":
+		q.period = C.TrafficQuotaPeriodMonthly
+		fallthrough
+	case C.TrafficQuotaPeriodMonthly:
+		if q.resetDay == 0 {
+			q.resetDay = 1
+		} else if q.resetDay < 1 || q.resetDay > 28 {
+			return nil, E.New("invalid reset_day: ", q.resetDay)
+		}
+	case C.TrafficQuotaPeriodRolling:
+		if q.interval == 0 {
+			q.interval = defaultRollingInterval
+		} else if q.interval < time.Hour {
+			return nil, E.New("interval must be at least one hour")
+		}
+	default:
+		return nil, E.New("unknown period: ", q.period)
+	}
+	q.limit.Store(q.configLimit)
+	return q, nil
+}
+
+// reconfigure applies the options of a re-registered quota while keeping its usage.
+func (q *quota) reconfigure(options *quota) {
+	q.access.Lock()
+	defer q.access.Unlock()
+	if q.period != options.period || q.resetDay != options.resetDay || q.interval != options.interval {
+		q.periodStart = time.Time{}
+	}
+	if q.limit.Load() == q.configLimit {
+		q.limit.Store(options.configLimit)
+	}
+	q.period = options.period
+	q.resetDay = options.resetDay
+	q.interval = options.interval
+	q.configLimit = options.configLimit
+	q.changed.Store(true)
+}
+
+func (q *quota) load(saved *adapter.SavedTrafficQuota, now time.Time) {
+	q.access.Lock()
+	if saved != nil {
+		q.periodStart = saved.PeriodStart
+		q.used.Store(saved.Used)
+		if saved.Limit > 0 {
+			q.limit.Store(saved.Limit)
+		}
+	}
+	q.access.Unlock()
+	q.update(now)
+	q.checkExhausted()
+}
+
+func (q *quota) save() *adapter.SavedTrafficQuota {
+	q.access.Lock()
+	defer q.access.Unlock()
+	saved := &adapter.SavedTrafficQuota{
+		PeriodStart: q.periodStart,
+		Used:        q.used.Load(),
+	}
+	if limit := q.limit.Load(); limit != q.configLimit {
+		saved.Limit = limit
+	}
+	return saved
+}
+
+// update starts a new period with a clean usage and the configured limit if the current one is over.
+func (q *quota) update(now time.Time) {
+	q.access.Lock()
+	var periodStart time.Time
+	if q.period == C.TrafficQuotaPeriodRolling {
+		periodStart = rollingPeriodStart(q.periodStart, now, q.interval)
+	} else {
+		periodStart = monthlyPeriodStart(now, q.resetDay)
+	}
+	if periodStart.Equal(q.periodStart) {
+		q.access.Unlock()
+		return
+	}
+	renewed := !q.periodStart.IsZero()
+	q.periodStart = periodStart
+	q.used.Store(0)
+	q.limit.Store(q.configLimit)
+	q.exhausted.Store(false)
+	q.changed.Store(true)
+	q.access.Unlock()
+	if renewed {
+		q.manager.logger.Info("traffic quota of user ", q.user, " on inbound ", q.inbound, " renewed")
+	}
+}
+
+func (q *quota) periodEnd(periodStart time.Time) time.Time {
+	if q.period == C.TrafficQuotaPeriodRolling {
+		return periodStart.Add(q.interval)
+	}
+	return periodStart.AddDate(0, 1, 0)
+}
+
+func (q *quota) status() adapter.TrafficQuotaStatus {
+	q.access.Lock()
+	periodStart := q.periodStart
+	q.access.Unlock()
+	return adapter.TrafficQuotaStatus{
+		Inbound:     q.inbound,
+		User:        q.user,
+		Limit:       q.limit.Load(),
+		Used:        q.used.Load(),
+		PeriodStart: periodStart,
+		PeriodEnd:   q.periodEnd(periodStart),
+	}
+}
+
+func (q *quota) reset() {
+	q.used.Store(0)
+	q.limit.Store(q.configLimit)
+	q.changed.Store(true)
+	q.checkExhausted()
+}
+
+func (q *quota) setLimit(limit uint64) {
+	q.limit.Store(limit)
+	q.changed.Store(true)
+	q.checkExhausted()
+}
+
+func (q *quota) setUsed(used uint64) {
+	q.used.Store(used)
+	q.changed.Store(true)
+	q.checkExhausted()
+}
+
+func (q *quota) add(n int64) {
+	q.used.Add(uint64(n))
+	q.changed.Store(true)
+	q.checkExhausted()
+}
+
+// checkExhausted closes all connections of the user once the quota is used up.
+func (q *quota) checkExhausted() {
+	if q.used.Load() < q.limit.Load() {
+		q.exhausted.Store(false)
+		return
+	}
+	if q.exhausted.Swap(true) {
+		return
+	}
+	q.manager.logger.Warn("traffic quota of user ", q.user, " on inbound ", q.inbound, " exceeded: ", byteformats.FormatBytes(q.limit.Load()))
+	q.access.Lock()
+	connections := q.connections.Array()
+	q.access.Unlock()
+	for _, conn := range connections {
+		conn.Close()
+	}
+}
+
+func (q *quota) newConn(conn net.Conn) net.Conn {
+	counterConn := &quotaConn{
+		Conn:  bufio.NewCounterConn(conn, []N.CountFunc{q.add}, []N.CountFunc{q.add}),
+		quota: q,
+	}
+	q.access.Lock()
+	counterConn.element = q.connections.PushBack(counterConn)
+	q.access.Unlock()
+	return counterConn
+}
+
+func (q *quota) newPacketConn(conn N.PacketConn) N.PacketConn {
+	counterConn := &quotaPacketConn{
+		PacketConn: bufio.NewCounterPacketConn(conn, []N.CountFunc{q.add}, []N.CountFunc{q.add}),
+		quota:      q,
+	}
+	q.access.Lock()
+	counterConn.element = q.connections.PushBack(counterConn)
+	q.access.Unlock()
+	return counterConn
+}
+
+func (q *quota) removeConnection(element *list.Element[io.Closer]) {
+	q.access.Lock()
+	q.connections.Remove(element)
+	q.access.Unlock()
+}
+
+type quotaConn struct {
+	net.Conn
+	quota     *quota
+	element   *list.Element[io.Closer]
+	closeOnce sync.Once
+}
+
+func (c *quotaConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.quota.removeConnection(c.element)
+	})
+	return c.Conn.Close()
+}
+
+func (c *quotaConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *quotaConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *quotaConn) Upstream() any {
+	return c.Conn
+}
+
+type quotaPacketConn struct {
+	N.PacketConn
+	quota     *quota
+	element   *list.Element[io.Closer]
+	closeOnce sync.Once
+}
+
+func (c *quotaPacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.quota.removeConnection(c.element)
+	})
+	return c.PacketConn.Close()
+}
+
+func (c *quotaPacketConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *quotaPacketConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *quotaPacketConn) Upstream() any {
+	return c.PacketConn
+}
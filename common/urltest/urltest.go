@@ -19,6 +19,16 @@ import (
 
 var _ adapter.URLTestHistoryStorage = (*HistoryStorage)(nil)
 
+const (
+	// MaxScore is the passive health score of an outbound after a successful test.
+	MaxScore = 100
+	// UnhealthyScore is the score below which an outbound is skipped by groups.
+	UnhealthyScore = 50
+
+	scoreFailurePenalty = 25
+	scoreSuccessBonus   = 10
+)
+
 type HistoryStorage struct {
 	access       sync.RWMutex
 	delayHistory map[string]*adapter.URLTestHistory
@@ -53,11 +63,42 @@ func (s *HistoryStorage) DeleteURLTestHistory(tag string) {
 
 func (s *HistoryStorage) StoreURLTestHistory(tag string, history *adapter.URLTestHistory) {
 	s.access.Lock()
+	history.Score = MaxScore
 	s.delayHistory[tag] = history
 	s.notifyUpdated()
 	s.access.Unlock()
 }
 
+// ReportConnection scores an outbound by the result of a real connection.
+// Outbounds without history are already unavailable and are not scored.
+func (s *HistoryStorage) ReportConnection(tag string, success bool) *adapter.URLTestHistory {
+	s.access.Lock()
+	defer s.access.Unlock()
+	history := s.delayHistory[tag]
+	if history == nil {
+		return nil
+	}
+	score := int(history.Score)
+	if success {
+		score = min(score+scoreSuccessBonus, MaxScore)
+	} else {
+		score = max(score-scoreFailurePenalty, 0)
+	}
+	if score == int(history.Score) {
+		return history
+	}
+	newHistory := *history
+	newHistory.Score = uint8(score)
+	s.delayHistory[tag] = &newHistory
+	s.notifyUpdated()
+	return &newHistory
+}
+
+// IsHealthy reports whether an outbound with the history is available for selection.
+func IsHealthy(history *adapter.URLTestHistory) bool {
+	return history != nil && history.Score >= UnhealthyScore
+}
+
 func (s *HistoryStorage) notifyUpdated() {
 	updateHook := s.updateHook
 	if updateHook != nil {
@@ -0,0 +1,37 @@
+package urltest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryStorageReportConnection(t *testing.T) {
+	t.Parallel()
+	storage := NewHistoryStorage()
+	require.Nil(t, storage.ReportConnection("proxy", false))
+	storage.StoreURLTestHistory("proxy", &adapter.URLTestHistory{
+		Time:  time.Now(),
+		Delay: 100,
+	})
+	history := storage.LoadURLTestHistory("proxy")
+	require.Equal(t, uint8(MaxScore), history.Score)
+	require.True(t, IsHealthy(history))
+	for range 2 {
+		history = storage.ReportConnection("proxy", false)
+	}
+	require.True(t, IsHealthy(history))
+	history = storage.ReportConnection("proxy", false)
+	require.False(t, IsHealthy(history))
+	require.Equal(t, uint16(100), history.Delay)
+	history = storage.ReportConnection("proxy", true)
+	require.Equal(t, uint8(35), history.Score)
+	storage.StoreURLTestHistory("proxy", &adapter.URLTestHistory{
+		Time:  time.Now(),
+		Delay: 80,
+	})
+	require.True(t, IsHealthy(storage.LoadURLTestHistory("proxy")))
+}
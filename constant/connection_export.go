@@ -0,0 +1,7 @@
+package constant
+
+const (
+	ConnectionExportTypeFile = "file"
+	ConnectionExportTypeUnix = "unix"
+	ConnectionExportTypeHTTP = "http"
+)
@@ -28,6 +28,15 @@ const (
 	DNSTypeFakeIP      = "fakeip"
 	DNSTypeDHCP        = "dhcp"
 	DNSTypeTailscale   = "tailscale"
+	DNSTypeGroup       = "group"
+	DNSTypeDNSCrypt    = "dnscrypt"
+	DNSTypeODoH        = "odoh"
+)
+
+const (
+	DNSGroupStrategyParallel = "parallel"
+	DNSGroupStrategyFastest  = "fastest"
+	DNSGroupStrategyFailover = "failover"
 )
 
 const (
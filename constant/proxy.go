@@ -33,8 +33,17 @@ const (
 )
 
 const (
-	TypeSelector = "selector"
-	TypeURLTest  = "urltest"
+	TypeSelector    = "selector"
+	TypeURLTest     = "urltest"
+	TypeLoadBalance = "load-balance"
+	TypeFallback    = "fallback"
+)
+
+const (
+	LoadBalanceStrategyRoundRobin        = "round-robin"
+	LoadBalanceStrategyRandom            = "random"
+	LoadBalanceStrategyConsistentHashing = "consistent-hashing"
+	LoadBalanceStrategyStickySessions    = "sticky-sessions"
 )
 
 func ProxyDisplayName(proxyType string) string {
@@ -89,6 +98,10 @@ func ProxyDisplayName(proxyType string) string {
 		return "Selector"
 	case TypeURLTest:
 		return "URLTest"
+	case TypeLoadBalance:
+		return "LoadBalance"
+	case TypeFallback:
+		return "Fallback"
 	default:
 		return "Unknown"
 	}
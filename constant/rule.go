@@ -36,6 +36,7 @@ const (
 	RuleActionTypeSniff        = "sniff"
 	RuleActionTypeResolve      = "resolve"
 	RuleActionTypePredefined   = "predefined"
+	RuleActionTypeRewrite      = "rewrite"
 )
 
 const (
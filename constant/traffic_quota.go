@@ -0,0 +1,6 @@
+package constant
+
+const (
+	TrafficQuotaPeriodMonthly = "monthly"
+	TrafficQuotaPeriodRolling = "rolling"
+)
@@ -22,6 +22,7 @@ type Instance struct {
 	instance              *box.Box
 	clashServer           adapter.ClashServer
 	cacheFile             adapter.CacheFile
+	dnsQueryLog           adapter.DNSQueryLog
 	pauseManager          pause.Manager
 	urlTestHistoryStorage *urltest.HistoryStorage
 }
@@ -103,6 +104,7 @@ func (s *StartedService) newInstance(profileContent string, overrideOptions *Ove
 	i.clashServer = service.FromContext[adapter.ClashServer](ctx)
 	i.pauseManager = service.FromContext[pause.Manager](ctx)
 	i.cacheFile = service.FromContext[adapter.CacheFile](ctx)
+	i.dnsQueryLog = service.FromContext[adapter.DNSQueryLog](ctx)
 	return i, nil
 }
 
@@ -576,10 +576,14 @@ func (s *StartedService) URLTest(ctx context.Context, request *URLTestRequest) (
 	if !isOutboundGroup {
 		return nil, E.New("outbound is not a group: ", groupTag)
 	}
-	urlTest, isURLTest := abstractOutboundGroup.(*group.URLTest)
-	if isURLTest {
-		go urlTest.CheckOutbounds()
-	} else {
+	switch urlTestGroup := abstractOutboundGroup.(type) {
+	case *group.URLTest:
+		go urlTestGroup.CheckOutbounds()
+	case *group.LoadBalance:
+		go urlTestGroup.CheckOutbounds()
+	case *group.Fallback:
+		go urlTestGroup.CheckOutbounds()
+	default:
 		historyStorage := boxService.urlTestHistoryStorage
 
 		outbounds := common.Filter(common.Map(outboundGroup.All(), func(it string) adapter.Outbound {
@@ -1008,6 +1012,84 @@ func (s *StartedService) GetStartedAt(ctx context.Context, empty *emptypb.Empty)
 	return &StartedAt{StartedAt: s.startedAt.UnixMilli()}, nil
 }
 
+func (s *StartedService) SubscribeDNSLog(empty *emptypb.Empty, server grpc.ServerStreamingServer[DNSLog]) error {
+	err := s.waitForStarted(server.Context())
+	if err != nil {
+		return err
+	}
+	s.serviceAccess.RLock()
+	queryLog := s.instance.dnsQueryLog
+	s.serviceAccess.RUnlock()
+	if queryLog == nil {
+		return E.New("DNS query log is disabled")
+	}
+	subscription, done, err := queryLog.Subscribe()
+	if err != nil {
+		return err
+	}
+	defer queryLog.UnSubscribe(subscription)
+	err = server.Send(&DNSLog{
+		Entries: common.Map(queryLog.Entries(), newDNSLogEntry),
+		Reset_:  true,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-server.Context().Done():
+			return server.Context().Err()
+		case entry := <-subscription:
+			var rawMessage DNSLog
+			if entry == nil {
+				rawMessage.Reset_ = true
+			} else {
+				rawMessage.Entries = append(rawMessage.Entries, newDNSLogEntry(entry))
+			}
+		fetch:
+			for {
+				select {
+				case entry = <-subscription:
+					if entry == nil {
+						rawMessage.Entries = nil
+						rawMessage.Reset_ = true
+					} else {
+						rawMessage.Entries = append(rawMessage.Entries, newDNSLogEntry(entry))
+					}
+				default:
+					break fetch
+				}
+			}
+			err = server.Send(&rawMessage)
+			if err != nil {
+				return err
+			}
+		case <-done:
+			return nil
+		}
+	}
+}
+
+func newDNSLogEntry(entry *adapter.DNSQueryLogEntry) *DNSLog_Entry {
+	return &DNSLog_Entry{
+		Id:        entry.ID,
+		Time:      entry.Time.UnixMilli(),
+		Inbound:   entry.Inbound,
+		Source:    entry.Source,
+		Domain:    entry.Domain,
+		QueryType: entry.QueryType,
+		Rule:      entry.Rule,
+		Transport: entry.Transport,
+		Rcode:     entry.Rcode,
+		Answers:   entry.Answers,
+		Latency:   entry.Latency.Build().Milliseconds(),
+		Cached:    entry.Cached,
+		Error:     entry.Error,
+	}
+}
+
 func (s *StartedService) mustEmbedUnimplementedStartedServiceServer() {
 }
 
@@ -1722,6 +1722,58 @@ func (x *StartedAt) GetStartedAt() int64 {
 	return 0
 }
 
+type DNSLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*DNSLog_Entry        `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Reset_        bool                   `protobuf:"varint,2,opt,name=reset,proto3" json:"reset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSLog) Reset() {
+	*x = DNSLog{}
+	mi := &file_daemon_started_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSLog) ProtoMessage() {}
+
+func (x *DNSLog) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSLog.ProtoReflect.Descriptor instead.
+func (*DNSLog) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DNSLog) GetEntries() []*DNSLog_Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *DNSLog) GetReset_() bool {
+	if x != nil {
+		return x.Reset_
+	}
+	return false
+}
+
 type Log_Message struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Level         LogLevel               `protobuf:"varint,1,opt,name=level,proto3,enum=daemon.LogLevel" json:"level,omitempty"`
@@ -1732,7 +1784,7 @@ type Log_Message struct {
 
 func (x *Log_Message) Reset() {
 	*x = Log_Message{}
-	mi := &file_daemon_started_service_proto_msgTypes[25]
+	mi := &file_daemon_started_service_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1744,7 +1796,7 @@ func (x *Log_Message) String() string {
 func (*Log_Message) ProtoMessage() {}
 
 func (x *Log_Message) ProtoReflect() protoreflect.Message {
-	mi := &file_daemon_started_service_proto_msgTypes[25]
+	mi := &file_daemon_started_service_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1774,6 +1826,146 @@ func (x *Log_Message) GetMessage() string {
 	return ""
 }
 
+type DNSLog_Entry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time          int64                  `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	Inbound       string                 `protobuf:"bytes,3,opt,name=inbound,proto3" json:"inbound,omitempty"`
+	Source        string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	Domain        string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`
+	QueryType     string                 `protobuf:"bytes,6,opt,name=queryType,proto3" json:"queryType,omitempty"`
+	Rule          string                 `protobuf:"bytes,7,opt,name=rule,proto3" json:"rule,omitempty"`
+	Transport     string                 `protobuf:"bytes,8,opt,name=transport,proto3" json:"transport,omitempty"`
+	Rcode         string                 `protobuf:"bytes,9,opt,name=rcode,proto3" json:"rcode,omitempty"`
+	Answers       []string               `protobuf:"bytes,10,rep,name=answers,proto3" json:"answers,omitempty"`
+	Latency       int64                  `protobuf:"varint,11,opt,name=latency,proto3" json:"latency,omitempty"`
+	Cached        bool                   `protobuf:"varint,12,opt,name=cached,proto3" json:"cached,omitempty"`
+	Error         string                 `protobuf:"bytes,13,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSLog_Entry) Reset() {
+	*x = DNSLog_Entry{}
+	mi := &file_daemon_started_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSLog_Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSLog_Entry) ProtoMessage() {}
+
+func (x *DNSLog_Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_daemon_started_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSLog_Entry.ProtoReflect.Descriptor instead.
+func (*DNSLog_Entry) Descriptor() ([]byte, []int) {
+	return file_daemon_started_service_proto_rawDescGZIP(), []int{25, 0}
+}
+
+func (x *DNSLog_Entry) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DNSLog_Entry) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *DNSLog_Entry) GetInbound() string {
+	if x != nil {
+		return x.Inbound
+	}
+	return ""
+}
+
+func (x *DNSLog_Entry) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *DNSLog_Entry) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *DNSLog_Entry) GetQueryType() string {
+	if x != nil {
+		return x.QueryType
+	}
+	return ""
+}
+
+func (x *DNSLog_Entry) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *DNSLog_Entry) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+func (x *DNSLog_Entry) GetRcode() string {
+	if x != nil {
+		return x.Rcode
+	}
+	return ""
+}
+
+func (x *DNSLog_Entry) GetAnswers() []string {
+	if x != nil {
+		return x.Answers
+	}
+	return nil
+}
+
+func (x *DNSLog_Entry) GetLatency() int64 {
+	if x != nil {
+		return x.Latency
+	}
+	return 0
+}
+
+func (x *DNSLog_Entry) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *DNSLog_Entry) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_daemon_started_service_proto protoreflect.FileDescriptor
 
 const file_daemon_started_service_proto_rawDesc = "" +
@@ -1900,7 +2092,25 @@ const file_daemon_started_service_proto_rawDesc = "" +
 	"\timpending\x18\x02 \x01(\bR\timpending\x12$\n" +
 	"\rmigrationLink\x18\x03 \x01(\tR\rmigrationLink\")\n" +
 	"\tStartedAt\x12\x1c\n" +
-	"\tstartedAt\x18\x01 \x01(\x03R\tstartedAt*U\n" +
+	"\tstartedAt\x18\x01 \x01(\x03R\tstartedAt\"\x8e\x03\n" +
+	"\x06DNSLog\x12.\n" +
+	"\aentries\x18\x01 \x03(\v2\x14.daemon.DNSLog.EntryR\aentries\x12\x14\n" +
+	"\x05reset\x18\x02 \x01(\bR\x05reset\x1a\xbd\x02\n" +
+	"\x05Entry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\x03R\x04time\x12\x18\n" +
+	"\ainbound\x18\x03 \x01(\tR\ainbound\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\x12\x16\n" +
+	"\x06domain\x18\x05 \x01(\tR\x06domain\x12\x1c\n" +
+	"\tqueryType\x18\x06 \x01(\tR\tqueryType\x12\x12\n" +
+	"\x04rule\x18\a \x01(\tR\x04rule\x12\x1c\n" +
+	"\ttransport\x18\b \x01(\tR\ttransport\x12\x14\n" +
+	"\x05rcode\x18\t \x01(\tR\x05rcode\x12\x18\n" +
+	"\aanswers\x18\n" +
+	" \x03(\tR\aanswers\x12\x18\n" +
+	"\alatency\x18\v \x01(\x03R\alatency\x12\x16\n" +
+	"\x06cached\x18\f \x01(\bR\x06cached\x12\x14\n" +
+	"\x05error\x18\r \x01(\tR\x05error*U\n" +
 	"\bLogLevel\x12\t\n" +
 	"\x05PANIC\x10\x00\x12\t\n" +
 	"\x05FATAL\x10\x01\x12\t\n" +
@@ -1912,7 +2122,7 @@ const file_daemon_started_service_proto_rawDesc = "" +
 	"\x13ConnectionEventType\x12\x18\n" +
 	"\x14CONNECTION_EVENT_NEW\x10\x00\x12\x1b\n" +
 	"\x17CONNECTION_EVENT_UPDATE\x10\x01\x12\x1b\n" +
-	"\x17CONNECTION_EVENT_CLOSED\x10\x022\xe5\v\n" +
+	"\x17CONNECTION_EVENT_CLOSED\x10\x022\xa4\f\n" +
 	"\x0eStartedService\x12=\n" +
 	"\vStopService\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12?\n" +
 	"\rReloadService\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12K\n" +
@@ -1934,7 +2144,8 @@ const file_daemon_started_service_proto_rawDesc = "" +
 	"\x0fCloseConnection\x12\x1e.daemon.CloseConnectionRequest\x1a\x16.google.protobuf.Empty\"\x00\x12G\n" +
 	"\x13CloseAllConnections\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\"\x00\x12M\n" +
 	"\x15GetDeprecatedWarnings\x12\x16.google.protobuf.Empty\x1a\x1a.daemon.DeprecatedWarnings\"\x00\x12;\n" +
-	"\fGetStartedAt\x12\x16.google.protobuf.Empty\x1a\x11.daemon.StartedAt\"\x00B%Z#github.com/sagernet/sing-box/daemonb\x06proto3"
+	"\fGetStartedAt\x12\x16.google.protobuf.Empty\x1a\x11.daemon.StartedAt\"\x00\x12=\n" +
+	"\x0fSubscribeDNSLog\x12\x16.google.protobuf.Empty\x1a\x0e.daemon.DNSLog\"\x000\x01B%Z#github.com/sagernet/sing-box/daemonb\x06proto3"
 
 var (
 	file_daemon_started_service_proto_rawDescOnce sync.Once
@@ -1950,7 +2161,7 @@ func file_daemon_started_service_proto_rawDescGZIP() []byte {
 
 var (
 	file_daemon_started_service_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-	file_daemon_started_service_proto_msgTypes  = make([]protoimpl.MessageInfo, 26)
+	file_daemon_started_service_proto_msgTypes  = make([]protoimpl.MessageInfo, 28)
 	file_daemon_started_service_proto_goTypes   = []any{
 		(LogLevel)(0),                        // 0: daemon.LogLevel
 		(ConnectionEventType)(0),             // 1: daemon.ConnectionEventType
@@ -1980,14 +2191,16 @@ var (
 		(*DeprecatedWarnings)(nil),           // 25: daemon.DeprecatedWarnings
 		(*DeprecatedWarning)(nil),            // 26: daemon.DeprecatedWarning
 		(*StartedAt)(nil),                    // 27: daemon.StartedAt
-		(*Log_Message)(nil),                  // 28: daemon.Log.Message
-		(*emptypb.Empty)(nil),                // 29: google.protobuf.Empty
+		(*DNSLog)(nil),                       // 28: daemon.DNSLog
+		(*Log_Message)(nil),                  // 29: daemon.Log.Message
+		(*DNSLog_Entry)(nil),                 // 30: daemon.DNSLog.Entry
+		(*emptypb.Empty)(nil),                // 31: google.protobuf.Empty
 	}
 )
 
 var file_daemon_started_service_proto_depIdxs = []int32{
 	2,  // 0: daemon.ServiceStatus.status:type_name -> daemon.ServiceStatus.Type
-	28, // 1: daemon.Log.messages:type_name -> daemon.Log.Message
+	29, // 1: daemon.Log.messages:type_name -> daemon.Log.Message
 	0,  // 2: daemon.DefaultLogLevel.level:type_name -> daemon.LogLevel
 	10, // 3: daemon.Groups.group:type_name -> daemon.Group
 	11, // 4: daemon.Group.items:type_name -> daemon.GroupItem
@@ -1996,54 +2209,57 @@ var file_daemon_started_service_proto_depIdxs = []int32{
 	20, // 7: daemon.ConnectionEvents.events:type_name -> daemon.ConnectionEvent
 	23, // 8: daemon.Connection.processInfo:type_name -> daemon.ProcessInfo
 	26, // 9: daemon.DeprecatedWarnings.warnings:type_name -> daemon.DeprecatedWarning
-	0,  // 10: daemon.Log.Message.level:type_name -> daemon.LogLevel
-	29, // 11: daemon.StartedService.StopService:input_type -> google.protobuf.Empty
-	29, // 12: daemon.StartedService.ReloadService:input_type -> google.protobuf.Empty
-	29, // 13: daemon.StartedService.SubscribeServiceStatus:input_type -> google.protobuf.Empty
-	29, // 14: daemon.StartedService.SubscribeLog:input_type -> google.protobuf.Empty
-	29, // 15: daemon.StartedService.GetDefaultLogLevel:input_type -> google.protobuf.Empty
-	29, // 16: daemon.StartedService.ClearLogs:input_type -> google.protobuf.Empty
-	5,  // 17: daemon.StartedService.SubscribeStatus:input_type -> daemon.SubscribeStatusRequest
-	29, // 18: daemon.StartedService.SubscribeGroups:input_type -> google.protobuf.Empty
-	29, // 19: daemon.StartedService.GetClashModeStatus:input_type -> google.protobuf.Empty
-	29, // 20: daemon.StartedService.SubscribeClashMode:input_type -> google.protobuf.Empty
-	15, // 21: daemon.StartedService.SetClashMode:input_type -> daemon.ClashMode
-	12, // 22: daemon.StartedService.URLTest:input_type -> daemon.URLTestRequest
-	13, // 23: daemon.StartedService.SelectOutbound:input_type -> daemon.SelectOutboundRequest
-	14, // 24: daemon.StartedService.SetGroupExpand:input_type -> daemon.SetGroupExpandRequest
-	29, // 25: daemon.StartedService.GetSystemProxyStatus:input_type -> google.protobuf.Empty
-	18, // 26: daemon.StartedService.SetSystemProxyEnabled:input_type -> daemon.SetSystemProxyEnabledRequest
-	19, // 27: daemon.StartedService.SubscribeConnections:input_type -> daemon.SubscribeConnectionsRequest
-	24, // 28: daemon.StartedService.CloseConnection:input_type -> daemon.CloseConnectionRequest
-	29, // 29: daemon.StartedService.CloseAllConnections:input_type -> google.protobuf.Empty
-	29, // 30: daemon.StartedService.GetDeprecatedWarnings:input_type -> google.protobuf.Empty
-	29, // 31: daemon.StartedService.GetStartedAt:input_type -> google.protobuf.Empty
-	29, // 32: daemon.StartedService.StopService:output_type -> google.protobuf.Empty
-	29, // 33: daemon.StartedService.ReloadService:output_type -> google.protobuf.Empty
-	3,  // 34: daemon.StartedService.SubscribeServiceStatus:output_type -> daemon.ServiceStatus
-	6,  // 35: daemon.StartedService.SubscribeLog:output_type -> daemon.Log
-	7,  // 36: daemon.StartedService.GetDefaultLogLevel:output_type -> daemon.DefaultLogLevel
-	29, // 37: daemon.StartedService.ClearLogs:output_type -> google.protobuf.Empty
-	8,  // 38: daemon.StartedService.SubscribeStatus:output_type -> daemon.Status
-	9,  // 39: daemon.StartedService.SubscribeGroups:output_type -> daemon.Groups
-	16, // 40: daemon.StartedService.GetClashModeStatus:output_type -> daemon.ClashModeStatus
-	15, // 41: daemon.StartedService.SubscribeClashMode:output_type -> daemon.ClashMode
-	29, // 42: daemon.StartedService.SetClashMode:output_type -> google.protobuf.Empty
-	29, // 43: daemon.StartedService.URLTest:output_type -> google.protobuf.Empty
-	29, // 44: daemon.StartedService.SelectOutbound:output_type -> google.protobuf.Empty
-	29, // 45: daemon.StartedService.SetGroupExpand:output_type -> google.protobuf.Empty
-	17, // 46: daemon.StartedService.GetSystemProxyStatus:output_type -> daemon.SystemProxyStatus
-	29, // 47: daemon.StartedService.SetSystemProxyEnabled:output_type -> google.protobuf.Empty
-	21, // 48: daemon.StartedService.SubscribeConnections:output_type -> daemon.ConnectionEvents
-	29, // 49: daemon.StartedService.CloseConnection:output_type -> google.protobuf.Empty
-	29, // 50: daemon.StartedService.CloseAllConnections:output_type -> google.protobuf.Empty
-	25, // 51: daemon.StartedService.GetDeprecatedWarnings:output_type -> daemon.DeprecatedWarnings
-	27, // 52: daemon.StartedService.GetStartedAt:output_type -> daemon.StartedAt
-	32, // [32:53] is the sub-list for method output_type
-	11, // [11:32] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	30, // 10: daemon.DNSLog.entries:type_name -> daemon.DNSLog.Entry
+	0,  // 11: daemon.Log.Message.level:type_name -> daemon.LogLevel
+	31, // 12: daemon.StartedService.StopService:input_type -> google.protobuf.Empty
+	31, // 13: daemon.StartedService.ReloadService:input_type -> google.protobuf.Empty
+	31, // 14: daemon.StartedService.SubscribeServiceStatus:input_type -> google.protobuf.Empty
+	31, // 15: daemon.StartedService.SubscribeLog:input_type -> google.protobuf.Empty
+	31, // 16: daemon.StartedService.GetDefaultLogLevel:input_type -> google.protobuf.Empty
+	31, // 17: daemon.StartedService.ClearLogs:input_type -> google.protobuf.Empty
+	5,  // 18: daemon.StartedService.SubscribeStatus:input_type -> daemon.SubscribeStatusRequest
+	31, // 19: daemon.StartedService.SubscribeGroups:input_type -> google.protobuf.Empty
+	31, // 20: daemon.StartedService.GetClashModeStatus:input_type -> google.protobuf.Empty
+	31, // 21: daemon.StartedService.SubscribeClashMode:input_type -> google.protobuf.Empty
+	15, // 22: daemon.StartedService.SetClashMode:input_type -> daemon.ClashMode
+	12, // 23: daemon.StartedService.URLTest:input_type -> daemon.URLTestRequest
+	13, // 24: daemon.StartedService.SelectOutbound:input_type -> daemon.SelectOutboundRequest
+	14, // 25: daemon.StartedService.SetGroupExpand:input_type -> daemon.SetGroupExpandRequest
+	31, // 26: daemon.StartedService.GetSystemProxyStatus:input_type -> google.protobuf.Empty
+	18, // 27: daemon.StartedService.SetSystemProxyEnabled:input_type -> daemon.SetSystemProxyEnabledRequest
+	19, // 28: daemon.StartedService.SubscribeConnections:input_type -> daemon.SubscribeConnectionsRequest
+	24, // 29: daemon.StartedService.CloseConnection:input_type -> daemon.CloseConnectionRequest
+	31, // 30: daemon.StartedService.CloseAllConnections:input_type -> google.protobuf.Empty
+	31, // 31: daemon.StartedService.GetDeprecatedWarnings:input_type -> google.protobuf.Empty
+	31, // 32: daemon.StartedService.GetStartedAt:input_type -> google.protobuf.Empty
+	31, // 33: daemon.StartedService.SubscribeDNSLog:input_type -> google.protobuf.Empty
+	31, // 34: daemon.StartedService.StopService:output_type -> google.protobuf.Empty
+	31, // 35: daemon.StartedService.ReloadService:output_type -> google.protobuf.Empty
+	3,  // 36: daemon.StartedService.SubscribeServiceStatus:output_type -> daemon.ServiceStatus
+	6,  // 37: daemon.StartedService.SubscribeLog:output_type -> daemon.Log
+	7,  // 38: daemon.StartedService.GetDefaultLogLevel:output_type -> daemon.DefaultLogLevel
+	31, // 39: daemon.StartedService.ClearLogs:output_type -> google.protobuf.Empty
+	8,  // 40: daemon.StartedService.SubscribeStatus:output_type -> daemon.Status
+	9,  // 41: daemon.StartedService.SubscribeGroups:output_type -> daemon.Groups
+	16, // 42: daemon.StartedService.GetClashModeStatus:output_type -> daemon.ClashModeStatus
+	15, // 43: daemon.StartedService.SubscribeClashMode:output_type -> daemon.ClashMode
+	31, // 44: daemon.StartedService.SetClashMode:output_type -> google.protobuf.Empty
+	31, // 45: daemon.StartedService.URLTest:output_type -> google.protobuf.Empty
+	31, // 46: daemon.StartedService.SelectOutbound:output_type -> google.protobuf.Empty
+	31, // 47: daemon.StartedService.SetGroupExpand:output_type -> google.protobuf.Empty
+	17, // 48: daemon.StartedService.GetSystemProxyStatus:output_type -> daemon.SystemProxyStatus
+	31, // 49: daemon.StartedService.SetSystemProxyEnabled:output_type -> google.protobuf.Empty
+	21, // 50: daemon.StartedService.SubscribeConnections:output_type -> daemon.ConnectionEvents
+	31, // 51: daemon.StartedService.CloseConnection:output_type -> google.protobuf.Empty
+	31, // 52: daemon.StartedService.CloseAllConnections:output_type -> google.protobuf.Empty
+	25, // 53: daemon.StartedService.GetDeprecatedWarnings:output_type -> daemon.DeprecatedWarnings
+	27, // 54: daemon.StartedService.GetStartedAt:output_type -> daemon.StartedAt
+	28, // 55: daemon.StartedService.SubscribeDNSLog:output_type -> daemon.DNSLog
+	34, // [34:56] is the sub-list for method output_type
+	12, // [12:34] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_daemon_started_service_proto_init() }
@@ -2057,7 +2273,7 @@ func file_daemon_started_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_daemon_started_service_proto_rawDesc), len(file_daemon_started_service_proto_rawDesc)),
 			NumEnums:      3,
-			NumMessages:   26,
+			NumMessages:   28,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
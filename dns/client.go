@@ -6,10 +6,12 @@ import (
 	"net"
 	"net/netip"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/compatible"
+	"github.com/sagernet/sing-box/common/fastestip"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
@@ -29,6 +31,25 @@ var (
 	ErrResponseRejectedCached = E.Extend(ErrResponseRejected, "cached")
 )
 
+const (
+	// DefaultServeStaleWindow is the recommended maximum stale timer of RFC 8767.
+	DefaultServeStaleWindow = 24 * time.Hour
+
+	// staleAnswerTTL is the TTL of stale answers recommended by RFC 8767.
+	staleAnswerTTL = 30
+
+	// staleRefreshTimeout is the client response timer of RFC 8767,
+	// after which the stale answer is served while the refresh continues in background.
+	staleRefreshTimeout = 1800 * time.Millisecond
+
+	// staleRefreshRecheck is the failure recheck timer of RFC 8767,
+	// during which stale answers are served without querying upstream again.
+	staleRefreshRecheck = 30 * time.Second
+
+	// prefetchRatio is the remaining fraction of the original TTL below which cached answers are refreshed in background.
+	prefetchRatio = 10
+)
+
 var _ adapter.DNSClient = (*Client)(nil)
 
 type Client struct {
@@ -36,14 +57,26 @@ type Client struct {
 	disableCache       bool
 	disableExpire      bool
 	independentCache   bool
+	serveStale         bool
+	staleWindow        time.Duration
+	prefetch           bool
 	clientSubnet       netip.Prefix
 	rdrc               adapter.RDRCStore
 	initRDRCFunc       func() adapter.RDRCStore
+	cacheStore         adapter.DNSCacheStore
+	initCacheStoreFunc func() adapter.DNSCacheStore
 	logger             logger.ContextLogger
 	cache              freelru.Cache[dns.Question, *dns.Msg]
 	cacheLock          compatible.Map[dns.Question, chan struct{}]
 	transportCache     freelru.Cache[transportCacheKey, *dns.Msg]
 	transportCacheLock compatible.Map[dns.Question, chan struct{}]
+	refreshing         compatible.Map[transportCacheKey, *cacheRefresh]
+	refreshFailed      compatible.Map[transportCacheKey, time.Time]
+	cacheStatistics    compatible.Map[string, *cacheStatistics]
+	dnssecEnabled      bool
+	dnssec             *dnssecValidator
+	trackers           []adapter.DNSQueryTracker
+	prober             *fastestip.Prober
 }
 
 type ClientOptions struct {
@@ -51,25 +84,41 @@ type ClientOptions struct {
 	DisableCache     bool
 	DisableExpire    bool
 	IndependentCache bool
+	ServeStale       bool
+	StaleWindow      time.Duration
+	Prefetch         bool
 	CacheCapacity    uint32
 	ClientSubnet     netip.Prefix
 	RDRC             func() adapter.RDRCStore
+	CacheStore       func() adapter.DNSCacheStore
+	DNSSEC           bool
+	TrustAnchor      []dns.RR
 	Logger           logger.ContextLogger
 }
 
 func NewClient(options ClientOptions) *Client {
 	client := &Client{
-		timeout:          options.Timeout,
-		disableCache:     options.DisableCache,
-		disableExpire:    options.DisableExpire,
-		independentCache: options.IndependentCache,
-		clientSubnet:     options.ClientSubnet,
-		initRDRCFunc:     options.RDRC,
-		logger:           options.Logger,
+		timeout:            options.Timeout,
+		disableCache:       options.DisableCache,
+		disableExpire:      options.DisableExpire,
+		independentCache:   options.IndependentCache,
+		serveStale:         options.ServeStale && !options.DisableExpire,
+		staleWindow:        options.StaleWindow,
+		prefetch:           options.Prefetch && !options.DisableExpire,
+		clientSubnet:       options.ClientSubnet,
+		initRDRCFunc:       options.RDRC,
+		initCacheStoreFunc: options.CacheStore,
+		dnssecEnabled:      options.DNSSEC,
+		logger:             options.Logger,
+		prober:             fastestip.NewProber(),
 	}
 	if client.timeout == 0 {
 		client.timeout = C.DNSTimeout
 	}
+	client.dnssec = newDNSSECValidator(client.timeout, options.TrustAnchor)
+	if client.staleWindow == 0 {
+		client.staleWindow = DefaultServeStaleWindow
+	}
 	cacheCapacity := options.CacheCapacity
 	if cacheCapacity < 1024 {
 		cacheCapacity = 1024
@@ -89,10 +138,80 @@ type transportCacheKey struct {
 	transportTag string
 }
 
+type cacheStatus uint8
+
+const (
+	cacheStatusFresh cacheStatus = iota
+	cacheStatusPrefetch
+	cacheStatusStale
+)
+
+type cacheStatistics struct {
+	hit   atomic.Uint64
+	miss  atomic.Uint64
+	stale atomic.Uint64
+}
+
 func (c *Client) Start() {
 	if c.initRDRCFunc != nil {
 		c.rdrc = c.initRDRCFunc()
 	}
+	if c.initCacheStoreFunc != nil && !c.disableCache {
+		c.cacheStore = c.initCacheStoreFunc()
+		if c.cacheStore != nil {
+			c.loadCacheStore()
+		}
+	}
+}
+
+// loadCacheStore restores cached answers saved by the last run and keeps the store in sync with evictions.
+func (c *Client) loadCacheStore() {
+	if !c.independentCache {
+		c.cache.SetOnEvict(func(question dns.Question, _ *dns.Msg) {
+			c.cacheStore.DeleteDNSCacheAsync("", question, c.logger)
+		})
+	} else {
+		c.transportCache.SetOnEvict(func(key transportCacheKey, _ *dns.Msg) {
+			c.cacheStore.DeleteDNSCacheAsync(key.transportTag, key.Question, c.logger)
+		})
+	}
+	timeNow := time.Now()
+	var loaded int
+	c.cacheStore.LoadDNSCache(func(transportName string, question dns.Question, message *dns.Msg, expireAt time.Time) bool {
+		if (transportName != "") != c.independentCache {
+			return false
+		}
+		var lifetime time.Duration
+		if !c.disableExpire {
+			if expireAt.IsZero() {
+				return false
+			}
+			lifetime = expireAt.Sub(timeNow)
+			if c.serveStale {
+				lifetime += c.staleWindow
+			}
+			if lifetime <= 0 {
+				return false
+			}
+		}
+		if !c.independentCache {
+			c.cache.AddWithLifetime(question, message, lifetime)
+		} else {
+			c.transportCache.AddWithLifetime(transportCacheKey{
+				Question:     question,
+				transportTag: transportName,
+			}, message, lifetime)
+		}
+		loaded++
+		return true
+	})
+	if loaded > 0 && c.logger != nil {
+		c.logger.Debug("loaded ", loaded, " cached answers")
+	}
+}
+
+func (c *Client) AppendTracker(tracker adapter.DNSQueryTracker) {
+	c.trackers = append(c.trackers, tracker)
 }
 
 func extractNegativeTTL(response *dns.Msg) (uint32, bool) {
@@ -140,6 +259,7 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 			len(message.Extra[0].(*dns.OPT).Option) == 0) &&
 		!options.ClientSubnet.IsValid()
 	disableCache := !isSimpleRequest || c.disableCache || options.DisableCache
+	validate := c.validateDNSSEC(transport, options, message.CheckingDisabled)
 	if !disableCache {
 		if c.cache != nil {
 			cond, loaded := c.cacheLock.LoadOrStore(question, make(chan struct{}))
@@ -162,14 +282,46 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 				}()
 			}
 		}
-		response, ttl := c.loadResponse(question, transport)
+		response, ttl, status := c.loadResponse(question, transport)
+		if response != nil && validate {
+			// cached answers may come from unvalidated queries, and validated chains are cached separately
+			secure, err := c.dnssec.Validate(ctx, transport, question, response)
+			if err != nil {
+				response = nil
+			} else {
+				response.AuthenticatedData = secure
+				dnssecResponse(message, response)
+			}
+		} else if response != nil && (message.IsEdns0() == nil || !message.IsEdns0().Do()) {
+			stripDNSSECRecords(message, response)
+		}
+		if response != nil && status == cacheStatusStale {
+			// RFC 8767: stale answers are only served when refreshing from upstream fails or does not finish in time
+			freshResponse, err := c.refreshStale(ctx, transport, message, question, options, responseChecker)
+			if err == nil && (freshResponse.Rcode == dns.RcodeSuccess || freshResponse.Rcode == dns.RcodeNameError) || errors.Is(err, ErrResponseRejected) {
+				c.loadCacheStatistics(transport).miss.Add(1)
+				return freshResponse, err
+			}
+		}
 		if response != nil {
+			c.recordCacheStatus(transport, status)
+			if status == cacheStatusPrefetch {
+				c.refreshAsync(ctx, transport, message, question, options, responseChecker)
+			}
+			for _, tracker := range c.trackers {
+				tracker.DNSQueryCached(ctx, transport, question)
+			}
+			response = rewriteResponse(response, options)
 			logCachedResponse(c.logger, ctx, response, ttl)
 			response.Id = message.Id
 			return response, nil
 		}
+		c.loadCacheStatistics(transport).miss.Add(1)
 	}
+	return c.exchange(ctx, transport, message, question, options, responseChecker, disableCache)
+}
 
+func (c *Client) exchange(ctx context.Context, transport adapter.DNSTransport, message *dns.Msg, question dns.Question, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool, disableCache bool) (*dns.Msg, error) {
 	messageId := message.Id
 	contextTransport, clientSubnetLoaded := transportTagFromContext(ctx)
 	if clientSubnetLoaded && transport.Tag() == contextTransport {
@@ -182,9 +334,18 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 			return nil, ErrResponseRejectedCached
 		}
 	}
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	response, err := transport.Exchange(ctx, message)
+	validate := c.validateDNSSEC(transport, options, message.CheckingDisabled)
+	request := message
+	if validate {
+		request = dnssecRequest(message)
+	}
+	exchangeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	exchangeStart := time.Now()
+	response, err := transport.Exchange(exchangeCtx, request)
 	cancel()
+	for _, tracker := range c.trackers {
+		tracker.DNSQueryExchanged(ctx, transport, question, time.Since(exchangeStart), err)
+	}
 	if err != nil {
 		var rcodeError RcodeError
 		if errors.As(err, &rcodeError) {
@@ -193,6 +354,23 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 			return nil, err
 		}
 	}
+	if validate {
+		response.AuthenticatedData = false
+		if response.Rcode == dns.RcodeSuccess || response.Rcode == dns.RcodeNameError {
+			secure, err := c.dnssec.Validate(ctx, transport, question, response)
+			if err != nil {
+				if c.logger != nil {
+					c.logger.WarnContext(ctx, E.Cause(err, "DNSSEC validation failed for ", FqdnToDomain(question.Name)))
+				}
+				return dnssecBogusResponse(message, err), nil
+			}
+			response.AuthenticatedData = secure
+		}
+		// the upstream OPT record carries the DO bit in its TTL field
+		response.Extra = common.Filter(response.Extra, func(it dns.RR) bool {
+			return it.Header().Rrtype != dns.TypeOPT
+		})
+	}
 	/*if question.Qtype == dns.TypeA || question.Qtype == dns.TypeAAAA {
 		validResponse := response
 	loop:
@@ -232,17 +410,18 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 	if responseChecker != nil {
 		var rejected bool
 		// TODO: add accept_any rule and support to check response instead of addresses
-		if response.Rcode != dns.RcodeSuccess || len(response.Answer) == 0 {
+		checkedResponse := rewriteResponse(response, options)
+		if checkedResponse.Rcode != dns.RcodeSuccess || len(checkedResponse.Answer) == 0 {
 			rejected = true
 		} else {
-			rejected = !responseChecker(MessageToAddresses(response))
+			rejected = !responseChecker(MessageToAddresses(checkedResponse))
 		}
 		if rejected {
 			if !disableCache && c.rdrc != nil {
 				c.rdrc.SaveRDRCAsync(transport.Tag(), question.Name, question.Qtype, c.logger)
 			}
-			logRejectedResponse(c.logger, ctx, response)
-			return response, ErrResponseRejected
+			logRejectedResponse(c.logger, ctx, checkedResponse)
+			return checkedResponse, ErrResponseRejected
 		}
 	}
 	if question.Qtype == dns.TypeHTTPS {
@@ -282,6 +461,9 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 	if options.RewriteTTL != nil {
 		timeToLive = *options.RewriteTTL
 	}
+	for _, rewriter := range options.Rewrite {
+		timeToLive = rewriter.RewriteTTL(timeToLive)
+	}
 	for _, recordList := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
 		for _, record := range recordList {
 			record.Header().Ttl = timeToLive
@@ -290,6 +472,11 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 	if !disableCache {
 		c.storeCache(transport, question, response, timeToLive)
 	}
+	if validate {
+		response = response.Copy()
+		dnssecResponse(message, response)
+	}
+	response = rewriteResponse(response, options)
 	response.Id = messageId
 	requestEDNSOpt := message.IsEdns0()
 	responseEDNSOpt := response.IsEdns0()
@@ -306,6 +493,18 @@ func (c *Client) Exchange(ctx context.Context, transport adapter.DNSTransport, m
 }
 
 func (c *Client) Lookup(ctx context.Context, transport adapter.DNSTransport, domain string, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) ([]netip.Addr, error) {
+	addresses, err := c.lookup(ctx, transport, domain, options, responseChecker)
+	if err != nil || options.FastestIP == nil {
+		return addresses, err
+	}
+	tag, dialer, loaded := fastestip.DialerFromContext(ctx)
+	if !loaded {
+		return addresses, nil
+	}
+	return c.prober.Sort(ctx, tag, dialer, addresses, options.FastestIP), nil
+}
+
+func (c *Client) lookup(ctx context.Context, transport adapter.DNSTransport, domain string, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) ([]netip.Addr, error) {
 	domain = FqdnToDomain(domain)
 	dnsName := dns.Fqdn(domain)
 	var strategy C.DomainStrategy
@@ -346,11 +545,125 @@ func (c *Client) Lookup(ctx context.Context, transport adapter.DNSTransport, dom
 }
 
 func (c *Client) ClearCache() {
+	c.dnssec.Reset()
 	if c.cache != nil {
 		c.cache.Purge()
 	} else if c.transportCache != nil {
 		c.transportCache.Purge()
 	}
+	if c.cacheStore != nil {
+		err := c.cacheStore.ClearDNSCache()
+		if err != nil && c.logger != nil {
+			c.logger.Warn("clear DNS cache: ", err)
+		}
+	}
+}
+
+func (c *Client) CacheStatistics() map[string]adapter.DNSCacheStatistics {
+	statistics := make(map[string]adapter.DNSCacheStatistics)
+	c.cacheStatistics.Range(func(transportTag string, counter *cacheStatistics) bool {
+		statistics[transportTag] = adapter.DNSCacheStatistics{
+			Hit:   counter.hit.Load(),
+			Miss:  counter.miss.Load(),
+			Stale: counter.stale.Load(),
+		}
+		return true
+	})
+	return statistics
+}
+
+func (c *Client) loadCacheStatistics(transport adapter.DNSTransport) *cacheStatistics {
+	counter, loaded := c.cacheStatistics.Load(transport.Tag())
+	if !loaded {
+		counter, _ = c.cacheStatistics.LoadOrStore(transport.Tag(), &cacheStatistics{})
+	}
+	return counter
+}
+
+func (c *Client) recordCacheStatus(transport adapter.DNSTransport, status cacheStatus) {
+	counter := c.loadCacheStatistics(transport)
+	if status == cacheStatusStale {
+		counter.stale.Add(1)
+	} else {
+		counter.hit.Add(1)
+	}
+}
+
+type cacheRefresh struct {
+	done     chan struct{}
+	response *dns.Msg
+	err      error
+}
+
+// refreshAsync exchanges an expiring cached question in background,
+// concurrent refreshes of the same question are merged, and failures are remembered for staleRefreshRecheck.
+func (c *Client) refreshAsync(ctx context.Context, transport adapter.DNSTransport, message *dns.Msg, question dns.Question, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) (*cacheRefresh, bool) {
+	key := transportCacheKey{
+		Question:     question,
+		transportTag: transport.Tag(),
+	}
+	refresh, loaded := c.refreshing.LoadOrStore(key, &cacheRefresh{done: make(chan struct{})})
+	if loaded {
+		return refresh, true
+	}
+	message = message.Copy()
+	go func() {
+		defer func() {
+			c.refreshing.Delete(key)
+			close(refresh.done)
+		}()
+		refresh.response, refresh.err = c.exchange(context.WithoutCancel(ctx), transport, message, question, options, responseChecker, false)
+		err := refresh.err
+		if err == nil && refresh.response.Rcode != dns.RcodeSuccess && refresh.response.Rcode != dns.RcodeNameError {
+			err = RcodeError(refresh.response.Rcode)
+		}
+		if err != nil && !errors.Is(err, ErrResponseRejected) {
+			c.refreshFailed.Store(key, time.Now())
+			if c.logger != nil {
+				c.logger.DebugContext(ctx, E.Cause(err, "refresh cached ", FqdnToDomain(question.Name)))
+			}
+		} else {
+			c.refreshFailed.Delete(key)
+		}
+	}()
+	return refresh, false
+}
+
+// refreshStale refreshes a stale cached answer, and returns ErrNotCached when the stale answer should be served.
+func (c *Client) refreshStale(ctx context.Context, transport adapter.DNSTransport, message *dns.Msg, question dns.Question, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) (*dns.Msg, error) {
+	key := transportCacheKey{
+		Question:     question,
+		transportTag: transport.Tag(),
+	}
+	if failedAt, loaded := c.refreshFailed.Load(key); loaded && time.Since(failedAt) < staleRefreshRecheck {
+		return nil, ErrNotCached
+	}
+	refresh, loaded := c.refreshAsync(ctx, transport, message, question, options, responseChecker)
+	if loaded {
+		return nil, ErrNotCached
+	}
+	timer := time.NewTimer(staleRefreshTimeout)
+	defer timer.Stop()
+	select {
+	case <-refresh.done:
+		return refresh.response, refresh.err
+	case <-timer.C:
+		return nil, ErrNotCached
+	case <-ctx.Done():
+		return nil, ErrNotCached
+	}
+}
+
+func (c *Client) validateDNSSEC(transport adapter.DNSTransport, options adapter.DNSQueryOptions, checkingDisabled bool) bool {
+	if !c.dnssecEnabled && !options.ValidateDNSSEC || checkingDisabled {
+		return false
+	}
+	switch transport.Type() {
+	case C.DNSTypeFakeIP, C.DNSTypeHosts:
+		return false
+	default:
+		return true
+	}
 }
 
 func sortAddresses(response4 []netip.Addr, response6 []netip.Addr, strategy C.DomainStrategy) []netip.Addr {
@@ -361,10 +674,25 @@ func sortAddresses(response4 []netip.Addr, response6 []netip.Addr, strategy C.Do
 	}
 }
 
+// rewriteResponse applies the rewrite actions to a copy of the response,
+// cached answers only take the rewritten TTL, so that they can be validated again and other changes are not shared between rules.
+func rewriteResponse(response *dns.Msg, options adapter.DNSQueryOptions) *dns.Msg {
+	if len(options.Rewrite) == 0 {
+		return response
+	}
+	response = response.Copy()
+	for _, rewriter := range options.Rewrite {
+		rewriter.RewriteResponse(response)
+	}
+	response.AuthenticatedData = false
+	return response
+}
+
 func (c *Client) storeCache(transport adapter.DNSTransport, question dns.Question, message *dns.Msg, timeToLive uint32) {
 	if timeToLive == 0 {
 		return
 	}
+	var expireAt time.Time
 	if c.disableExpire {
 		if !c.independentCache {
 			c.cache.Add(question, message)
@@ -375,14 +703,26 @@ func (c *Client) storeCache(transport adapter.DNSTransport, question dns.Questio
 			}, message)
 		}
 	} else {
+		lifetime := time.Second * time.Duration(timeToLive)
+		expireAt = time.Now().Add(lifetime)
+		if c.serveStale {
+			lifetime += c.staleWindow
+		}
 		if !c.independentCache {
-			c.cache.AddWithLifetime(question, message, time.Second*time.Duration(timeToLive))
+			c.cache.AddWithLifetime(question, message, lifetime)
 		} else {
 			c.transportCache.AddWithLifetime(transportCacheKey{
 				Question:     question,
 				transportTag: transport.Tag(),
-			}, message, time.Second*time.Duration(timeToLive))
+			}, message, lifetime)
+		}
+	}
+	if c.cacheStore != nil {
+		var transportName string
+		if c.independentCache {
+			transportName = transport.Tag()
 		}
+		c.cacheStore.SaveDNSCacheAsync(transportName, question, message, expireAt, c.logger)
 	}
 }
 
@@ -393,9 +733,12 @@ func (c *Client) lookupToExchange(ctx context.Context, transport adapter.DNSTran
 		Qclass: dns.ClassINET,
 	}
 	disableCache := c.disableCache || options.DisableCache
-	if !disableCache {
-		cachedAddresses, err := c.questionCache(question, transport)
+	if !disableCache && !c.validateDNSSEC(transport, options, false) {
+		cachedAddresses, err := c.questionCache(ctx, question, transport, options, responseChecker)
 		if err != ErrNotCached {
+			for _, tracker := range c.trackers {
+				tracker.DNSQueryCached(ctx, transport, question)
+			}
 			return cachedAddresses, err
 		}
 	}
@@ -415,18 +758,32 @@ func (c *Client) lookupToExchange(ctx context.Context, transport adapter.DNSTran
 	return MessageToAddresses(response), nil
 }
 
-func (c *Client) questionCache(question dns.Question, transport adapter.DNSTransport) ([]netip.Addr, error) {
-	response, _ := c.loadResponse(question, transport)
+func (c *Client) questionCache(ctx context.Context, question dns.Question, transport adapter.DNSTransport, options adapter.DNSQueryOptions, responseChecker func(responseAddrs []netip.Addr) bool) ([]netip.Addr, error) {
+	response, _, status := c.loadResponse(question, transport)
 	if response == nil {
 		return nil, ErrNotCached
 	}
+	if status == cacheStatusStale {
+		// let Exchange try upstream before falling back to the stale answer
+		return nil, ErrNotCached
+	}
+	c.recordCacheStatus(transport, status)
+	if status == cacheStatusPrefetch {
+		c.refreshAsync(ctx, transport, &dns.Msg{
+			MsgHdr: dns.MsgHdr{
+				RecursionDesired: true,
+			},
+			Question: []dns.Question{question},
+		}, question, options, responseChecker)
+	}
+	response = rewriteResponse(response, options)
 	if response.Rcode != dns.RcodeSuccess {
 		return nil, RcodeError(response.Rcode)
 	}
 	return MessageToAddresses(response), nil
 }
 
-func (c *Client) loadResponse(question dns.Question, transport adapter.DNSTransport) (*dns.Msg, int) {
+func (c *Client) loadResponse(question dns.Question, transport adapter.DNSTransport) (*dns.Msg, int, cacheStatus) {
 	var (
 		response *dns.Msg
 		loaded   bool
@@ -441,9 +798,9 @@ func (c *Client) loadResponse(question dns.Question, transport adapter.DNSTransp
 			})
 		}
 		if !loaded {
-			return nil, 0
+			return nil, 0, cacheStatusFresh
 		}
-		return response.Copy(), 0
+		return response.Copy(), 0, cacheStatusFresh
 	} else {
 		var expireAt time.Time
 		if !c.independentCache {
@@ -455,7 +812,7 @@ func (c *Client) loadResponse(question dns.Question, transport adapter.DNSTransp
 			})
 		}
 		if !loaded {
-			return nil, 0
+			return nil, 0, cacheStatusFresh
 		}
 		timeNow := time.Now()
 		if timeNow.After(expireAt) {
@@ -467,7 +824,19 @@ func (c *Client) loadResponse(question dns.Question, transport adapter.DNSTransp
 					transportTag: transport.Tag(),
 				})
 			}
-			return nil, 0
+			return nil, 0, cacheStatusFresh
+		}
+		if c.serveStale {
+			expireAt = expireAt.Add(-c.staleWindow)
+			if timeNow.After(expireAt) {
+				response = response.Copy()
+				for _, recordList := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+					for _, record := range recordList {
+						record.Header().Ttl = staleAnswerTTL
+					}
+				}
+				return response, staleAnswerTTL, cacheStatusStale
+			}
 		}
 		var originTTL int
 		for _, recordList := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
@@ -481,6 +850,10 @@ func (c *Client) loadResponse(question dns.Question, transport adapter.DNSTransp
 		if nowTTL < 0 {
 			nowTTL = 0
 		}
+		status := cacheStatusFresh
+		if c.prefetch && nowTTL*prefetchRatio < originTTL {
+			status = cacheStatusPrefetch
+		}
 		response = response.Copy()
 		if originTTL > 0 {
 			duration := uint32(originTTL - nowTTL)
@@ -496,7 +869,7 @@ func (c *Client) loadResponse(question dns.Question, transport adapter.DNSTransp
 				}
 			}
 		}
-		return response, nowTTL
+		return response, nowTTL, status
 	}
 }
 
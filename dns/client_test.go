@@ -0,0 +1,91 @@
+package dns
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+type failingTestTransport struct {
+	TransportAdapter
+	queries atomic.Int32
+	block   chan struct{}
+}
+
+func (t *failingTestTransport) Start(stage adapter.StartStage) error {
+	return nil
+}
+
+func (t *failingTestTransport) Close() error {
+	return nil
+}
+
+func (t *failingTestTransport) Reset() {
+}
+
+func (t *failingTestTransport) Exchange(ctx context.Context, message *dns.Msg) (*dns.Msg, error) {
+	t.queries.Add(1)
+	if t.block != nil {
+		select {
+		case <-t.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, E.New("upstream unavailable")
+}
+
+func newStaleTestClient(t *testing.T, block chan struct{}) (*Client, *failingTestTransport, *dns.Msg) {
+	client := NewClient(ClientOptions{
+		ServeStale: true,
+	})
+	transport := &failingTestTransport{
+		TransportAdapter: NewTransportAdapter(C.DNSTypeUDP, "test", nil),
+		block:            block,
+	}
+	message := new(dns.Msg).SetQuestion("www.test.", dns.TypeA)
+	response := new(dns.Msg).SetReply(message)
+	response.Answer = []dns.RR{dnssecTestRR(t, "www.test. 300 IN A 1.2.3.4")}
+	// expired an hour ago, still within the stale window
+	client.cache.AddWithLifetime(message.Question[0], response, client.staleWindow-time.Hour)
+	return client, transport, message
+}
+
+func TestClientServeStaleOnFailure(t *testing.T) {
+	t.Parallel()
+	client, transport, message := newStaleTestClient(t, nil)
+	for i := 0; i < 3; i++ {
+		response, err := client.Exchange(context.Background(), transport, message, adapter.DNSQueryOptions{}, nil)
+		require.NoError(t, err)
+		require.Len(t, response.Answer, 1)
+		require.Equal(t, uint32(staleAnswerTTL), response.Answer[0].Header().Ttl)
+	}
+	// failed refreshes are not retried within the recheck timer
+	require.Equal(t, int32(1), transport.queries.Load())
+}
+
+func TestClientServeStaleOnTimeout(t *testing.T) {
+	t.Parallel()
+	block := make(chan struct{})
+	defer close(block)
+	client, transport, message := newStaleTestClient(t, block)
+	startAt := time.Now()
+	response, err := client.Exchange(context.Background(), transport, message, adapter.DNSQueryOptions{}, nil)
+	require.NoError(t, err)
+	require.Len(t, response.Answer, 1)
+	require.Less(t, time.Since(startAt), staleRefreshTimeout+time.Second)
+	// the refresh is still in progress, so the stale answer is served at once
+	startAt = time.Now()
+	_, err = client.Exchange(context.Background(), transport, message, adapter.DNSQueryOptions{}, nil)
+	require.NoError(t, err)
+	require.Less(t, time.Since(startAt), staleRefreshTimeout)
+	require.Equal(t, int32(1), transport.queries.Load())
+}
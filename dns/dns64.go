@@ -0,0 +1,186 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dns64"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const (
+	dns64DiscoveryMinTTL    = time.Minute
+	dns64DiscoveryRetryTime = time.Minute
+)
+
+type dns64Discovery struct {
+	prefix   netip.Prefix
+	expireAt time.Time
+}
+
+// addDNS64Prefix records a prefix used to synthesize addresses, discovered prefixes are recorded by their transport
+// and configured prefixes by an empty tag.
+func (r *Router) addDNS64Prefix(transportTag string, prefix netip.Prefix) {
+	r.dns64Access.Lock()
+	defer r.dns64Access.Unlock()
+	if common.Contains(r.dns64Prefixes[transportTag], prefix) {
+		return
+	}
+	r.dns64Prefixes[transportTag] = append(r.dns64Prefixes[transportTag], prefix)
+}
+
+func (r *Router) LookupDNS64(ip netip.Addr) (netip.Addr, bool) {
+	r.dns64Access.RLock()
+	defer r.dns64Access.RUnlock()
+	for _, prefixes := range r.dns64Prefixes {
+		for _, prefix := range prefixes {
+			address, loaded := dns64.Extract(prefix, ip)
+			if loaded {
+				return address, true
+			}
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// resetDNS64 drops discovered prefixes when the network changes, prefixes configured in rules are kept.
+func (r *Router) resetDNS64() {
+	r.dns64DiscoveryAccess.Lock()
+	clear(r.dns64Discovered)
+	r.dns64DiscoveryAccess.Unlock()
+	r.dns64Access.Lock()
+	for transportTag := range r.dns64Prefixes {
+		if transportTag != "" {
+			delete(r.dns64Prefixes, transportTag)
+		}
+	}
+	r.dns64Access.Unlock()
+}
+
+// synthesizeDNS64 answers an AAAA query without AAAA records with addresses synthesized from the A records (RFC 6147).
+func (r *Router) synthesizeDNS64(ctx context.Context, transport adapter.DNSTransport, message *mDNS.Msg, response *mDNS.Msg, options adapter.DNSQueryOptions) *mDNS.Msg {
+	question := message.Question[0]
+	if question.Qtype != mDNS.TypeAAAA || question.Qclass != mDNS.ClassINET || message.CheckingDisabled {
+		return response
+	}
+	if response.Rcode == mDNS.RcodeNameError {
+		return response
+	}
+	for _, answer := range response.Answer {
+		if record, isAAAA := answer.(*mDNS.AAAA); isAAAA && !M.AddrFromIP(record.AAAA).Is4In6() {
+			return response
+		}
+	}
+	prefix := options.DNS64Prefix
+	if prefix.IsValid() {
+		r.addDNS64Prefix("", prefix)
+	} else {
+		prefix = r.discoverDNS64Prefix(ctx, transport)
+		r.addDNS64Prefix(transport.Tag(), prefix)
+	}
+	queryA := message.Copy()
+	queryA.Question[0].Qtype = mDNS.TypeA
+	optionsA := options
+	optionsA.Strategy = C.DomainStrategyAsIS
+	optionsA.DNS64 = false
+	responseA, err := r.client.Exchange(ctx, transport, queryA, optionsA, nil)
+	if err != nil {
+		r.logger.DebugContext(ctx, E.Cause(err, "DNS64: exchange A records for ", FormatQuestion(question.String())))
+		return response
+	}
+	if responseA.Rcode != mDNS.RcodeSuccess {
+		return response
+	}
+	var ttlLimit uint32
+	for _, record := range response.Ns {
+		if soa, isSOA := record.(*mDNS.SOA); isSOA {
+			ttlLimit = min(soa.Hdr.Ttl, soa.Minttl)
+		}
+	}
+	var (
+		answers     []mDNS.RR
+		synthesized bool
+	)
+	for _, answer := range responseA.Answer {
+		record, isA := answer.(*mDNS.A)
+		if !isA {
+			answers = append(answers, answer)
+			continue
+		}
+		header := record.Hdr
+		header.Rrtype = mDNS.TypeAAAA
+		if ttlLimit > 0 && header.Ttl > ttlLimit {
+			header.Ttl = ttlLimit
+		}
+		answers = append(answers, &mDNS.AAAA{
+			Hdr:  header,
+			AAAA: dns64.Synthesize(prefix, M.AddrFromIP(record.A)).AsSlice(),
+		})
+		synthesized = true
+	}
+	if !synthesized {
+		return response
+	}
+	r.logger.DebugContext(ctx, "DNS64: synthesized ", FormatQuestion(question.String()), " with prefix ", prefix)
+	synthesizedResponse := responseA.Copy()
+	synthesizedResponse.Id = message.Id
+	synthesizedResponse.Question = message.Question
+	synthesizedResponse.Answer = answers
+	synthesizedResponse.AuthenticatedData = false
+	synthesizedResponse.Extra = nil
+	if responseOPT := responseA.IsEdns0(); responseOPT != nil {
+		synthesizedResponse.Extra = []mDNS.RR{responseOPT}
+	}
+	return synthesizedResponse
+}
+
+// discoverDNS64Prefix discovers the NAT64 prefix of the network behind the transport (RFC 7050),
+// the well-known prefix is used if none is found.
+func (r *Router) discoverDNS64Prefix(ctx context.Context, transport adapter.DNSTransport) netip.Prefix {
+	r.dns64DiscoveryAccess.Lock()
+	defer r.dns64DiscoveryAccess.Unlock()
+	discovery, loaded := r.dns64Discovered[transport.Tag()]
+	if loaded && time.Now().Before(discovery.expireAt) {
+		return discovery.prefix
+	}
+	discovery = dns64Discovery{
+		prefix:   dns64.WellKnownPrefix,
+		expireAt: time.Now().Add(dns64DiscoveryRetryTime),
+	}
+	message := new(mDNS.Msg)
+	message.SetQuestion(dns64.WellKnownName, mDNS.TypeAAAA)
+	response, err := r.client.Exchange(ctx, transport, message, adapter.DNSQueryOptions{}, nil)
+	if err != nil {
+		r.logger.DebugContext(ctx, E.Cause(err, "DNS64: discover prefix of ", transport.Tag()))
+	} else {
+		var (
+			addresses []netip.Addr
+			ttl       uint32
+		)
+		for _, answer := range response.Answer {
+			if record, isAAAA := answer.(*mDNS.AAAA); isAAAA {
+				addresses = append(addresses, M.AddrFromIP(record.AAAA))
+				if ttl == 0 || record.Hdr.Ttl < ttl {
+					ttl = record.Hdr.Ttl
+				}
+			}
+		}
+		prefixes := dns64.DiscoverPrefixes(addresses)
+		if len(prefixes) > 0 {
+			discovery.prefix = prefixes[0]
+			discovery.expireAt = time.Now().Add(max(time.Duration(ttl)*time.Second, dns64DiscoveryMinTTL))
+			r.logger.InfoContext(ctx, "DNS64: discovered prefix ", discovery.prefix, " of ", transport.Tag())
+		} else {
+			r.logger.DebugContext(ctx, "DNS64: no prefix discovered for ", transport.Tag(), ", using the well-known prefix")
+		}
+	}
+	r.dns64Discovered[transport.Tag()] = discovery
+	return discovery.prefix
+}
@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dns64"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	M "github.com/sagernet/sing/common/metadata"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+func newDNS64TestRouter(t *testing.T) (*Router, *dnssecTestTransport) {
+	transport := &dnssecTestTransport{
+		TransportAdapter: NewTransportAdapter(C.DNSTypeUDP, "test", nil),
+		responses:        make(map[dns.Question]*dns.Msg),
+	}
+	soa := dnssecTestRR(t, "test. 3600 IN SOA ns.test. admin.test. 1 3600 600 86400 300")
+	transport.add("www.test.", dns.TypeAAAA, dns.RcodeSuccess, nil, []dns.RR{soa})
+	transport.add("www.test.", dns.TypeA, dns.RcodeSuccess, []dns.RR{dnssecTestRR(t, "www.test. 600 IN A 192.0.2.1")}, nil)
+	router := &Router{
+		logger:          log.NewNOPFactory().NewLogger("dns"),
+		client:          NewClient(ClientOptions{DisableCache: true}),
+		dns64Prefixes:   make(map[string][]netip.Prefix),
+		dns64Discovered: make(map[string]dns64Discovery),
+	}
+	return router, transport
+}
+
+func synthesizeDNS64TestQuery(t *testing.T, router *Router, transport adapter.DNSTransport, prefix netip.Prefix) *dns.Msg {
+	message := new(dns.Msg).SetQuestion("www.test.", dns.TypeAAAA)
+	response, err := transport.Exchange(context.Background(), message)
+	require.NoError(t, err)
+	return router.synthesizeDNS64(context.Background(), transport, message, response, adapter.DNSQueryOptions{
+		DNS64:       true,
+		DNS64Prefix: prefix,
+	})
+}
+
+func TestDNS64Synthesize(t *testing.T) {
+	t.Parallel()
+	router, transport := newDNS64TestRouter(t)
+	prefix := netip.MustParsePrefix("2001:db8:64::/96")
+	response := synthesizeDNS64TestQuery(t, router, transport, prefix)
+	require.Len(t, response.Answer, 1)
+	record := response.Answer[0].(*dns.AAAA)
+	// the TTL is limited by the negative caching TTL of the AAAA response
+	require.Equal(t, uint32(300), record.Hdr.Ttl)
+	address := M.AddrFromIP(record.AAAA)
+	require.Equal(t, netip.MustParseAddr("2001:db8:64::c000:201"), address)
+	origin, loaded := router.LookupDNS64(address)
+	require.True(t, loaded)
+	require.Equal(t, netip.MustParseAddr("192.0.2.1"), origin)
+	router.resetDNS64()
+	_, loaded = router.LookupDNS64(address)
+	require.True(t, loaded)
+}
+
+func TestDNS64DiscoveredPrefix(t *testing.T) {
+	t.Parallel()
+	router, transport := newDNS64TestRouter(t)
+	prefix := netip.MustParsePrefix("2001:db8:122::/48")
+	transport.add(dns64.WellKnownName, dns.TypeAAAA, dns.RcodeSuccess, []dns.RR{&dns.AAAA{
+		Hdr:  dns.RR_Header{Name: dns64.WellKnownName, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 600},
+		AAAA: dns64.Synthesize(prefix, netip.MustParseAddr("192.0.0.170")).AsSlice(),
+	}}, nil)
+	response := synthesizeDNS64TestQuery(t, router, transport, netip.Prefix{})
+	require.Len(t, response.Answer, 1)
+	address := M.AddrFromIP(response.Answer[0].(*dns.AAAA).AAAA)
+	require.Equal(t, dns64.Synthesize(prefix, netip.MustParseAddr("192.0.2.1")), address)
+	_, loaded := router.LookupDNS64(address)
+	require.True(t, loaded)
+	// discovered prefixes are dropped when the network changes
+	router.resetDNS64()
+	_, loaded = router.LookupDNS64(address)
+	require.False(t, loaded)
+}
+
+func TestDNS64ExistingAAAA(t *testing.T) {
+	t.Parallel()
+	router, transport := newDNS64TestRouter(t)
+	transport.add("www.test.", dns.TypeAAAA, dns.RcodeSuccess, []dns.RR{dnssecTestRR(t, "www.test. 600 IN AAAA 2001:db8::1")}, nil)
+	response := synthesizeDNS64TestQuery(t, router, transport, netip.MustParsePrefix("2001:db8:64::/96"))
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, netip.MustParseAddr("2001:db8::1"), M.AddrFromIP(response.Answer[0].(*dns.AAAA).AAAA))
+	require.Empty(t, router.dns64Prefixes)
+}
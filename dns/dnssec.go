@@ -0,0 +1,664 @@
+package dns
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/contrab/freelru"
+	"github.com/sagernet/sing/contrab/maphash"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	dnssecUDPSize      = 1232
+	dnssecMaxDepth     = 16
+	dnssecZoneCapacity = 1024
+
+	// dnssecMaxIterations is the NSEC3 iteration limit of RFC 9276, zones above it are treated as insecure.
+	dnssecMaxIterations = 150
+)
+
+// defaultTrustAnchors are the DS records of the root zone KSKs published by IANA.
+var defaultTrustAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+type dnssecValidator struct {
+	timeout time.Duration
+	anchors map[string][]*dns.DS
+	zones   freelru.Cache[dnssecZoneKey, *dnssecZone]
+}
+
+type dnssecZoneKey struct {
+	transportTag string
+	zone         string
+}
+
+// dnssecZone is a validated link of the chain of trust, either the keys of a signed zone or a proven insecure delegation.
+type dnssecZone struct {
+	keys     []*dns.DNSKEY
+	insecure bool
+}
+
+func newDNSSECValidator(timeout time.Duration, trustAnchors []dns.RR) *dnssecValidator {
+	if len(trustAnchors) == 0 {
+		trustAnchors = common.Map(defaultTrustAnchors, func(it string) dns.RR {
+			return common.Must1(dns.NewRR(it))
+		})
+	}
+	anchors := make(map[string][]*dns.DS)
+	for _, anchor := range trustAnchors {
+		var ds *dns.DS
+		switch record := anchor.(type) {
+		case *dns.DS:
+			ds = record
+		case *dns.DNSKEY:
+			ds = record.ToDS(dns.SHA256)
+		}
+		if ds == nil {
+			continue
+		}
+		zone := dns.CanonicalName(ds.Hdr.Name)
+		anchors[zone] = append(anchors[zone], ds)
+	}
+	return &dnssecValidator{
+		timeout: timeout,
+		anchors: anchors,
+		zones:   common.Must1(freelru.NewSharded[dnssecZoneKey, *dnssecZone](dnssecZoneCapacity, maphash.NewHasher[dnssecZoneKey]().Hash32)),
+	}
+}
+
+func (v *dnssecValidator) Reset() {
+	v.zones.Purge()
+}
+
+// Validate checks the response of the question against the chain of trust,
+// it returns whether the response is secure, or an error if the response is bogus.
+func (v *dnssecValidator) Validate(ctx context.Context, transport adapter.DNSTransport, question dns.Question, response *dns.Msg) (bool, error) {
+	secure, _, err := v.validate(ctx, transport, question, response, 0)
+	return secure, err
+}
+
+// validate also returns the type bitmap of the record proving that the queried type does not exist at the name.
+func (v *dnssecValidator) validate(ctx context.Context, transport adapter.DNSTransport, question dns.Question, response *dns.Msg, depth int) (bool, []uint16, error) {
+	if depth > dnssecMaxDepth {
+		return false, nil, E.New("chain of trust too deep")
+	}
+	answerSecure, wildcards, err := v.verifyAnswer(ctx, transport, response.Answer, depth)
+	if err != nil {
+		return false, nil, err
+	}
+	if !answerSecure {
+		return false, nil, nil
+	}
+	name, answered := dnssecAnswerTarget(question, response.Answer)
+	if answered && len(wildcards) == 0 {
+		return true, nil, nil
+	}
+	denial, err := v.verifyAuthority(ctx, transport, response.Ns, depth)
+	if err != nil {
+		return false, nil, err
+	}
+	for _, wildcard := range wildcards {
+		if !denial.proveWildcardExpansion(wildcard.name, wildcard.labels) {
+			if denial.insecure {
+				return false, nil, nil
+			}
+			return false, nil, E.New("missing proof of wildcard expansion for ", wildcard.name)
+		}
+	}
+	if answered {
+		return true, nil, nil
+	}
+	var (
+		proven bool
+		optOut bool
+		types  []uint16
+	)
+	if response.Rcode == dns.RcodeNameError {
+		proven, optOut = denial.proveNameError(name)
+	} else {
+		proven, optOut, types = denial.proveNoData(name, question.Qtype)
+	}
+	if proven {
+		return !optOut, types, nil
+	}
+	insecure, err := v.isInsecure(ctx, transport, name, question.Qtype, depth)
+	if err != nil {
+		return false, nil, err
+	}
+	if !insecure {
+		return false, nil, E.New("missing proof of non-existence for ", name)
+	}
+	return false, nil, nil
+}
+
+type dnssecRRSetKey struct {
+	name   string
+	rrType uint16
+}
+
+type dnssecWildcard struct {
+	name   string
+	labels uint8
+}
+
+// verifyAnswer verifies all RRsets of the answer section, an unsigned RRset makes the answer insecure only if its zone is proven insecure.
+func (v *dnssecValidator) verifyAnswer(ctx context.Context, transport adapter.DNSTransport, records []dns.RR, depth int) (bool, []dnssecWildcard, error) {
+	keys, rrSets, signatures := dnssecRRSets(records)
+	var (
+		secure    = true
+		wildcards []dnssecWildcard
+	)
+	for _, key := range keys {
+		rrSet := rrSets[key]
+		if key.rrType == dns.TypeCNAME && dnssecSynthesizedByDNAME(key.name, rrSet, rrSets) {
+			continue
+		}
+		if len(signatures[key]) == 0 {
+			insecure, err := v.isInsecure(ctx, transport, key.name, key.rrType, depth)
+			if err != nil {
+				return false, nil, err
+			}
+			if !insecure {
+				return false, nil, E.New("missing signature for ", key.name, " ", dns.TypeToString[key.rrType])
+			}
+			secure = false
+			continue
+		}
+		signature, err := v.verifyRRSet(ctx, transport, rrSet, signatures[key], depth)
+		if err != nil {
+			return false, nil, err
+		}
+		if signature == nil {
+			secure = false
+			continue
+		}
+		if int(signature.Labels) < dns.CountLabel(key.name) {
+			wildcards = append(wildcards, dnssecWildcard{key.name, signature.Labels})
+		}
+	}
+	return secure, wildcards, nil
+}
+
+// verifyAuthority collects the verified NSEC and NSEC3 records of the authority section, unsigned records are ignored.
+func (v *dnssecValidator) verifyAuthority(ctx context.Context, transport adapter.DNSTransport, records []dns.RR, depth int) (*dnssecDenial, error) {
+	keys, rrSets, signatures := dnssecRRSets(records)
+	var denial dnssecDenial
+	for _, key := range keys {
+		if key.rrType != dns.TypeNSEC && key.rrType != dns.TypeNSEC3 || len(signatures[key]) == 0 {
+			continue
+		}
+		signature, err := v.verifyRRSet(ctx, transport, rrSets[key], signatures[key], depth)
+		if err != nil {
+			return nil, err
+		}
+		if signature == nil {
+			continue
+		}
+		for _, record := range rrSets[key] {
+			switch nsecRecord := record.(type) {
+			case *dns.NSEC:
+				denial.nsec = append(denial.nsec, nsecRecord)
+			case *dns.NSEC3:
+				if nsecRecord.Hash != dns.SHA1 {
+					continue
+				}
+				if nsecRecord.Iterations > dnssecMaxIterations {
+					denial.insecure = true
+					continue
+				}
+				denial.nsec3 = append(denial.nsec3, nsecRecord)
+			}
+		}
+	}
+	return &denial, nil
+}
+
+// verifyRRSet returns the signature that validates the RRset, or nil if the signer zone is insecure.
+func (v *dnssecValidator) verifyRRSet(ctx context.Context, transport adapter.DNSTransport, rrSet []dns.RR, signatures []*dns.RRSIG, depth int) (*dns.RRSIG, error) {
+	var (
+		usable   bool
+		insecure bool
+		lastErr  error
+	)
+	timeNow := time.Now()
+	for _, signature := range signatures {
+		if !dnssecAlgorithmSupported(signature.Algorithm) {
+			continue
+		}
+		usable = true
+		signer := dns.CanonicalName(signature.SignerName)
+		if !dns.IsSubDomain(signer, dns.CanonicalName(rrSet[0].Header().Name)) {
+			lastErr = E.New("signer ", signer, " is not an ancestor of ", rrSet[0].Header().Name)
+			continue
+		}
+		zone, err := v.loadZone(ctx, transport, signer, depth+1)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if zone.insecure {
+			insecure = true
+			continue
+		}
+		if !signature.ValidityPeriod(timeNow) {
+			lastErr = E.New("signature of ", rrSet[0].Header().Name, " ", dns.TypeToString[signature.TypeCovered], " expired")
+			continue
+		}
+		for _, key := range zone.keys {
+			if key.KeyTag() != signature.KeyTag || key.Algorithm != signature.Algorithm {
+				continue
+			}
+			err = signature.Verify(key, rrSet)
+			if err == nil {
+				return signature, nil
+			}
+			lastErr = E.Cause(err, "verify ", rrSet[0].Header().Name, " ", dns.TypeToString[signature.TypeCovered])
+		}
+	}
+	if !usable {
+		// signatures of unsupported algorithms are treated as missing
+		insecure, lastErr = v.isInsecure(ctx, transport, rrSet[0].Header().Name, rrSet[0].Header().Rrtype, depth)
+		if lastErr == nil && !insecure {
+			lastErr = E.New("no supported signature for ", rrSet[0].Header().Name, " ", dns.TypeToString[rrSet[0].Header().Rrtype])
+		}
+	}
+	if insecure {
+		return nil, nil
+	}
+	return nil, lastErr
+}
+
+// isInsecure checks if the name belongs to a zone below a proven insecure delegation.
+func (v *dnssecValidator) isInsecure(ctx context.Context, transport adapter.DNSTransport, name string, rrType uint16, depth int) (bool, error) {
+	name = dns.CanonicalName(name)
+	if rrType == dns.TypeDS && name != "." {
+		// DS records belong to the parent side of the zone cut
+		name = dnssecParent(name)
+	}
+	for ancestor := name; ; ancestor = dnssecParent(ancestor) {
+		if _, anchored := v.anchors[ancestor]; anchored {
+			break
+		}
+		if zone, loaded := v.zones.Get(dnssecZoneKey{transport.Tag(), ancestor}); loaded {
+			if zone.insecure {
+				return true, nil
+			}
+			break
+		}
+		if ancestor == "." {
+			break
+		}
+	}
+	zoneName, err := v.findZone(ctx, transport, name)
+	if err != nil {
+		return false, err
+	}
+	zone, err := v.loadZone(ctx, transport, zoneName, depth+1)
+	if err != nil {
+		return false, err
+	}
+	return zone.insecure, nil
+}
+
+// findZone looks up the apex of the zone containing the name by its SOA record.
+func (v *dnssecValidator) findZone(ctx context.Context, transport adapter.DNSTransport, name string) (string, error) {
+	for {
+		response, err := v.exchange(ctx, transport, name, dns.TypeSOA)
+		if err != nil {
+			return "", E.Cause(err, "query SOA of ", name)
+		}
+		var aliased bool
+		for _, record := range response.Answer {
+			switch answer := record.(type) {
+			case *dns.SOA:
+				if dns.CanonicalName(answer.Hdr.Name) == name {
+					return name, nil
+				}
+			case *dns.CNAME:
+				aliased = aliased || dns.CanonicalName(answer.Hdr.Name) == name
+			}
+		}
+		if !aliased {
+			for _, record := range response.Ns {
+				if soa, isSOA := record.(*dns.SOA); isSOA {
+					zone := dns.CanonicalName(soa.Hdr.Name)
+					if dns.IsSubDomain(zone, name) {
+						return zone, nil
+					}
+				}
+			}
+		}
+		if !aliased || name == "." {
+			return "", E.New("missing SOA of ", name)
+		}
+		// the apex of a zone cannot own a CNAME record
+		name = dnssecParent(name)
+	}
+}
+
+// loadZone authenticates the keys of the zone from its DS records, which are validated against the parent zone or configured as trust anchors.
+func (v *dnssecValidator) loadZone(ctx context.Context, transport adapter.DNSTransport, zone string, depth int) (*dnssecZone, error) {
+	key := dnssecZoneKey{transport.Tag(), zone}
+	cachedZone, loaded := v.zones.Get(key)
+	if loaded {
+		return cachedZone, nil
+	}
+	if depth > dnssecMaxDepth {
+		return nil, E.New("chain of trust too deep")
+	}
+	dsSet, anchored := v.anchors[zone]
+	if !anchored {
+		if zone == "." {
+			return v.storeZone(key, &dnssecZone{insecure: true}, 0), nil
+		}
+		response, err := v.exchange(ctx, transport, zone, dns.TypeDS)
+		if err != nil {
+			return nil, E.Cause(err, "query DS of ", zone)
+		}
+		secure, types, err := v.validate(ctx, transport, dns.Question{Name: zone, Qtype: dns.TypeDS, Qclass: dns.ClassINET}, response, depth)
+		if err != nil {
+			return nil, E.Cause(err, "validate DS of ", zone)
+		}
+		if !secure {
+			return v.storeZone(key, &dnssecZone{insecure: true}, dnssecMinimumTTL(response)), nil
+		}
+		for _, record := range response.Answer {
+			if ds, isDS := record.(*dns.DS); isDS && dns.CanonicalName(ds.Hdr.Name) == zone {
+				dsSet = append(dsSet, ds)
+			}
+		}
+		if len(dsSet) == 0 {
+			// RFC 6840 4.4: only a delegation proves that the zone is unsigned
+			if !common.Contains(types, dns.TypeNS) || common.Contains(types, dns.TypeSOA) {
+				return nil, E.New("missing proof of insecure delegation for ", zone)
+			}
+			return v.storeZone(key, &dnssecZone{insecure: true}, dnssecMinimumTTL(response)), nil
+		}
+	}
+	dsSet = common.Filter(dsSet, func(it *dns.DS) bool {
+		return dnssecAlgorithmSupported(it.Algorithm) && dnssecDigestSupported(it.DigestType)
+	})
+	if len(dsSet) == 0 {
+		// RFC 4035 5.2: zones signed only with unsupported algorithms are treated as insecure
+		return v.storeZone(key, &dnssecZone{insecure: true}, 0), nil
+	}
+	response, err := v.exchange(ctx, transport, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, E.Cause(err, "query DNSKEY of ", zone)
+	}
+	var (
+		rrSet      []dns.RR
+		keys       []*dns.DNSKEY
+		signatures []*dns.RRSIG
+	)
+	for _, record := range response.Answer {
+		if dns.CanonicalName(record.Header().Name) != zone {
+			continue
+		}
+		switch answer := record.(type) {
+		case *dns.DNSKEY:
+			rrSet = append(rrSet, answer)
+			if answer.Flags&dns.ZONE != 0 {
+				keys = append(keys, answer)
+			}
+		case *dns.RRSIG:
+			if answer.TypeCovered == dns.TypeDNSKEY {
+				signatures = append(signatures, answer)
+			}
+		}
+	}
+	timeNow := time.Now()
+	for _, ds := range dsSet {
+		for _, entryKey := range keys {
+			if entryKey.KeyTag() != ds.KeyTag || entryKey.Algorithm != ds.Algorithm {
+				continue
+			}
+			keyDS := entryKey.ToDS(ds.DigestType)
+			if keyDS == nil || !strings.EqualFold(keyDS.Digest, ds.Digest) {
+				continue
+			}
+			for _, signature := range signatures {
+				if signature.KeyTag != ds.KeyTag || !signature.ValidityPeriod(timeNow) || signature.Verify(entryKey, rrSet) != nil {
+					continue
+				}
+				ttl := dnssecMinimumTTL(response)
+				if untilExpiration := time.Until(time.Unix(int64(signature.Expiration), 0)); untilExpiration < time.Duration(ttl)*time.Second {
+					ttl = uint32(untilExpiration / time.Second)
+				}
+				return v.storeZone(key, &dnssecZone{keys: keys}, ttl), nil
+			}
+		}
+	}
+	return nil, E.New("no DNSKEY of ", zone, " matches its DS records")
+}
+
+// storeZone caches the zone for the TTL in seconds, zero means until the cache is reset.
+func (v *dnssecValidator) storeZone(key dnssecZoneKey, zone *dnssecZone, ttl uint32) *dnssecZone {
+	if ttl == 0 {
+		v.zones.Add(key, zone)
+	} else {
+		v.zones.AddWithLifetime(key, zone, time.Duration(ttl)*time.Second)
+	}
+	return zone
+}
+
+func (v *dnssecValidator) exchange(ctx context.Context, transport adapter.DNSTransport, name string, rrType uint16) (*dns.Msg, error) {
+	message := &dns.Msg{
+		MsgHdr: dns.MsgHdr{
+			Id:                dns.Id(),
+			RecursionDesired:  true,
+			CheckingDisabled:  true,
+			AuthenticatedData: true,
+		},
+		Question: []dns.Question{{
+			Name:   name,
+			Qtype:  rrType,
+			Qclass: dns.ClassINET,
+		}},
+	}
+	message.SetEdns0(dnssecUDPSize, true)
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+	response, err := transport.Exchange(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	if response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError {
+		return nil, RcodeError(response.Rcode)
+	}
+	return response, nil
+}
+
+// dnssecRequest copies the request with the DO and CD bits set, so that the upstream returns signatures for unvalidated data.
+func dnssecRequest(message *dns.Msg) *dns.Msg {
+	request := message.Copy()
+	request.CheckingDisabled = true
+	if opt := request.IsEdns0(); opt != nil {
+		opt.SetDo()
+	} else {
+		request.SetEdns0(dnssecUDPSize, true)
+	}
+	return request
+}
+
+// dnssecResponse prepares a validated response for the request, removing DNSSEC records and the AD bit unless requested (RFC 4035 3.2.1, RFC 6840 5.8).
+func dnssecResponse(request *dns.Msg, response *dns.Msg) {
+	requestOpt := request.IsEdns0()
+	dnssecOK := requestOpt != nil && requestOpt.Do()
+	if !dnssecOK {
+		stripDNSSECRecords(request, response)
+		if !request.AuthenticatedData {
+			response.AuthenticatedData = false
+		}
+	}
+	response.CheckingDisabled = request.CheckingDisabled
+	response.Extra = common.Filter(response.Extra, func(it dns.RR) bool {
+		return it.Header().Rrtype != dns.TypeOPT
+	})
+	if requestOpt != nil {
+		response.SetEdns0(requestOpt.UDPSize(), dnssecOK)
+	}
+}
+
+// stripDNSSECRecords removes DNSSEC records not explicitly queried for from the response.
+func stripDNSSECRecords(request *dns.Msg, response *dns.Msg) {
+	var queryType uint16
+	if len(request.Question) > 0 {
+		queryType = request.Question[0].Qtype
+	}
+	isDNSSECRecord := func(it dns.RR) bool {
+		switch rrType := it.Header().Rrtype; rrType {
+		case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3:
+			return rrType != queryType
+		default:
+			return false
+		}
+	}
+	response.Answer = common.Filter(response.Answer, func(it dns.RR) bool {
+		return !isDNSSECRecord(it)
+	})
+	response.Ns = common.Filter(response.Ns, func(it dns.RR) bool {
+		return !isDNSSECRecord(it)
+	})
+}
+
+// dnssecBogusResponse is the SERVFAIL response of a bogus answer, with the extended error of RFC 8914 for EDNS requests.
+func dnssecBogusResponse(message *dns.Msg, err error) *dns.Msg {
+	response := FixedResponseStatus(message, dns.RcodeServerFailure)
+	if requestOpt := message.IsEdns0(); requestOpt != nil {
+		response.SetEdns0(requestOpt.UDPSize(), requestOpt.Do())
+		responseOpt := response.IsEdns0()
+		responseOpt.Option = append(responseOpt.Option, &dns.EDNS0_EDE{
+			InfoCode:  dns.ExtendedErrorCodeDNSBogus,
+			ExtraText: err.Error(),
+		})
+	}
+	return response
+}
+
+func dnssecRRSets(records []dns.RR) ([]dnssecRRSetKey, map[dnssecRRSetKey][]dns.RR, map[dnssecRRSetKey][]*dns.RRSIG) {
+	var keys []dnssecRRSetKey
+	rrSets := make(map[dnssecRRSetKey][]dns.RR)
+	signatures := make(map[dnssecRRSetKey][]*dns.RRSIG)
+	for _, record := range records {
+		header := record.Header()
+		if header.Rrtype == dns.TypeOPT {
+			continue
+		}
+		if signature, isSignature := record.(*dns.RRSIG); isSignature {
+			key := dnssecRRSetKey{dns.CanonicalName(header.Name), signature.TypeCovered}
+			signatures[key] = append(signatures[key], signature)
+			continue
+		}
+		key := dnssecRRSetKey{dns.CanonicalName(header.Name), header.Rrtype}
+		if _, loaded := rrSets[key]; !loaded {
+			keys = append(keys, key)
+		}
+		rrSets[key] = append(rrSets[key], record)
+	}
+	return keys, rrSets, signatures
+}
+
+// dnssecAnswerTarget follows the CNAME chain of the answer, and returns the final name and whether it has records of the queried type.
+func dnssecAnswerTarget(question dns.Question, records []dns.RR) (string, bool) {
+	name := dns.CanonicalName(question.Name)
+	for range len(records) + 1 {
+		var target string
+		for _, record := range records {
+			header := record.Header()
+			if dns.CanonicalName(header.Name) != name {
+				continue
+			}
+			if header.Rrtype == question.Qtype || question.Qtype == dns.TypeANY {
+				return name, true
+			}
+			if cname, isCNAME := record.(*dns.CNAME); isCNAME {
+				target = dns.CanonicalName(cname.Target)
+			}
+		}
+		if target == "" {
+			break
+		}
+		name = target
+	}
+	return name, false
+}
+
+// dnssecSynthesizedByDNAME checks if the CNAME is the substitution of a DNAME record of the answer (RFC 6672 5.3.1),
+// a mismatched CNAME is verified as a normal RRset.
+func dnssecSynthesizedByDNAME(name string, cnameSet []dns.RR, rrSets map[dnssecRRSetKey][]dns.RR) bool {
+	if len(cnameSet) != 1 {
+		return false
+	}
+	target := dns.CanonicalName(cnameSet[0].(*dns.CNAME).Target)
+	for key, rrSet := range rrSets {
+		if key.rrType != dns.TypeDNAME || key.name == name || !dns.IsSubDomain(key.name, name) {
+			continue
+		}
+		for _, record := range rrSet {
+			prefix := name[:len(name)-len(key.name)]
+			if key.name == "." {
+				prefix = name
+			}
+			substitution := dns.CanonicalName(record.(*dns.DNAME).Target)
+			if substitution == "." {
+				substitution = prefix
+			} else {
+				substitution = prefix + substitution
+			}
+			if substitution == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func dnssecMinimumTTL(response *dns.Msg) uint32 {
+	var ttl uint32
+	for _, recordList := range [][]dns.RR{response.Answer, response.Ns} {
+		for _, record := range recordList {
+			if ttl == 0 || record.Header().Ttl < ttl {
+				ttl = record.Header().Ttl
+			}
+		}
+	}
+	if ttl == 0 {
+		ttl = 1
+	}
+	return ttl
+}
+
+func dnssecParent(name string) string {
+	labelIndexes := dns.Split(name)
+	if len(labelIndexes) < 2 {
+		return "."
+	}
+	return name[labelIndexes[1]:]
+}
+
+func dnssecAlgorithmSupported(algorithm uint8) bool {
+	switch algorithm {
+	case dns.RSASHA1, dns.RSASHA1NSEC3SHA1, dns.RSASHA256, dns.RSASHA512, dns.ECDSAP256SHA256, dns.ECDSAP384SHA384, dns.ED25519:
+		return true
+	default:
+		return false
+	}
+}
+
+func dnssecDigestSupported(digestType uint8) bool {
+	switch digestType {
+	case dns.SHA1, dns.SHA256, dns.SHA384:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,187 @@
+package dns
+
+import (
+	"cmp"
+	"strings"
+
+	"github.com/sagernet/sing/common"
+
+	"github.com/miekg/dns"
+)
+
+// dnssecDenial holds the verified NSEC and NSEC3 records of a response, used to prove that a name or type does not exist.
+type dnssecDenial struct {
+	nsec  []*dns.NSEC
+	nsec3 []*dns.NSEC3
+	// insecure is set if NSEC3 records were ignored for exceeding the iteration limit.
+	insecure bool
+}
+
+// proveNameError proves that the name does not exist and no wildcard could have matched it (RFC 4035 5.4, RFC 5155 8.4).
+func (d *dnssecDenial) proveNameError(name string) (proven bool, optOut bool) {
+	if covering := d.coveringNSEC(name); covering != nil {
+		encloser := dnssecClosestEncloserNSEC(name, covering)
+		if d.coveringNSEC(dnssecWildcardName(encloser)) != nil {
+			return true, false
+		}
+	}
+	if encloser, nextCloserRecord := d.closestEncloserProof(name); nextCloserRecord != nil {
+		if d.coveringNSEC3(dnssecWildcardName(encloser)) != nil {
+			return true, nextCloserRecord.Flags&1 != 0
+		}
+	}
+	if d.insecure {
+		return true, true
+	}
+	return false, false
+}
+
+// proveNoData proves that the name has no records of the type (RFC 4035 5.4, RFC 5155 8.5-8.7),
+// and returns the type bitmap of the record matching the name if there is one.
+func (d *dnssecDenial) proveNoData(name string, rrType uint16) (proven bool, optOut bool, types []uint16) {
+	for _, record := range d.nsec {
+		if dns.CanonicalName(record.Hdr.Name) == name {
+			if dnssecTypeDenied(record.TypeBitMap, rrType) {
+				return true, false, record.TypeBitMap
+			}
+			return false, false, nil
+		}
+	}
+	for _, record := range d.nsec3 {
+		if record.Match(name) {
+			if dnssecTypeDenied(record.TypeBitMap, rrType) {
+				return true, false, record.TypeBitMap
+			}
+			return false, false, nil
+		}
+	}
+	if covering := d.coveringNSEC(name); covering != nil {
+		if dns.IsSubDomain(name, dns.CanonicalName(covering.NextDomain)) {
+			// the name is an empty non-terminal
+			return true, false, nil
+		}
+		encloser := dnssecClosestEncloserNSEC(name, covering)
+		wildcard := dnssecWildcardName(encloser)
+		for _, record := range d.nsec {
+			if dns.CanonicalName(record.Hdr.Name) == wildcard && dnssecTypeDenied(record.TypeBitMap, rrType) {
+				return true, false, nil
+			}
+		}
+	}
+	if encloser, nextCloserRecord := d.closestEncloserProof(name); nextCloserRecord != nil {
+		if rrType == dns.TypeDS && nextCloserRecord.Flags&1 != 0 {
+			// an opt-out span may contain unsigned delegations
+			return true, true, nil
+		}
+		wildcard := dnssecWildcardName(encloser)
+		for _, record := range d.nsec3 {
+			if record.Match(wildcard) && dnssecTypeDenied(record.TypeBitMap, rrType) {
+				return true, false, nil
+			}
+		}
+	}
+	if d.insecure {
+		return true, true, nil
+	}
+	return false, false, nil
+}
+
+// proveWildcardExpansion proves that the name did not exist when the answer was synthesized from a wildcard with the given labels.
+func (d *dnssecDenial) proveWildcardExpansion(name string, labels uint8) bool {
+	if d.coveringNSEC(name) != nil {
+		return true
+	}
+	return d.coveringNSEC3(dnssecAncestor(name, int(labels)+1)) != nil
+}
+
+func (d *dnssecDenial) coveringNSEC(name string) *dns.NSEC {
+	for _, record := range d.nsec {
+		owner := dns.CanonicalName(record.Hdr.Name)
+		if owner != name && dns.IsSubDomain(owner, name) && dnssecIsDelegation(record.TypeBitMap) {
+			// names below a zone cut are not covered by the NSEC records of the parent
+			continue
+		}
+		if dnssecNSECCovers(owner, dns.CanonicalName(record.NextDomain), name) {
+			return record
+		}
+	}
+	return nil
+}
+
+func (d *dnssecDenial) coveringNSEC3(name string) *dns.NSEC3 {
+	for _, record := range d.nsec3 {
+		if record.Cover(name) {
+			return record
+		}
+	}
+	return nil
+}
+
+// closestEncloserProof finds the closest existing ancestor of the name, and the record covering the next closer name (RFC 5155 8.3).
+func (d *dnssecDenial) closestEncloserProof(name string) (string, *dns.NSEC3) {
+	if len(d.nsec3) == 0 {
+		return "", nil
+	}
+	for labels := dns.CountLabel(name) - 1; labels >= 0; labels-- {
+		encloser := dnssecAncestor(name, labels)
+		matched := common.Any(d.nsec3, func(it *dns.NSEC3) bool {
+			return it.Match(encloser) && !(labels > 0 && dnssecIsDelegation(it.TypeBitMap))
+		})
+		if !matched {
+			continue
+		}
+		return encloser, d.coveringNSEC3(dnssecAncestor(name, labels+1))
+	}
+	return "", nil
+}
+
+func dnssecClosestEncloserNSEC(name string, record *dns.NSEC) string {
+	labels := max(dns.CompareDomainName(name, record.Hdr.Name), dns.CompareDomainName(name, record.NextDomain))
+	return dnssecAncestor(name, labels)
+}
+
+// dnssecNSECCovers checks if the name sorts between the owner and next name of an NSEC record, the last record of a zone wraps around to its apex.
+func dnssecNSECCovers(owner string, next string, name string) bool {
+	if dnssecCompareName(owner, next) < 0 {
+		return dnssecCompareName(owner, name) < 0 && dnssecCompareName(name, next) < 0
+	}
+	return dnssecCompareName(owner, name) < 0 || dnssecCompareName(name, next) < 0
+}
+
+// dnssecCompareName compares names in the canonical order of RFC 4034 6.1.
+func dnssecCompareName(a string, b string) int {
+	aLabels := dns.SplitDomainName(dns.CanonicalName(a))
+	bLabels := dns.SplitDomainName(dns.CanonicalName(b))
+	for i := 1; i <= len(aLabels) && i <= len(bLabels); i++ {
+		if result := strings.Compare(aLabels[len(aLabels)-i], bLabels[len(bLabels)-i]); result != 0 {
+			return result
+		}
+	}
+	return cmp.Compare(len(aLabels), len(bLabels))
+}
+
+func dnssecAncestor(name string, labels int) string {
+	labelIndexes := dns.Split(name)
+	if labels <= 0 {
+		return "."
+	}
+	if labels >= len(labelIndexes) {
+		return name
+	}
+	return name[labelIndexes[len(labelIndexes)-labels]:]
+}
+
+func dnssecWildcardName(encloser string) string {
+	if encloser == "." {
+		return "*."
+	}
+	return "*." + encloser
+}
+
+func dnssecTypeDenied(types []uint16, rrType uint16) bool {
+	return !common.Contains(types, rrType) && !common.Contains(types, dns.TypeCNAME)
+}
+
+func dnssecIsDelegation(types []uint16) bool {
+	return common.Contains(types, dns.TypeDNAME) || common.Contains(types, dns.TypeNS) && !common.Contains(types, dns.TypeSOA)
+}
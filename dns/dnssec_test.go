@@ -0,0 +1,282 @@
+package dns
+
+import (
+	"context"
+	"crypto"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+type dnssecTestZone struct {
+	name       string
+	key        *dns.DNSKEY
+	privateKey crypto.Signer
+}
+
+func newDNSSECTestZone(t *testing.T, name string) *dnssecTestZone {
+	key := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: name, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 3600},
+		Flags:     dns.ZONE | dns.SEP,
+		Protocol:  3,
+		Algorithm: dns.ECDSAP256SHA256,
+	}
+	privateKey, err := key.Generate(256)
+	require.NoError(t, err)
+	return &dnssecTestZone{name, key, privateKey.(crypto.Signer)}
+}
+
+// sign appends signatures valid from an hour ago to an hour later to all RRsets of the records.
+func (z *dnssecTestZone) sign(t *testing.T, records ...dns.RR) []dns.RR {
+	return z.signWithValidity(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), records...)
+}
+
+func (z *dnssecTestZone) signWithValidity(t *testing.T, inception time.Time, expiration time.Time, records ...dns.RR) []dns.RR {
+	keys, rrSets, _ := dnssecRRSets(records)
+	signed := records
+	for _, key := range keys {
+		signature := &dns.RRSIG{
+			Hdr:        dns.RR_Header{Name: key.name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: 3600},
+			Algorithm:  z.key.Algorithm,
+			Inception:  uint32(inception.Unix()),
+			Expiration: uint32(expiration.Unix()),
+			KeyTag:     z.key.KeyTag(),
+			SignerName: z.name,
+		}
+		require.NoError(t, signature.Sign(z.privateKey, rrSets[key]))
+		signed = append(signed, signature)
+	}
+	return signed
+}
+
+// nsec3Chain returns the NSEC3 chain of the existing names of the zone.
+func (z *dnssecTestZone) nsec3Chain(optOut bool, names map[string][]uint16) []dns.RR {
+	hashes := make(map[string]string)
+	var sorted []string
+	for name := range names {
+		hash := dns.HashName(name, dns.SHA1, 0, "")
+		hashes[hash] = name
+		sorted = append(sorted, hash)
+	}
+	sort.Strings(sorted)
+	var flags uint8
+	if optOut {
+		flags = 1
+	}
+	var records []dns.RR
+	for i, hash := range sorted {
+		records = append(records, &dns.NSEC3{
+			Hdr:        dns.RR_Header{Name: hash + "." + z.name, Rrtype: dns.TypeNSEC3, Class: dns.ClassINET, Ttl: 3600},
+			Hash:       dns.SHA1,
+			Flags:      flags,
+			HashLength: 20,
+			NextDomain: sorted[(i+1)%len(sorted)],
+			TypeBitMap: names[hashes[hash]],
+		})
+	}
+	return records
+}
+
+type dnssecTestTransport struct {
+	TransportAdapter
+	responses map[dns.Question]*dns.Msg
+}
+
+func (t *dnssecTestTransport) Start(stage adapter.StartStage) error {
+	return nil
+}
+
+func (t *dnssecTestTransport) Close() error {
+	return nil
+}
+
+func (t *dnssecTestTransport) Reset() {
+}
+
+func (t *dnssecTestTransport) Exchange(ctx context.Context, message *dns.Msg) (*dns.Msg, error) {
+	question := message.Question[0]
+	question.Name = dns.CanonicalName(question.Name)
+	response, loaded := t.responses[question]
+	if !loaded {
+		return nil, E.New("unexpected query: ", question.String())
+	}
+	response = response.Copy()
+	response.Id = message.Id
+	return response, nil
+}
+
+func (t *dnssecTestTransport) add(name string, rrType uint16, rcode int, answer []dns.RR, ns []dns.RR) {
+	question := dns.Question{Name: name, Qtype: rrType, Qclass: dns.ClassINET}
+	response := &dns.Msg{
+		MsgHdr: dns.MsgHdr{
+			Response:         true,
+			RecursionDesired: true,
+			Rcode:            rcode,
+		},
+		Question: []dns.Question{question},
+		Answer:   answer,
+		Ns:       ns,
+	}
+	t.responses[question] = response
+}
+
+func (t *dnssecTestTransport) validate(ctx context.Context, validator *dnssecValidator, name string, rrType uint16) (bool, error) {
+	question := dns.Question{Name: name, Qtype: rrType, Qclass: dns.ClassINET}
+	return validator.Validate(ctx, t, question, t.responses[question].Copy())
+}
+
+func dnssecTestRR(t *testing.T, content string) dns.RR {
+	record, err := dns.NewRR(content)
+	require.NoError(t, err)
+	return record
+}
+
+// newDNSSECTestTransport serves a signed root and test. zone, with the nsec3.test. and optout.test. zones
+// denying existence by NSEC3 and the unsigned insecure.test. zone.
+func newDNSSECTestTransport(t *testing.T) (*dnssecTestTransport, []dns.RR) {
+	transport := &dnssecTestTransport{
+		TransportAdapter: NewTransportAdapter(C.DNSTypeUDP, "test", nil),
+		responses:        make(map[dns.Question]*dns.Msg),
+	}
+	root := newDNSSECTestZone(t, ".")
+	zone := newDNSSECTestZone(t, "test.")
+	transport.add(".", dns.TypeDNSKEY, dns.RcodeSuccess, root.sign(t, root.key), nil)
+	transport.add("test.", dns.TypeDS, dns.RcodeSuccess, root.sign(t, zone.key.ToDS(dns.SHA256)), nil)
+	transport.add("test.", dns.TypeDNSKEY, dns.RcodeSuccess, zone.sign(t, zone.key), nil)
+	soa := dnssecTestRR(t, "test. 3600 IN SOA ns.test. admin.test. 1 3600 600 86400 300")
+
+	transport.add("www.test.", dns.TypeA, dns.RcodeSuccess, zone.sign(t, dnssecTestRR(t, "www.test. 300 IN A 1.2.3.4")), nil)
+
+	bogus := zone.sign(t, dnssecTestRR(t, "bad.test. 300 IN A 1.2.3.4"))
+	bogus[0].(*dns.A).A = []byte{5, 6, 7, 8}
+	transport.add("bad.test.", dns.TypeA, dns.RcodeSuccess, bogus, nil)
+
+	transport.add("old.test.", dns.TypeA, dns.RcodeSuccess, zone.signWithValidity(t, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour), dnssecTestRR(t, "old.test. 300 IN A 1.2.3.4")), nil)
+
+	delegationNSEC := dnssecTestRR(t, "insecure.test. 300 IN NSEC old.test. NS RRSIG NSEC")
+	transport.add("insecure.test.", dns.TypeDS, dns.RcodeSuccess, nil, zone.sign(t, soa, delegationNSEC))
+	transport.add("www.insecure.test.", dns.TypeSOA, dns.RcodeSuccess, nil, []dns.RR{dnssecTestRR(t, "insecure.test. 3600 IN SOA ns.insecure.test. admin.insecure.test. 1 3600 600 86400 300")})
+	transport.add("www.insecure.test.", dns.TypeA, dns.RcodeSuccess, []dns.RR{dnssecTestRR(t, "www.insecure.test. 300 IN A 1.2.3.4")}, nil)
+
+	transport.add("missing.test.", dns.TypeA, dns.RcodeNameError, nil, zone.sign(t,
+		soa,
+		dnssecTestRR(t, "test. 300 IN NSEC bad.test. NS SOA RRSIG NSEC DNSKEY"),
+		delegationNSEC,
+	))
+
+	wildcard := zone.sign(t, dnssecTestRR(t, "*.wild.test. 300 IN A 1.2.3.4"))
+	wildcard[0].Header().Name = "a.wild.test."
+	wildcard[1].Header().Name = "a.wild.test."
+	transport.add("a.wild.test.", dns.TypeA, dns.RcodeSuccess, wildcard, zone.sign(t, dnssecTestRR(t, "*.wild.test. 300 IN NSEC www.test. A RRSIG NSEC")))
+	transport.add("b.wild.test.", dns.TypeA, dns.RcodeSuccess, wildcard, nil)
+
+	dname := zone.sign(t, dnssecTestRR(t, "dname.test. 300 IN DNAME test."))
+	www := zone.sign(t, dnssecTestRR(t, "www.test. 300 IN A 1.2.3.4"))
+	transport.add("www.dname.test.", dns.TypeA, dns.RcodeSuccess, []dns.RR{dname[0], dname[1], dnssecTestRR(t, "www.dname.test. 300 IN CNAME www.test."), www[0], www[1]}, nil)
+	transport.add("evil.dname.test.", dns.TypeA, dns.RcodeSuccess, []dns.RR{dname[0], dname[1], dnssecTestRR(t, "evil.dname.test. 300 IN CNAME www.test."), www[0], www[1]}, nil)
+	transport.add("evil.dname.test.", dns.TypeSOA, dns.RcodeSuccess, nil, zone.sign(t, soa))
+
+	for _, optOut := range []bool{false, true} {
+		name := "nsec3.test."
+		if optOut {
+			name = "optout.test."
+		}
+		childZone := newDNSSECTestZone(t, name)
+		transport.add(name, dns.TypeDS, dns.RcodeSuccess, zone.sign(t, childZone.key.ToDS(dns.SHA256)), nil)
+		transport.add(name, dns.TypeDNSKEY, dns.RcodeSuccess, childZone.sign(t, childZone.key), nil)
+		transport.add("missing."+name, dns.TypeA, dns.RcodeNameError, nil, childZone.sign(t, append(
+			[]dns.RR{dnssecTestRR(t, name+" 3600 IN SOA ns.test. admin.test. 1 3600 600 86400 300")},
+			childZone.nsec3Chain(optOut, map[string][]uint16{
+				name:          {dns.TypeNS, dns.TypeSOA, dns.TypeRRSIG, dns.TypeDNSKEY, dns.TypeNSEC3PARAM},
+				"www." + name: {dns.TypeA, dns.TypeRRSIG},
+			})...,
+		)...))
+	}
+	return transport, []dns.RR{root.key.ToDS(dns.SHA256)}
+}
+
+func TestDNSSECSecureAnswer(t *testing.T) {
+	t.Parallel()
+	transport, anchors := newDNSSECTestTransport(t)
+	secure, err := transport.validate(context.Background(), newDNSSECValidator(time.Second, anchors), "www.test.", dns.TypeA)
+	require.NoError(t, err)
+	require.True(t, secure)
+}
+
+func TestDNSSECBogusSignature(t *testing.T) {
+	t.Parallel()
+	transport, anchors := newDNSSECTestTransport(t)
+	client := NewClient(ClientOptions{
+		DisableCache: true,
+		DNSSEC:       true,
+		TrustAnchor:  anchors,
+	})
+	message := new(dns.Msg).SetQuestion("bad.test.", dns.TypeA)
+	message.SetEdns0(dnssecUDPSize, false)
+	response, err := client.Exchange(context.Background(), transport, message, adapter.DNSQueryOptions{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, dns.RcodeServerFailure, response.Rcode)
+	responseOpt := response.IsEdns0()
+	require.NotNil(t, responseOpt)
+	require.Len(t, responseOpt.Option, 1)
+	require.Equal(t, dns.ExtendedErrorCodeDNSBogus, responseOpt.Option[0].(*dns.EDNS0_EDE).InfoCode)
+}
+
+func TestDNSSECExpiredSignature(t *testing.T) {
+	t.Parallel()
+	transport, anchors := newDNSSECTestTransport(t)
+	_, err := transport.validate(context.Background(), newDNSSECValidator(time.Second, anchors), "old.test.", dns.TypeA)
+	require.ErrorContains(t, err, "expired")
+}
+
+func TestDNSSECInsecureDelegation(t *testing.T) {
+	t.Parallel()
+	transport, anchors := newDNSSECTestTransport(t)
+	secure, err := transport.validate(context.Background(), newDNSSECValidator(time.Second, anchors), "www.insecure.test.", dns.TypeA)
+	require.NoError(t, err)
+	require.False(t, secure)
+}
+
+func TestDNSSECNameError(t *testing.T) {
+	t.Parallel()
+	transport, anchors := newDNSSECTestTransport(t)
+	validator := newDNSSECValidator(time.Second, anchors)
+	secure, err := transport.validate(context.Background(), validator, "missing.test.", dns.TypeA)
+	require.NoError(t, err)
+	require.True(t, secure)
+	secure, err = transport.validate(context.Background(), validator, "missing.nsec3.test.", dns.TypeA)
+	require.NoError(t, err)
+	require.True(t, secure)
+	secure, err = transport.validate(context.Background(), validator, "missing.optout.test.", dns.TypeA)
+	require.NoError(t, err)
+	require.False(t, secure)
+}
+
+func TestDNSSECWildcard(t *testing.T) {
+	t.Parallel()
+	transport, anchors := newDNSSECTestTransport(t)
+	validator := newDNSSECValidator(time.Second, anchors)
+	secure, err := transport.validate(context.Background(), validator, "a.wild.test.", dns.TypeA)
+	require.NoError(t, err)
+	require.True(t, secure)
+	_, err = transport.validate(context.Background(), validator, "b.wild.test.", dns.TypeA)
+	require.ErrorContains(t, err, "missing proof of wildcard expansion")
+}
+
+func TestDNSSECDNAME(t *testing.T) {
+	t.Parallel()
+	transport, anchors := newDNSSECTestTransport(t)
+	validator := newDNSSECValidator(time.Second, anchors)
+	secure, err := transport.validate(context.Background(), validator, "www.dname.test.", dns.TypeA)
+	require.NoError(t, err)
+	require.True(t, secure)
+	_, err = transport.validate(context.Background(), validator, "evil.dname.test.", dns.TypeA)
+	require.ErrorContains(t, err, "missing signature for evil.dname.test. CNAME")
+}
@@ -0,0 +1,375 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	R "github.com/sagernet/sing-box/route/rule"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badoption"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/common/observable"
+	"github.com/sagernet/sing/common/x/list"
+	"github.com/sagernet/sing/service/filemanager"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const (
+	defaultQueryLogMaxEntries = 1000
+	defaultQueryLogMaxSize    = 10 * 1024 * 1024
+
+	// queryLogWriteQueueSize is the number of entries waiting to be written, new entries are dropped from the file when full.
+	queryLogWriteQueueSize = 1024
+)
+
+var (
+	_ adapter.DNSQueryLog      = (*QueryLog)(nil)
+	_ adapter.LifecycleService = (*QueryLog)(nil)
+)
+
+type QueryLog struct {
+	ctx        context.Context
+	logger     logger.ContextLogger
+	maxEntries int
+	path       string
+	maxSize    int64
+	access     sync.Mutex
+	entries    list.List[*adapter.DNSQueryLogEntry]
+	nextID     uint64
+	file       *os.File
+	fileSize   int64
+	writing    bool
+	writeQueue chan *adapter.DNSQueryLogEntry
+	dropped    atomic.Uint64
+	closed     chan struct{}
+	done       chan struct{}
+	subscriber *observable.Subscriber[*adapter.DNSQueryLogEntry]
+	observer   *observable.Observer[*adapter.DNSQueryLogEntry]
+}
+
+func NewQueryLog(ctx context.Context, logger logger.ContextLogger, options option.DNSQueryLogOptions) *QueryLog {
+	maxEntries := int(options.MaxEntries)
+	if maxEntries == 0 {
+		maxEntries = defaultQueryLogMaxEntries
+	}
+	maxSize := int64(options.MaxSize.Value())
+	if maxSize == 0 {
+		maxSize = defaultQueryLogMaxSize
+	}
+	queryLog := &QueryLog{
+		ctx:        ctx,
+		logger:     logger,
+		maxEntries: maxEntries,
+		path:       options.Path,
+		maxSize:    maxSize,
+		writeQueue: make(chan *adapter.DNSQueryLogEntry, queryLogWriteQueueSize),
+		closed:     make(chan struct{}),
+		done:       make(chan struct{}),
+		subscriber: observable.NewSubscriber[*adapter.DNSQueryLogEntry](128),
+	}
+	queryLog.observer = observable.NewObserver(queryLog.subscriber, 64)
+	return queryLog
+}
+
+func (l *QueryLog) Name() string {
+	return "dns query log"
+}
+
+func (l *QueryLog) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateInitialize || l.path == "" {
+		return nil
+	}
+	err := l.openFile()
+	if err != nil {
+		return err
+	}
+	l.writing = true
+	go l.loopWrite()
+	return nil
+}
+
+func (l *QueryLog) Close() error {
+	l.observer.Close()
+	if !l.writing {
+		return nil
+	}
+	close(l.closed)
+	<-l.done
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *QueryLog) openFile() error {
+	file, err := filemanager.OpenFile(l.ctx, l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return E.Cause(err, "open DNS query log file")
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return E.Cause(err, "open DNS query log file")
+	}
+	l.file = file
+	l.fileSize = fileInfo.Size()
+	return nil
+}
+
+// loopWrite appends queued entries to the file, so that queries are not blocked by disk writes.
+func (l *QueryLog) loopWrite() {
+	defer close(l.done)
+	for {
+		select {
+		case entry := <-l.writeQueue:
+			l.write(entry)
+		case <-l.closed:
+			for {
+				select {
+				case entry := <-l.writeQueue:
+					l.write(entry)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *QueryLog) write(entry *adapter.DNSQueryLogEntry) {
+	if l.file == nil {
+		return
+	}
+	if dropped := l.dropped.Swap(0); dropped > 0 {
+		l.logger.Warn("DNS query log write queue full, ", dropped, " entries not written")
+	}
+	content, err := json.Marshal(entry)
+	if err != nil {
+		l.logger.Warn(E.Cause(err, "write DNS query log"))
+		return
+	}
+	content = append(content, '\n')
+	if l.fileSize > 0 && l.fileSize+int64(len(content)) > l.maxSize {
+		err = l.rotate()
+		if err != nil {
+			l.logger.Warn(E.Cause(err, "rotate DNS query log"))
+			if l.file == nil {
+				return
+			}
+		}
+	}
+	n, err := l.file.Write(content)
+	l.fileSize += int64(n)
+	if err != nil {
+		l.logger.Warn(E.Cause(err, "write DNS query log"))
+	}
+}
+
+// rotate moves the full file to the path with the .1 suffix, replacing the previous one.
+func (l *QueryLog) rotate() error {
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return err
+	}
+	err = os.Rename(filemanager.BasePath(l.ctx, l.path), filemanager.BasePath(l.ctx, l.path)+".1")
+	if err != nil {
+		return err
+	}
+	return l.openFile()
+}
+
+func (l *QueryLog) Record(entry *adapter.DNSQueryLogEntry) {
+	l.access.Lock()
+	l.nextID++
+	entry.ID = l.nextID
+	l.entries.PushBack(entry)
+	if l.entries.Len() > l.maxEntries {
+		l.entries.Remove(l.entries.Front())
+	}
+	l.access.Unlock()
+	if l.path != "" {
+		select {
+		case l.writeQueue <- entry:
+		default:
+			l.dropped.Add(1)
+		}
+	}
+	l.subscriber.Emit(entry)
+}
+
+func (l *QueryLog) Entries() []*adapter.DNSQueryLogEntry {
+	l.access.Lock()
+	defer l.access.Unlock()
+	entries := make([]*adapter.DNSQueryLogEntry, 0, l.entries.Len())
+	for element := l.entries.Front(); element != nil; element = element.Next() {
+		entries = append(entries, element.Value)
+	}
+	return entries
+}
+
+func (l *QueryLog) Clear() {
+	l.access.Lock()
+	l.entries = list.List[*adapter.DNSQueryLogEntry]{}
+	l.access.Unlock()
+	l.subscriber.Emit(nil)
+}
+
+func (l *QueryLog) Subscribe() (subscription observable.Subscription[*adapter.DNSQueryLogEntry], done <-chan struct{}, err error) {
+	return l.observer.Subscribe()
+}
+
+func (l *QueryLog) UnSubscribe(subscription observable.Subscription[*adapter.DNSQueryLogEntry]) {
+	l.observer.UnSubscribe(subscription)
+}
+
+type queryLogContextKey struct{}
+
+// queryLogRecord collects the routing result of a single query,
+// cache hits are reported by the DNS client through queryLogTracker.
+type queryLogRecord struct {
+	entry     adapter.DNSQueryLogEntry
+	startedAt time.Time
+	cached    atomic.Bool
+	exchanged atomic.Bool
+}
+
+func newQueryLogRecord(ctx context.Context, domain string, queryType string) (context.Context, *queryLogRecord) {
+	startedAt := time.Now()
+	record := &queryLogRecord{
+		entry: adapter.DNSQueryLogEntry{
+			Time:      startedAt,
+			Domain:    FqdnToDomain(domain),
+			QueryType: queryType,
+		},
+		startedAt: startedAt,
+	}
+	if metadata := adapter.ContextFrom(ctx); metadata != nil {
+		record.entry.Inbound = metadata.Inbound
+		if metadata.Source.IsValid() {
+			record.entry.Source = metadata.Source.String()
+		}
+	}
+	return context.WithValue(ctx, (*queryLogContextKey)(nil), record), record
+}
+
+func queryLogRecordFromContext(ctx context.Context) *queryLogRecord {
+	record, _ := ctx.Value((*queryLogContextKey)(nil)).(*queryLogRecord)
+	return record
+}
+
+func (r *queryLogRecord) setTransport(transport adapter.DNSTransport) {
+	if r == nil || transport == nil {
+		return
+	}
+	r.entry.Transport = transport.Tag()
+}
+
+func (r *queryLogRecord) setRoute(rule adapter.DNSRule, transport adapter.DNSTransport) {
+	if r == nil {
+		return
+	}
+	if rule != nil {
+		r.entry.Rule = F.ToString(rule, " => ", rule.Action())
+	} else {
+		r.entry.Rule = "final"
+	}
+	r.setTransport(transport)
+}
+
+func (r *queryLogRecord) setStrategy(strategy C.DomainStrategy) {
+	if r == nil {
+		return
+	}
+	r.entry.QueryType = lookupQueryType(strategy)
+}
+
+func lookupQueryType(strategy C.DomainStrategy) string {
+	switch strategy {
+	case C.DomainStrategyIPv4Only:
+		return "A"
+	case C.DomainStrategyIPv6Only:
+		return "AAAA"
+	default:
+		return "A/AAAA"
+	}
+}
+
+func (r *queryLogRecord) finish(err error) *adapter.DNSQueryLogEntry {
+	entry := r.entry
+	entry.Latency = badoption.Duration(time.Since(r.startedAt).Round(time.Microsecond))
+	entry.Cached = r.cached.Load() && !r.exchanged.Load()
+	if err != nil {
+		var rcodeError RcodeError
+		var rejectedError *R.RejectedError
+		if errors.As(err, &rcodeError) {
+			entry.Rcode = mDNS.RcodeToString[int(rcodeError)]
+		} else if errors.As(err, &rejectedError) {
+			entry.Rcode = mDNS.RcodeToString[mDNS.RcodeRefused]
+			entry.Error = err.Error()
+		} else {
+			entry.Error = err.Error()
+		}
+	}
+	return &entry
+}
+
+func (r *queryLogRecord) finishExchange(response *mDNS.Msg, err error) *adapter.DNSQueryLogEntry {
+	entry := r.finish(err)
+	if response != nil {
+		entry.Rcode = mDNS.RcodeToString[response.Rcode]
+		for _, answer := range response.Answer {
+			entry.Answers = append(entry.Answers, formatQueryLogAnswer(answer))
+		}
+	}
+	return entry
+}
+
+func (r *queryLogRecord) finishLookup(responseAddrs []netip.Addr, err error) *adapter.DNSQueryLogEntry {
+	entry := r.finish(err)
+	if err == nil {
+		entry.Rcode = mDNS.RcodeToString[mDNS.RcodeSuccess]
+	}
+	for _, address := range responseAddrs {
+		if address.Is4() {
+			entry.Answers = append(entry.Answers, "A "+address.String())
+		} else {
+			entry.Answers = append(entry.Answers, "AAAA "+address.String())
+		}
+	}
+	return entry
+}
+
+func formatQueryLogAnswer(answer mDNS.RR) string {
+	header := answer.Header()
+	return mDNS.TypeToString[header.Rrtype] + " " + answer.String()[len(header.String()):]
+}
+
+var _ adapter.DNSQueryTracker = (*queryLogTracker)(nil)
+
+type queryLogTracker struct{}
+
+func (t *queryLogTracker) DNSQueryCached(ctx context.Context, transport adapter.DNSTransport, question mDNS.Question) {
+	if record := queryLogRecordFromContext(ctx); record != nil {
+		record.cached.Store(true)
+	}
+}
+
+func (t *queryLogTracker) DNSQueryExchanged(ctx context.Context, transport adapter.DNSTransport, question mDNS.Question, latency time.Duration, err error) {
+	if record := queryLogRecordFromContext(ctx); record != nil {
+		record.exchanged.Store(true)
+	}
+}
@@ -5,10 +5,11 @@ import (
 )
 
 const (
-	RcodeSuccess     RcodeError = mDNS.RcodeSuccess
-	RcodeFormatError RcodeError = mDNS.RcodeFormatError
-	RcodeNameError   RcodeError = mDNS.RcodeNameError
-	RcodeRefused     RcodeError = mDNS.RcodeRefused
+	RcodeSuccess       RcodeError = mDNS.RcodeSuccess
+	RcodeFormatError   RcodeError = mDNS.RcodeFormatError
+	RcodeServerFailure RcodeError = mDNS.RcodeServerFailure
+	RcodeNameError     RcodeError = mDNS.RcodeNameError
+	RcodeRefused       RcodeError = mDNS.RcodeRefused
 )
 
 type RcodeError int
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"net/netip"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/fastestip"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -31,12 +33,18 @@ var _ adapter.DNSRouter = (*Router)(nil)
 type Router struct {
 	ctx                   context.Context
 	logger                logger.ContextLogger
+	queryLog              adapter.DNSQueryLog
 	transport             adapter.DNSTransportManager
 	outbound              adapter.OutboundManager
 	client                adapter.DNSClient
+	access                sync.RWMutex
 	rules                 []adapter.DNSRule
 	defaultDomainStrategy C.DomainStrategy
 	dnsReverseMapping     freelru.Cache[netip.Addr, string]
+	dns64Access           sync.RWMutex
+	dns64Prefixes         map[string][]netip.Prefix
+	dns64DiscoveryAccess  sync.Mutex
+	dns64Discovered       map[string]dns64Discovery
 	platformInterface     adapter.PlatformInterface
 }
 
@@ -44,17 +52,28 @@ func NewRouter(ctx context.Context, logFactory log.Factory, options option.DNSOp
 	router := &Router{
 		ctx:                   ctx,
 		logger:                logFactory.NewLogger("dns"),
+		queryLog:              service.FromContext[adapter.DNSQueryLog](ctx),
 		transport:             service.FromContext[adapter.DNSTransportManager](ctx),
 		outbound:              service.FromContext[adapter.OutboundManager](ctx),
 		rules:                 make([]adapter.DNSRule, 0, len(options.Rules)),
 		defaultDomainStrategy: C.DomainStrategy(options.Strategy),
+		dns64Prefixes:         make(map[string][]netip.Prefix),
+		dns64Discovered:       make(map[string]dns64Discovery),
 	}
+	dnssecOptions := common.PtrValueOrDefault(options.DNSClientOptions.DNSSEC)
 	router.client = NewClient(ClientOptions{
 		DisableCache:     options.DNSClientOptions.DisableCache,
 		DisableExpire:    options.DNSClientOptions.DisableExpire,
 		IndependentCache: options.DNSClientOptions.IndependentCache,
+		ServeStale:       options.DNSClientOptions.ServeStale,
+		StaleWindow:      time.Duration(options.DNSClientOptions.ServeStaleWindow),
+		Prefetch:         options.DNSClientOptions.Prefetch,
 		CacheCapacity:    options.DNSClientOptions.CacheCapacity,
 		ClientSubnet:     options.DNSClientOptions.ClientSubnet.Build(netip.Prefix{}),
+		DNSSEC:           dnssecOptions.Enabled,
+		TrustAnchor: common.Map(dnssecOptions.TrustAnchor, func(it option.DNSRecordOptions) mDNS.RR {
+			return it.RR
+		}),
 		RDRC: func() adapter.RDRCStore {
 			cacheFile := service.FromContext[adapter.CacheFile](ctx)
 			if cacheFile == nil {
@@ -65,8 +84,21 @@ func NewRouter(ctx context.Context, logFactory log.Factory, options option.DNSOp
 			}
 			return cacheFile
 		},
+		CacheStore: func() adapter.DNSCacheStore {
+			cacheFile := service.FromContext[adapter.CacheFile](ctx)
+			if cacheFile == nil {
+				return nil
+			}
+			if !cacheFile.StoreDNS() {
+				return nil
+			}
+			return cacheFile
+		},
 		Logger: router.logger,
 	})
+	if router.queryLog != nil {
+		router.client.AppendTracker(new(queryLogTracker))
+	}
 	if options.ReverseMapping {
 		router.dnsReverseMapping = common.Must1(freelru.NewSharded[netip.Addr, string](1024, maphash.NewHasher[netip.Addr]().Hash32))
 	}
@@ -80,6 +112,9 @@ func (r *Router) Initialize(rules []option.DNSRule) error {
 			return E.Cause(err, "parse dns rule[", i, "]")
 		}
 		r.rules = append(r.rules, dnsRule)
+		if action, isRoute := dnsRule.Action().(*R.RuleActionDNSRoute); isRoute && action.DNS64 && action.DNS64Prefix.IsValid() {
+			r.addDNS64Prefix("", action.DNS64Prefix)
+		}
 	}
 	return nil
 }
@@ -117,7 +152,41 @@ func (r *Router) Close() error {
 	return err
 }
 
-func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int, isAddressQuery bool, options *adapter.DNSQueryOptions) (adapter.DNSTransport, adapter.DNSRule, int) {
+// Reload replaces DNS rules atomically.
+func (r *Router) Reload(rules []option.DNSRule) error {
+	newRules := make([]adapter.DNSRule, 0, len(rules))
+	closeRules := func(rules []adapter.DNSRule) {
+		for i, rule := range rules {
+			err := rule.Close()
+			if err != nil {
+				r.logger.Error(E.Cause(err, "close dns rule[", i, "]"))
+			}
+		}
+	}
+	for i, ruleOptions := range rules {
+		dnsRule, err := R.NewDNSRule(r.ctx, r.logger, ruleOptions, true)
+		if err != nil {
+			closeRules(newRules)
+			return E.Cause(err, "parse dns rule[", i, "]")
+		}
+		newRules = append(newRules, dnsRule)
+	}
+	for i, rule := range newRules {
+		err := rule.Start()
+		if err != nil {
+			closeRules(newRules)
+			return E.Cause(err, "initialize DNS rule[", i, "]")
+		}
+	}
+	r.access.Lock()
+	oldRules := r.rules
+	r.rules = newRules
+	r.access.Unlock()
+	closeRules(oldRules)
+	return nil
+}
+
+func (r *Router) matchDNS(ctx context.Context, rules []adapter.DNSRule, allowFakeIP bool, ruleIndex int, isAddressQuery bool, options *adapter.DNSQueryOptions) (adapter.DNSTransport, adapter.DNSRule, int) {
 	metadata := adapter.ContextFrom(ctx)
 	if metadata == nil {
 		panic("no context")
@@ -126,8 +195,8 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 	if ruleIndex != -1 {
 		currentRuleIndex = ruleIndex + 1
 	}
-	for ; currentRuleIndex < len(r.rules); currentRuleIndex++ {
-		currentRule := r.rules[currentRuleIndex]
+	for ; currentRuleIndex < len(rules); currentRuleIndex++ {
+		currentRule := rules[currentRuleIndex]
 		if currentRule.WithAddressLimit() && !isAddressQuery {
 			continue
 		}
@@ -166,6 +235,16 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 				if action.ClientSubnet.IsValid() {
 					options.ClientSubnet = action.ClientSubnet
 				}
+				if action.ValidateDNSSEC {
+					options.ValidateDNSSEC = true
+				}
+				if action.FastestIP != nil {
+					options.FastestIP = action.FastestIP
+				}
+				if action.DNS64 {
+					options.DNS64 = true
+					options.DNS64Prefix = action.DNS64Prefix
+				}
 				if legacyTransport, isLegacy := transport.(adapter.LegacyDNSTransport); isLegacy {
 					if options.Strategy == C.DomainStrategyAsIS {
 						options.Strategy = legacyTransport.LegacyStrategy()
@@ -188,6 +267,14 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 				if action.ClientSubnet.IsValid() {
 					options.ClientSubnet = action.ClientSubnet
 				}
+				if action.ValidateDNSSEC {
+					options.ValidateDNSSEC = true
+				}
+				if action.FastestIP != nil {
+					options.FastestIP = action.FastestIP
+				}
+			case *R.RuleActionDNSRewrite:
+				options.Rewrite = append(options.Rewrite, action)
 			case *R.RuleActionReject:
 				return nil, currentRule, currentRuleIndex
 			case *R.RuleActionPredefined:
@@ -199,6 +286,17 @@ func (r *Router) matchDNS(ctx context.Context, allowFakeIP bool, ruleIndex int,
 }
 
 func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapter.DNSQueryOptions) (*mDNS.Msg, error) {
+	if r.queryLog == nil || len(message.Question) != 1 {
+		return r.exchange(ctx, message, options, nil)
+	}
+	question := message.Question[0]
+	ctx, record := newQueryLogRecord(ctx, question.Name, mDNS.Type(question.Qtype).String())
+	response, err := r.exchange(ctx, message, options, record)
+	r.queryLog.Record(record.finishExchange(response, err))
+	return response, err
+}
+
+func (r *Router) exchange(ctx context.Context, message *mDNS.Msg, options adapter.DNSQueryOptions, record *queryLogRecord) (*mDNS.Msg, error) {
 	if len(message.Question) != 1 {
 		r.logger.WarnContext(ctx, "bad question size: ", len(message.Question))
 		responseMessage := mDNS.Msg{
@@ -241,6 +339,7 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 		if options.Strategy == C.DomainStrategyAsIS {
 			options.Strategy = r.defaultDomainStrategy
 		}
+		record.setTransport(transport)
 		response, err = r.client.Exchange(ctx, transport, message, options, nil)
 	} else {
 		var (
@@ -248,10 +347,14 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 			ruleIndex int
 		)
 		ruleIndex = -1
+		r.access.RLock()
+		rules := r.rules
+		r.access.RUnlock()
 		for {
 			dnsCtx := adapter.OverrideContext(ctx)
 			dnsOptions := options
-			transport, rule, ruleIndex = r.matchDNS(ctx, true, ruleIndex, isAddressQuery(message), &dnsOptions)
+			transport, rule, ruleIndex = r.matchDNS(ctx, rules, true, ruleIndex, isAddressQuery(message), &dnsOptions)
+			record.setRoute(rule, transport)
 			if rule != nil {
 				switch action := rule.Action().(type) {
 				case *R.RuleActionReject:
@@ -283,6 +386,9 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 				dnsOptions.Strategy = r.defaultDomainStrategy
 			}
 			response, err = r.client.Exchange(dnsCtx, transport, message, dnsOptions, responseCheck)
+			if err == nil && dnsOptions.DNS64 {
+				response = r.synthesizeDNS64(dnsCtx, transport, message, response, dnsOptions)
+			}
 			var rejected bool
 			if err != nil {
 				if errors.Is(err, ErrResponseRejectedCached) {
@@ -322,6 +428,16 @@ func (r *Router) Exchange(ctx context.Context, message *mDNS.Msg, options adapte
 }
 
 func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQueryOptions) ([]netip.Addr, error) {
+	if r.queryLog == nil {
+		return r.lookup(ctx, domain, options, nil)
+	}
+	ctx, record := newQueryLogRecord(ctx, domain, lookupQueryType(options.Strategy))
+	responseAddrs, err := r.lookup(ctx, domain, options, record)
+	r.queryLog.Record(record.finishLookup(responseAddrs, err))
+	return responseAddrs, err
+}
+
+func (r *Router) lookup(ctx context.Context, domain string, options adapter.DNSQueryOptions, record *queryLogRecord) ([]netip.Addr, error) {
 	var (
 		responseAddrs []netip.Addr
 		err           error
@@ -360,6 +476,11 @@ func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQ
 		if options.Strategy == C.DomainStrategyAsIS {
 			options.Strategy = r.defaultDomainStrategy
 		}
+		record.setTransport(transport)
+		record.setStrategy(options.Strategy)
+		if options.FastestIP != nil {
+			ctx = r.contextWithProbeDialer(ctx)
+		}
 		responseAddrs, err = r.client.Lookup(ctx, transport, domain, options, nil)
 	} else {
 		var (
@@ -368,10 +489,14 @@ func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQ
 			ruleIndex int
 		)
 		ruleIndex = -1
+		r.access.RLock()
+		rules := r.rules
+		r.access.RUnlock()
 		for {
 			dnsCtx := adapter.OverrideContext(ctx)
 			dnsOptions := options
-			transport, rule, ruleIndex = r.matchDNS(ctx, false, ruleIndex, true, &dnsOptions)
+			transport, rule, ruleIndex = r.matchDNS(ctx, rules, false, ruleIndex, true, &dnsOptions)
+			record.setRoute(rule, transport)
 			if rule != nil {
 				switch action := rule.Action().(type) {
 				case *R.RuleActionReject:
@@ -402,6 +527,10 @@ func (r *Router) Lookup(ctx context.Context, domain string, options adapter.DNSQ
 			if dnsOptions.Strategy == C.DomainStrategyAsIS {
 				dnsOptions.Strategy = r.defaultDomainStrategy
 			}
+			record.setStrategy(dnsOptions.Strategy)
+			if dnsOptions.FastestIP != nil {
+				dnsCtx = r.contextWithProbeDialer(dnsCtx)
+			}
 			responseAddrs, err = r.client.Lookup(dnsCtx, transport, domain, dnsOptions, responseCheck)
 			if responseCheck == nil || err == nil {
 				break
@@ -417,6 +546,16 @@ response:
 	return responseAddrs, err
 }
 
+// contextWithProbeDialer probes addresses through the default outbound
+// if the lookup is not made by a dialer that will connect to them.
+func (r *Router) contextWithProbeDialer(ctx context.Context) context.Context {
+	if _, _, loaded := fastestip.DialerFromContext(ctx); loaded {
+		return ctx
+	}
+	defaultOutbound := r.outbound.Default()
+	return fastestip.ContextWithDialer(ctx, defaultOutbound.Tag(), defaultOutbound)
+}
+
 func isAddressQuery(message *mDNS.Msg) bool {
 	for _, question := range message.Question {
 		if question.Qtype == mDNS.TypeA || question.Qtype == mDNS.TypeAAAA || question.Qtype == mDNS.TypeHTTPS {
@@ -433,6 +572,14 @@ func (r *Router) ClearCache() {
 	}
 }
 
+func (r *Router) CacheStatistics() map[string]adapter.DNSCacheStatistics {
+	return r.client.CacheStatistics()
+}
+
+func (r *Router) AppendTracker(tracker adapter.DNSQueryTracker) {
+	r.client.AppendTracker(tracker)
+}
+
 func (r *Router) LookupReverseMapping(ip netip.Addr) (string, bool) {
 	if r.dnsReverseMapping == nil {
 		return "", false
@@ -443,6 +590,7 @@ func (r *Router) LookupReverseMapping(ip netip.Addr) (string, bool) {
 
 func (r *Router) ResetNetwork() {
 	r.ClearCache()
+	r.resetDNS64()
 	for _, transport := range r.transport.Transports() {
 		transport.Reset()
 	}
@@ -0,0 +1,241 @@
+package dnscrypt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"strconv"
+	"time"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	esVersionXSalsa20Poly1305  = 1
+	esVersionXChaCha20Poly1305 = 2
+
+	certificateMagic = "DNSC"
+	certificateSize  = 124
+	clientMagicSize  = 8
+	nonceSize        = 24
+	halfNonceSize    = nonceSize / 2
+	tagSize          = poly1305.TagSize
+	publicKeySize    = 32
+
+	// queryOverhead is the size of the client magic, client public key, client nonce and tag of an encrypted query.
+	queryOverhead   = clientMagicSize + publicKeySize + halfNonceSize + tagSize
+	minQuerySize    = 256
+	paddingBlock    = 64
+	maxResponseSize = 65535
+)
+
+var resolverMagic = []byte{0x72, 0x36, 0x66, 0x6e, 0x76, 0x57, 0x6a, 0x38}
+
+type certificate struct {
+	esVersion   uint16
+	publicKey   [publicKeySize]byte
+	clientMagic [clientMagicSize]byte
+	serial      uint32
+	notBefore   time.Time
+	notAfter    time.Time
+}
+
+// parseCertificate parses and verifies a resolver certificate (https://dnscrypt.info/protocol).
+func parseCertificate(content []byte, providerKey ed25519.PublicKey) (*certificate, error) {
+	if len(content) < certificateSize {
+		return nil, E.New("short certificate")
+	}
+	if string(content[:4]) != certificateMagic {
+		return nil, E.New("invalid certificate magic")
+	}
+	esVersion := binary.BigEndian.Uint16(content[4:6])
+	switch esVersion {
+	case esVersionXSalsa20Poly1305, esVersionXChaCha20Poly1305:
+	default:
+		return nil, E.New("unsupported encryption system: ", esVersion)
+	}
+	if !ed25519.Verify(providerKey, content[72:], content[8:72]) {
+		return nil, E.New("invalid certificate signature")
+	}
+	cert := &certificate{
+		esVersion: esVersion,
+		serial:    binary.BigEndian.Uint32(content[112:116]),
+		notBefore: time.Unix(int64(binary.BigEndian.Uint32(content[116:120])), 0),
+		notAfter:  time.Unix(int64(binary.BigEndian.Uint32(content[120:124])), 0),
+	}
+	copy(cert.publicKey[:], content[72:104])
+	copy(cert.clientMagic[:], content[104:112])
+	return cert, nil
+}
+
+// unpackTXT reverses the presentation format escaping applied to TXT strings.
+func unpackTXT(content string) ([]byte, error) {
+	var result bytes.Buffer
+	for i := 0; i < len(content); i++ {
+		if content[i] != '\\' {
+			result.WriteByte(content[i])
+			continue
+		}
+		i++
+		if i == len(content) {
+			return nil, E.New("invalid escape")
+		}
+		if content[i] < '0' || content[i] > '9' {
+			result.WriteByte(content[i])
+			continue
+		}
+		if i+3 > len(content) {
+			return nil, E.New("invalid escape")
+		}
+		value, err := strconv.ParseUint(content[i:i+3], 10, 8)
+		if err != nil {
+			return nil, E.New("invalid escape")
+		}
+		result.WriteByte(byte(value))
+		i += 2
+	}
+	return result.Bytes(), nil
+}
+
+type cipher struct {
+	certificate *certificate
+	publicKey   [publicKeySize]byte
+	sharedKey   [32]byte
+}
+
+func newCipher(cert *certificate) (*cipher, error) {
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedKey, err := computeSharedKey(cert.esVersion, privateKey, &cert.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &cipher{
+		certificate: cert,
+		publicKey:   *publicKey,
+		sharedKey:   sharedKey,
+	}, nil
+}
+
+func computeSharedKey(esVersion uint16, privateKey *[32]byte, publicKey *[publicKeySize]byte) ([32]byte, error) {
+	var sharedKey [32]byte
+	if esVersion == esVersionXSalsa20Poly1305 {
+		box.Precompute(&sharedKey, publicKey, privateKey)
+		return sharedKey, nil
+	}
+	dhKey, err := curve25519.X25519(privateKey[:], publicKey[:])
+	if err != nil {
+		return sharedKey, err
+	}
+	subKey, err := chacha20.HChaCha20(dhKey, make([]byte, 16))
+	if err != nil {
+		return sharedKey, err
+	}
+	copy(sharedKey[:], subKey)
+	return sharedKey, nil
+}
+
+// encrypt builds an encrypted query, returning the client nonce to match against the response.
+func (c *cipher) encrypt(message []byte, minSize int) ([]byte, []byte, error) {
+	var nonce [nonceSize]byte
+	_, err := rand.Read(nonce[:halfNonceSize])
+	if err != nil {
+		return nil, nil, err
+	}
+	paddedSize := (len(message) + 1 + paddingBlock - 1) / paddingBlock * paddingBlock
+	paddedSize = max(paddedSize, minSize-queryOverhead)
+	padded := make([]byte, paddedSize)
+	copy(padded, message)
+	padded[len(message)] = 0x80
+	query := make([]byte, 0, queryOverhead+paddedSize)
+	query = append(query, c.certificate.clientMagic[:]...)
+	query = append(query, c.publicKey[:]...)
+	query = append(query, nonce[:halfNonceSize]...)
+	query = seal(c.certificate.esVersion, query, padded, &nonce, &c.sharedKey)
+	return query, nonce[:halfNonceSize], nil
+}
+
+func (c *cipher) decrypt(response []byte, clientNonce []byte) ([]byte, error) {
+	if len(response) < len(resolverMagic)+nonceSize+tagSize {
+		return nil, E.New("short response")
+	}
+	if !bytes.Equal(response[:len(resolverMagic)], resolverMagic) {
+		return nil, E.New("invalid response magic")
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], response[len(resolverMagic):])
+	if subtle.ConstantTimeCompare(nonce[:halfNonceSize], clientNonce) != 1 {
+		return nil, E.New("unexpected response nonce")
+	}
+	padded, err := open(c.certificate.esVersion, response[len(resolverMagic)+nonceSize:], &nonce, &c.sharedKey)
+	if err != nil {
+		return nil, err
+	}
+	return unpad(padded)
+}
+
+func unpad(content []byte) ([]byte, error) {
+	index := bytes.LastIndexFunc(content, func(r rune) bool {
+		return r != 0
+	})
+	if index < 0 || content[index] != 0x80 {
+		return nil, E.New("invalid padding")
+	}
+	return content[:index], nil
+}
+
+func seal(esVersion uint16, out []byte, message []byte, nonce *[nonceSize]byte, key *[32]byte) []byte {
+	if esVersion == esVersionXSalsa20Poly1305 {
+		return secretbox.Seal(out, message, nonce, key)
+	}
+	stream := xchachaStream(message, nonce, key)
+	var polyKey [32]byte
+	copy(polyKey[:], stream[:32])
+	var tag [tagSize]byte
+	poly1305.Sum(&tag, stream[32:], &polyKey)
+	out = append(out, tag[:]...)
+	return append(out, stream[32:]...)
+}
+
+func open(esVersion uint16, sealed []byte, nonce *[nonceSize]byte, key *[32]byte) ([]byte, error) {
+	if esVersion == esVersionXSalsa20Poly1305 {
+		message, ok := secretbox.Open(nil, sealed, nonce, key)
+		if !ok {
+			return nil, E.New("decrypt response: authentication failed")
+		}
+		return message, nil
+	}
+	if len(sealed) < tagSize {
+		return nil, E.New("short response")
+	}
+	var tag [tagSize]byte
+	copy(tag[:], sealed)
+	ciphertext := sealed[tagSize:]
+	stream := xchachaStream(ciphertext, nonce, key)
+	var polyKey [32]byte
+	copy(polyKey[:], stream[:32])
+	if !poly1305.Verify(&tag, ciphertext, &polyKey) {
+		return nil, E.New("decrypt response: authentication failed")
+	}
+	return stream[32:], nil
+}
+
+// xchachaStream applies the secretbox construction over XChaCha20: the first 32 bytes of the key stream are the poly1305 key,
+// the rest is XORed with the content.
+func xchachaStream(content []byte, nonce *[nonceSize]byte, key *[32]byte) []byte {
+	stream := make([]byte, 32+len(content))
+	copy(stream[32:], content)
+	streamCipher, _ := chacha20.NewUnauthenticatedCipher(key[:], nonce[:])
+	streamCipher.XORKeyStream(stream, stream)
+	return stream
+}
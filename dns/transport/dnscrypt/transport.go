@@ -0,0 +1,330 @@
+package dnscrypt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/dnsstamp"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	mDNS "github.com/miekg/dns"
+)
+
+func RegisterTransport(registry *dns.TransportRegistry) {
+	dns.RegisterTransport[option.DNSCryptDNSServerOptions](registry, C.DNSTypeDNSCrypt, NewTransport)
+}
+
+var _ adapter.DNSTransport = (*Transport)(nil)
+
+type Transport struct {
+	dns.TransportAdapter
+	logger       logger.ContextLogger
+	dialer       N.Dialer
+	serverAddr   M.Socksaddr
+	relayAddr    M.Socksaddr
+	providerName string
+	providerKey  ed25519.PublicKey
+	access       sync.Mutex
+	cipher       *cipher
+}
+
+func NewTransport(ctx context.Context, logger log.ContextLogger, tag string, options option.DNSCryptDNSServerOptions) (adapter.DNSTransport, error) {
+	if options.Stamp == "" {
+		return nil, E.New("missing stamp")
+	}
+	stamp, err := dnsstamp.Parse(options.Stamp)
+	if err != nil {
+		return nil, E.Cause(err, "parse stamp")
+	}
+	if stamp.Protocol != dnsstamp.ProtocolDNSCrypt {
+		return nil, E.New("unexpected stamp protocol: ", stamp.Protocol)
+	}
+	if len(stamp.PublicKey) != ed25519.PublicKeySize {
+		return nil, E.New("invalid provider public key")
+	}
+	if stamp.ProviderName == "" {
+		return nil, E.New("missing provider name")
+	}
+	serverAddr := parseAddress(stamp.Address)
+	if !serverAddr.IsValid() {
+		return nil, E.New("invalid server address: ", stamp.Address)
+	}
+	var relayAddr M.Socksaddr
+	if options.Relay != "" {
+		relayStamp, err := dnsstamp.Parse(options.Relay)
+		if err != nil {
+			return nil, E.Cause(err, "parse relay stamp")
+		}
+		if relayStamp.Protocol != dnsstamp.ProtocolDNSCryptRelay {
+			return nil, E.New("unexpected relay stamp protocol: ", relayStamp.Protocol)
+		}
+		relayAddr = parseAddress(relayStamp.Address)
+		if !relayAddr.IsValid() {
+			return nil, E.New("invalid relay address: ", relayStamp.Address)
+		}
+		if !serverAddr.IsIP() {
+			return nil, E.New("server address must be an IP address when using a relay")
+		}
+	}
+	destination := serverAddr
+	if relayAddr.IsValid() {
+		destination = relayAddr
+	}
+	transportDialer, err := dialer.NewWithOptions(dialer.Options{
+		Context:        ctx,
+		Options:        options.DialerOptions,
+		RemoteIsDomain: destination.IsFqdn(),
+		DirectResolver: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var dependencies []string
+	if options.DomainResolver != nil && options.DomainResolver.Server != "" {
+		dependencies = append(dependencies, options.DomainResolver.Server)
+	}
+	return &Transport{
+		TransportAdapter: dns.NewTransportAdapter(C.DNSTypeDNSCrypt, tag, dependencies),
+		logger:           logger,
+		dialer:           transportDialer,
+		serverAddr:       serverAddr,
+		relayAddr:        relayAddr,
+		providerName:     mDNS.Fqdn(stamp.ProviderName),
+		providerKey:      ed25519.PublicKey(stamp.PublicKey),
+	}, nil
+}
+
+func parseAddress(address string) M.Socksaddr {
+	serverAddr := M.ParseSocksaddr(address)
+	if serverAddr.Port == 0 {
+		serverAddr.Port = 443
+	}
+	return serverAddr
+}
+
+func (t *Transport) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	return dialer.InitializeDetour(t.dialer)
+}
+
+func (t *Transport) Close() error {
+	return nil
+}
+
+func (t *Transport) Reset() {
+	t.access.Lock()
+	defer t.access.Unlock()
+	t.cipher = nil
+}
+
+func (t *Transport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	queryCipher, err := t.loadCipher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response, err := t.exchange(ctx, queryCipher, N.NetworkUDP, message)
+	if err != nil {
+		return nil, err
+	}
+	if response.Truncated {
+		t.logger.InfoContext(ctx, "response truncated, retrying with TCP")
+		return t.exchange(ctx, queryCipher, N.NetworkTCP, message)
+	}
+	return response, nil
+}
+
+func (t *Transport) exchange(ctx context.Context, queryCipher *cipher, network string, message *mDNS.Msg) (*mDNS.Msg, error) {
+	rawMessage, err := message.Pack()
+	if err != nil {
+		return nil, err
+	}
+	var minSize int
+	if network == N.NetworkUDP {
+		minSize = minQuerySize
+	}
+	query, clientNonce, err := queryCipher.encrypt(rawMessage, minSize)
+	if err != nil {
+		return nil, err
+	}
+	rawResponse, err := t.roundTrip(ctx, network, query)
+	if err != nil {
+		return nil, err
+	}
+	rawResponse, err = queryCipher.decrypt(rawResponse, clientNonce)
+	if err != nil {
+		// the resolver may have rotated its key, fetch certificates again for the next query
+		t.access.Lock()
+		if t.cipher == queryCipher {
+			t.cipher = nil
+		}
+		t.access.Unlock()
+		return nil, err
+	}
+	var response mDNS.Msg
+	err = response.Unpack(rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (t *Transport) loadCipher(ctx context.Context) (*cipher, error) {
+	t.access.Lock()
+	queryCipher := t.cipher
+	t.access.Unlock()
+	if queryCipher != nil && time.Now().Before(queryCipher.certificate.notAfter) {
+		return queryCipher, nil
+	}
+	// the lock is not held while fetching, so that queries are not blocked by a slow or unreachable resolver
+	cert, err := t.fetchCertificate(ctx)
+	if err != nil {
+		return nil, E.Cause(err, "fetch certificate")
+	}
+	queryCipher, err = newCipher(cert)
+	if err != nil {
+		return nil, err
+	}
+	t.access.Lock()
+	t.cipher = queryCipher
+	t.access.Unlock()
+	return queryCipher, nil
+}
+
+// fetchCertificate queries the TXT records of the provider name and selects the newest valid certificate,
+// preferring XChaCha20-Poly1305.
+func (t *Transport) fetchCertificate(ctx context.Context) (*certificate, error) {
+	query := new(mDNS.Msg)
+	query.SetQuestion(t.providerName, mDNS.TypeTXT)
+	rawQuery, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	rawResponse, err := t.roundTrip(ctx, N.NetworkUDP, rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	var response mDNS.Msg
+	err = response.Unpack(rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	if response.Truncated {
+		rawResponse, err = t.roundTrip(ctx, N.NetworkTCP, rawQuery)
+		if err != nil {
+			return nil, err
+		}
+		err = response.Unpack(rawResponse)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if response.Id != query.Id {
+		return nil, E.New("unexpected response id")
+	}
+	var (
+		selected *certificate
+		now      = time.Now()
+	)
+	for _, record := range response.Answer {
+		txtRecord, isTXT := record.(*mDNS.TXT)
+		if !isTXT || !strings.EqualFold(txtRecord.Hdr.Name, t.providerName) {
+			continue
+		}
+		content, err := unpackTXT(strings.Join(txtRecord.Txt, ""))
+		if err != nil {
+			t.logger.DebugContext(ctx, E.Cause(err, "unpack certificate"))
+			continue
+		}
+		cert, err := parseCertificate(content, t.providerKey)
+		if err != nil {
+			t.logger.DebugContext(ctx, E.Cause(err, "parse certificate"))
+			continue
+		}
+		if now.Before(cert.notBefore) || !now.Before(cert.notAfter) {
+			t.logger.DebugContext(ctx, "ignore certificate ", cert.serial, ": not valid at this time")
+			continue
+		}
+		if selected == nil || cert.esVersion > selected.esVersion || cert.esVersion == selected.esVersion && cert.serial > selected.serial {
+			selected = cert
+		}
+	}
+	if selected == nil {
+		return nil, E.New("no valid certificate found for ", t.providerName)
+	}
+	t.logger.DebugContext(ctx, "loaded certificate ", selected.serial, " for ", t.providerName)
+	return selected, nil
+}
+
+// roundTrip sends the packet to the resolver or through the relay, and reads back the raw response.
+func (t *Transport) roundTrip(ctx context.Context, network string, packet []byte) ([]byte, error) {
+	destination := t.serverAddr
+	if t.relayAddr.IsValid() {
+		destination = t.relayAddr
+		packet = append(relayHeader(t.serverAddr), packet...)
+	}
+	conn, err := t.dialer.DialContext(ctx, network, destination)
+	if err != nil {
+		return nil, E.Cause(err, "dial ", network, " connection")
+	}
+	defer conn.Close()
+	if deadline, loaded := ctx.Deadline(); loaded {
+		conn.SetDeadline(deadline)
+	}
+	if network == N.NetworkUDP {
+		_, err = conn.Write(packet)
+		if err != nil {
+			return nil, E.Cause(err, "write request")
+		}
+		response := make([]byte, maxResponseSize)
+		n, err := conn.Read(response)
+		if err != nil {
+			return nil, E.Cause(err, "read response")
+		}
+		return response[:n], nil
+	}
+	return roundTripStream(conn, packet)
+}
+
+func roundTripStream(conn net.Conn, packet []byte) ([]byte, error) {
+	request := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(packet)), uint16(len(packet)))
+	_, err := conn.Write(append(request, packet...))
+	if err != nil {
+		return nil, E.Cause(err, "write request")
+	}
+	var responseLen uint16
+	err = binary.Read(conn, binary.BigEndian, &responseLen)
+	if err != nil {
+		return nil, E.Cause(err, "read response")
+	}
+	response := make([]byte, responseLen)
+	_, err = io.ReadFull(conn, response)
+	if err != nil {
+		return nil, E.Cause(err, "read response")
+	}
+	return response, nil
+}
+
+// relayHeader builds the header of Anonymized DNSCrypt, which tells the relay where to forward the packet.
+func relayHeader(serverAddr M.Socksaddr) []byte {
+	header := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00}
+	serverIP := serverAddr.Addr.As16()
+	header = append(header, serverIP[:]...)
+	return binary.BigEndian.AppendUint16(header, serverAddr.Port)
+}
@@ -0,0 +1,285 @@
+package dnscrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/log"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const testProviderName = "2.dnscrypt-cert.example.com."
+
+type testServer struct {
+	esVersion   uint16
+	providerKey ed25519.PrivateKey
+	publicKey   *[32]byte
+	privateKey  *[32]byte
+	clientMagic [clientMagicSize]byte
+	udpConn     net.PacketConn
+	tcpListener net.Listener
+}
+
+func startTestServer(t *testing.T, esVersion uint16) *testServer {
+	_, providerKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	server := &testServer{
+		esVersion:   esVersion,
+		providerKey: providerKey,
+		publicKey:   publicKey,
+		privateKey:  privateKey,
+	}
+	_, err = rand.Read(server.clientMagic[:])
+	require.NoError(t, err)
+	server.udpConn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server.tcpListener, err = net.Listen("tcp", server.udpConn.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		server.udpConn.Close()
+		server.tcpListener.Close()
+	})
+	go server.serveUDP()
+	go server.serveTCP()
+	return server
+}
+
+func (s *testServer) addr() M.Socksaddr {
+	return M.SocksaddrFromNet(s.udpConn.LocalAddr())
+}
+
+func (s *testServer) certificate() []byte {
+	signed := make([]byte, 0, certificateSize-72)
+	signed = append(signed, s.publicKey[:]...)
+	signed = append(signed, s.clientMagic[:]...)
+	signed = binary.BigEndian.AppendUint32(signed, 1)
+	signed = binary.BigEndian.AppendUint32(signed, uint32(time.Now().Add(-time.Hour).Unix()))
+	signed = binary.BigEndian.AppendUint32(signed, uint32(time.Now().Add(time.Hour).Unix()))
+	content := []byte(certificateMagic)
+	content = binary.BigEndian.AppendUint16(content, s.esVersion)
+	content = append(content, 0, 0)
+	content = append(content, ed25519.Sign(s.providerKey, signed)...)
+	return append(content, signed...)
+}
+
+func (s *testServer) serveUDP() {
+	buffer := make([]byte, maxResponseSize)
+	for {
+		n, addr, err := s.udpConn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		response := s.handle(buffer[:n], true)
+		if response != nil {
+			s.udpConn.WriteTo(response, addr)
+		}
+	}
+}
+
+func (s *testServer) serveTCP() {
+	for {
+		conn, err := s.tcpListener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			var length uint16
+			if binary.Read(conn, binary.BigEndian, &length) != nil {
+				return
+			}
+			packet := make([]byte, length)
+			if _, err := io.ReadFull(conn, packet); err != nil {
+				return
+			}
+			response := s.handle(packet, false)
+			if response != nil {
+				conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(response))), response...))
+			}
+		}()
+	}
+}
+
+func (s *testServer) handle(packet []byte, isUDP bool) []byte {
+	if !bytes.HasPrefix(packet, s.clientMagic[:]) {
+		var query mDNS.Msg
+		if query.Unpack(packet) != nil || len(query.Question) != 1 || query.Question[0].Name != testProviderName {
+			return nil
+		}
+		response := new(mDNS.Msg)
+		response.SetReply(&query)
+		response.Answer = append(response.Answer, &mDNS.TXT{
+			Hdr: mDNS.RR_Header{Name: testProviderName, Rrtype: mDNS.TypeTXT, Class: mDNS.ClassINET, Ttl: 60},
+			Txt: []string{escapeTXT(s.certificate())},
+		})
+		rawResponse, _ := response.Pack()
+		return rawResponse
+	}
+	var (
+		clientKey [publicKeySize]byte
+		nonce     [nonceSize]byte
+	)
+	copy(clientKey[:], packet[clientMagicSize:])
+	copy(nonce[:], packet[clientMagicSize+publicKeySize:clientMagicSize+publicKeySize+halfNonceSize])
+	sharedKey, err := computeSharedKey(s.esVersion, s.privateKey, &clientKey)
+	if err != nil {
+		return nil
+	}
+	padded, err := open(s.esVersion, packet[clientMagicSize+publicKeySize+halfNonceSize:], &nonce, &sharedKey)
+	if err != nil {
+		return nil
+	}
+	rawQuery, err := unpad(padded)
+	if err != nil {
+		return nil
+	}
+	var query mDNS.Msg
+	if query.Unpack(rawQuery) != nil {
+		return nil
+	}
+	response := new(mDNS.Msg)
+	response.SetReply(&query)
+	if isUDP && query.Question[0].Name == "tcp.example.com." {
+		response.Truncated = true
+	} else {
+		response.Answer = append(response.Answer, &mDNS.A{
+			Hdr: mDNS.RR_Header{Name: query.Question[0].Name, Rrtype: mDNS.TypeA, Class: mDNS.ClassINET, Ttl: 60},
+			A:   net.IPv4(1, 2, 3, 4),
+		})
+	}
+	rawResponse, _ := response.Pack()
+	_, err = rand.Read(nonce[halfNonceSize:])
+	if err != nil {
+		return nil
+	}
+	paddedResponse := make([]byte, (len(rawResponse)+paddingBlock)/paddingBlock*paddingBlock)
+	copy(paddedResponse, rawResponse)
+	paddedResponse[len(rawResponse)] = 0x80
+	content := append(append([]byte{}, resolverMagic...), nonce[:]...)
+	return seal(s.esVersion, content, paddedResponse, &nonce, &sharedKey)
+}
+
+func escapeTXT(content []byte) string {
+	var builder bytes.Buffer
+	for _, b := range content {
+		if b < ' ' || b > '~' || b == '"' || b == '\\' {
+			builder.WriteString("\\")
+			builder.WriteString(strconv.FormatUint(uint64(b)+1000, 10)[1:])
+		} else {
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
+}
+
+// startTestRelay runs an Anonymized DNSCrypt relay forwarding UDP packets to the address in the relay header.
+func startTestRelay(t *testing.T) M.Socksaddr {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	go func() {
+		buffer := make([]byte, maxResponseSize)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			packet := append([]byte{}, buffer[:n]...)
+			go func() {
+				if len(packet) < 28 || !bytes.Equal(packet[:10], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0}) {
+					return
+				}
+				serverIP := netip.AddrFrom16([16]byte(packet[10:26])).Unmap()
+				serverAddr := netip.AddrPortFrom(serverIP, binary.BigEndian.Uint16(packet[26:28]))
+				upstream, err := net.DialUDP("udp", nil, net.UDPAddrFromAddrPort(serverAddr))
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				upstream.SetDeadline(time.Now().Add(5 * time.Second))
+				_, err = upstream.Write(packet[28:])
+				if err != nil {
+					return
+				}
+				response := make([]byte, maxResponseSize)
+				n, err := upstream.Read(response)
+				if err != nil {
+					return
+				}
+				conn.WriteTo(response[:n], addr)
+			}()
+		}
+	}()
+	return M.SocksaddrFromNet(conn.LocalAddr())
+}
+
+func newTestTransport(server *testServer, relayAddr M.Socksaddr) *Transport {
+	return &Transport{
+		TransportAdapter: dns.NewTransportAdapter(C.DNSTypeDNSCrypt, "test", nil),
+		logger:           log.NewNOPFactory().Logger(),
+		dialer:           N.SystemDialer,
+		serverAddr:       server.addr(),
+		relayAddr:        relayAddr,
+		providerName:     testProviderName,
+		providerKey:      server.providerKey.Public().(ed25519.PublicKey),
+	}
+}
+
+func testExchange(t *testing.T, transport *Transport, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	query := new(mDNS.Msg)
+	query.SetQuestion(name, mDNS.TypeA)
+	response, err := transport.Exchange(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, query.Id, response.Id)
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, "1.2.3.4", response.Answer[0].(*mDNS.A).A.String())
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+	for _, esVersion := range []uint16{esVersionXSalsa20Poly1305, esVersionXChaCha20Poly1305} {
+		transport := newTestTransport(startTestServer(t, esVersion), M.Socksaddr{})
+		testExchange(t, transport, "example.com.")
+		testExchange(t, transport, "tcp.example.com.")
+	}
+}
+
+func TestTransportRelay(t *testing.T) {
+	t.Parallel()
+	transport := newTestTransport(startTestServer(t, esVersionXChaCha20Poly1305), startTestRelay(t))
+	testExchange(t, transport, "example.com.")
+}
+
+func TestTransportInvalidCertificate(t *testing.T) {
+	t.Parallel()
+	transport := newTestTransport(startTestServer(t, esVersionXChaCha20Poly1305), M.Socksaddr{})
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	transport.providerKey = otherKey.Public().(ed25519.PublicKey)
+	query := new(mDNS.Msg)
+	query.SetQuestion("example.com.", mDNS.TypeA)
+	_, err = transport.Exchange(context.Background(), query)
+	require.ErrorContains(t, err, "no valid certificate")
+}
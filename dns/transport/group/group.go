@@ -0,0 +1,225 @@
+package group
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	"github.com/sagernet/sing/service"
+
+	mDNS "github.com/miekg/dns"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+
+	// latencyWeight is the weight of the latest sample in the latency EWMA.
+	latencyWeight = 0.3
+)
+
+func RegisterTransport(registry *dns.TransportRegistry) {
+	dns.RegisterTransport[option.GroupDNSServerOptions](registry, C.DNSTypeGroup, NewTransport)
+}
+
+var _ adapter.DNSTransport = (*Transport)(nil)
+
+type Transport struct {
+	dns.TransportAdapter
+	logger   logger.ContextLogger
+	manager  adapter.DNSTransportManager
+	tags     []string
+	strategy string
+	timeout  time.Duration
+	access   sync.Mutex
+	latency  map[string]time.Duration
+}
+
+func NewTransport(ctx context.Context, logger log.ContextLogger, tag string, options option.GroupDNSServerOptions) (adapter.DNSTransport, error) {
+	if len(options.Servers) == 0 {
+		return nil, E.New("missing servers")
+	}
+	switch options.Strategy {
+	case "":
+		options.Strategy = C.DNSGroupStrategyFailover
+	case C.DNSGroupStrategyParallel, C.DNSGroupStrategyFastest, C.DNSGroupStrategyFailover:
+	default:
+		return nil, E.New("unknown strategy: ", options.Strategy)
+	}
+	timeout := time.Duration(options.Timeout)
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	return &Transport{
+		TransportAdapter: dns.NewTransportAdapter(C.DNSTypeGroup, tag, options.Servers),
+		logger:           logger,
+		manager:          service.FromContext[adapter.DNSTransportManager](ctx),
+		tags:             options.Servers,
+		strategy:         options.Strategy,
+		timeout:          timeout,
+		latency:          make(map[string]time.Duration),
+	}, nil
+}
+
+func (t *Transport) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	_, err := t.loadTransports()
+	return err
+}
+
+// loadTransports looks servers up on every query, since servers may be re-created by reloading.
+func (t *Transport) loadTransports() ([]adapter.DNSTransport, error) {
+	transports := make([]adapter.DNSTransport, 0, len(t.tags))
+	for _, tag := range t.tags {
+		transport, loaded := t.manager.Transport(tag)
+		if !loaded {
+			return nil, E.New("server not found: ", tag)
+		}
+		if _, isFakeIP := transport.(adapter.FakeIPTransport); isFakeIP {
+			return nil, E.New("fakeip server is not allowed in group: ", tag)
+		}
+		transports = append(transports, transport)
+	}
+	return transports, nil
+}
+
+func (t *Transport) Close() error {
+	return nil
+}
+
+func (t *Transport) Reset() {
+	t.access.Lock()
+	defer t.access.Unlock()
+	t.latency = make(map[string]time.Duration)
+}
+
+func (t *Transport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	transports, err := t.loadTransports()
+	if err != nil {
+		return nil, err
+	}
+	switch t.strategy {
+	case C.DNSGroupStrategyParallel:
+		return t.exchangeParallel(ctx, message, transports)
+	case C.DNSGroupStrategyFastest:
+		return t.exchangeSequential(ctx, message, t.sortByLatency(transports))
+	default:
+		return t.exchangeSequential(ctx, message, transports)
+	}
+}
+
+func (t *Transport) exchange(ctx context.Context, transport adapter.DNSTransport, message *mDNS.Msg) (*mDNS.Msg, error) {
+	exchangeCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	startAt := time.Now()
+	response, err := transport.Exchange(exchangeCtx, message.Copy())
+	if err == nil && response.Rcode == mDNS.RcodeServerFailure {
+		err = dns.RcodeServerFailure
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			t.updateLatency(transport, t.timeout)
+		}
+		return response, err
+	}
+	t.updateLatency(transport, time.Since(startAt))
+	return response, nil
+}
+
+// exchangeSequential queries servers in order, moving on to the next one on error, timeout or SERVFAIL.
+func (t *Transport) exchangeSequential(ctx context.Context, message *mDNS.Msg, transports []adapter.DNSTransport) (*mDNS.Msg, error) {
+	var (
+		response       *mDNS.Msg
+		exchangeErrors []error
+	)
+	for _, transport := range transports {
+		if ctx.Err() != nil {
+			break
+		}
+		exchangeResponse, err := t.exchange(ctx, transport, message)
+		if err == nil {
+			return exchangeResponse, nil
+		}
+		if exchangeResponse != nil {
+			response = exchangeResponse
+		}
+		t.logger.DebugContext(ctx, E.Cause(err, "exchange with server[", transport.Tag(), "]"))
+		exchangeErrors = append(exchangeErrors, E.Cause(err, transport.Tag()))
+	}
+	if response != nil {
+		return response, nil
+	}
+	if len(exchangeErrors) == 0 {
+		return nil, ctx.Err()
+	}
+	return nil, E.Errors(exchangeErrors...)
+}
+
+// exchangeParallel queries all servers at once, the first successful answer wins.
+func (t *Transport) exchangeParallel(ctx context.Context, message *mDNS.Msg, transports []adapter.DNSTransport) (*mDNS.Msg, error) {
+	type exchangeResult struct {
+		transport adapter.DNSTransport
+		response  *mDNS.Msg
+		err       error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan exchangeResult, len(transports))
+	for _, transport := range transports {
+		go func() {
+			response, err := t.exchange(ctx, transport, message)
+			results <- exchangeResult{transport, response, err}
+		}()
+	}
+	var (
+		response       *mDNS.Msg
+		exchangeErrors []error
+	)
+	for range transports {
+		result := <-results
+		if result.err == nil {
+			return result.response, nil
+		}
+		if result.response != nil {
+			response = result.response
+		}
+		exchangeErrors = append(exchangeErrors, E.Cause(result.err, result.transport.Tag()))
+	}
+	if response != nil {
+		return response, nil
+	}
+	return nil, E.Errors(exchangeErrors...)
+}
+
+func (t *Transport) updateLatency(transport adapter.DNSTransport, latency time.Duration) {
+	if t.strategy != C.DNSGroupStrategyFastest {
+		return
+	}
+	t.access.Lock()
+	defer t.access.Unlock()
+	average, loaded := t.latency[transport.Tag()]
+	if !loaded {
+		t.latency[transport.Tag()] = latency
+		return
+	}
+	t.latency[transport.Tag()] = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(average))
+}
+
+// sortByLatency orders servers by their latency average, servers without samples come first so that they are measured.
+func (t *Transport) sortByLatency(transports []adapter.DNSTransport) []adapter.DNSTransport {
+	t.access.Lock()
+	defer t.access.Unlock()
+	sort.SliceStable(transports, func(i, j int) bool {
+		return t.latency[transports[i].Tag()] < t.latency[transports[j].Tag()]
+	})
+	return transports
+}
@@ -0,0 +1,170 @@
+package odoh
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+
+	E "github.com/sagernet/sing/common/exceptions"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// A minimal implementation of the base mode of HPKE (RFC 9180), limited to the suites used by Oblivious DoH targets.
+
+const (
+	kemX25519HKDFSHA256 = 0x0020
+	kdfHKDFSHA256       = 0x0001
+
+	aeadAES128GCM        = 0x0001
+	aeadAES256GCM        = 0x0002
+	aeadChaCha20Poly1305 = 0x0003
+
+	hpkeVersion = "HPKE-v1"
+)
+
+type hpkeSuite struct {
+	kemID  uint16
+	kdfID  uint16
+	aeadID uint16
+}
+
+func (s hpkeSuite) supported() bool {
+	if s.kemID != kemX25519HKDFSHA256 || s.kdfID != kdfHKDFSHA256 {
+		return false
+	}
+	switch s.aeadID {
+	case aeadAES128GCM, aeadAES256GCM, aeadChaCha20Poly1305:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s hpkeSuite) keySize() int {
+	if s.aeadID == aeadAES128GCM {
+		return 16
+	}
+	return 32
+}
+
+func (s hpkeSuite) nonceSize() int {
+	return 12
+}
+
+func (s hpkeSuite) newAEAD(key []byte) (cipher.AEAD, error) {
+	switch s.aeadID {
+	case aeadAES128GCM, aeadAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case aeadChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	default:
+		return nil, E.New("unsupported AEAD: ", s.aeadID)
+	}
+}
+
+func (s hpkeSuite) id() []byte {
+	suiteID := []byte("HPKE")
+	suiteID = binary.BigEndian.AppendUint16(suiteID, s.kemID)
+	suiteID = binary.BigEndian.AppendUint16(suiteID, s.kdfID)
+	return binary.BigEndian.AppendUint16(suiteID, s.aeadID)
+}
+
+func kemSuiteID() []byte {
+	return binary.BigEndian.AppendUint16([]byte("KEM"), kemX25519HKDFSHA256)
+}
+
+func labeledExtract(suiteID []byte, salt []byte, label string, ikm []byte) []byte {
+	labeledIKM := make([]byte, 0, len(hpkeVersion)+len(suiteID)+len(label)+len(ikm))
+	labeledIKM = append(labeledIKM, hpkeVersion...)
+	labeledIKM = append(labeledIKM, suiteID...)
+	labeledIKM = append(labeledIKM, label...)
+	labeledIKM = append(labeledIKM, ikm...)
+	prk, _ := hkdf.Extract(sha256.New, labeledIKM, salt)
+	return prk
+}
+
+func labeledExpand(suiteID []byte, prk []byte, label string, info []byte, length int) []byte {
+	labeledInfo := binary.BigEndian.AppendUint16(nil, uint16(length))
+	labeledInfo = append(labeledInfo, hpkeVersion...)
+	labeledInfo = append(labeledInfo, suiteID...)
+	labeledInfo = append(labeledInfo, label...)
+	labeledInfo = append(labeledInfo, info...)
+	key, _ := hkdf.Expand(sha256.New, prk, string(labeledInfo), length)
+	return key
+}
+
+// kemSharedSecret derives the shared secret of DHKEM(X25519, HKDF-SHA256) from the Diffie-Hellman result.
+func kemSharedSecret(dh []byte, enc []byte, publicKey []byte) []byte {
+	kemContext := append(append([]byte{}, enc...), publicKey...)
+	eaePRK := labeledExtract(kemSuiteID(), nil, "eae_prk", dh)
+	return labeledExpand(kemSuiteID(), eaePRK, "shared_secret", kemContext, sha256.Size)
+}
+
+type hpkeContext struct {
+	suite          hpkeSuite
+	aead           cipher.AEAD
+	baseNonce      []byte
+	exporterSecret []byte
+}
+
+func newHPKEContext(suite hpkeSuite, sharedSecret []byte, info []byte) (*hpkeContext, error) {
+	suiteID := suite.id()
+	keyScheduleContext := []byte{0x00}
+	keyScheduleContext = append(keyScheduleContext, labeledExtract(suiteID, nil, "psk_id_hash", nil)...)
+	keyScheduleContext = append(keyScheduleContext, labeledExtract(suiteID, nil, "info_hash", info)...)
+	secret := labeledExtract(suiteID, sharedSecret, "secret", nil)
+	aead, err := suite.newAEAD(labeledExpand(suiteID, secret, "key", keyScheduleContext, suite.keySize()))
+	if err != nil {
+		return nil, err
+	}
+	return &hpkeContext{
+		suite:          suite,
+		aead:           aead,
+		baseNonce:      labeledExpand(suiteID, secret, "base_nonce", keyScheduleContext, suite.nonceSize()),
+		exporterSecret: labeledExpand(suiteID, secret, "exp", keyScheduleContext, sha256.Size),
+	}, nil
+}
+
+// setupBaseSender encapsulates a fresh ephemeral key to the public key of the recipient.
+func setupBaseSender(suite hpkeSuite, publicKey []byte, info []byte) ([]byte, *hpkeContext, error) {
+	recipientKey, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ephemeralKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	dh, err := ephemeralKey.ECDH(recipientKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := ephemeralKey.PublicKey().Bytes()
+	context, err := newHPKEContext(suite, kemSharedSecret(dh, enc, publicKey), info)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, context, nil
+}
+
+// seal encrypts the only message of the context, so the nonce is the base nonce.
+func (c *hpkeContext) seal(aad []byte, plaintext []byte) []byte {
+	return c.aead.Seal(nil, c.baseNonce, plaintext, aad)
+}
+
+func (c *hpkeContext) open(aad []byte, ciphertext []byte) ([]byte, error) {
+	return c.aead.Open(nil, c.baseNonce, ciphertext, aad)
+}
+
+func (c *hpkeContext) export(exporterContext []byte, length int) []byte {
+	return labeledExpand(c.suite.id(), c.exporterSecret, "sec", exporterContext, length)
+}
@@ -0,0 +1,165 @@
+package odoh
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+
+	E "github.com/sagernet/sing/common/exceptions"
+)
+
+// Message formats of Oblivious DNS over HTTPS (RFC 9230).
+
+const (
+	MimeType = "application/oblivious-dns-message"
+
+	configVersion       = 0x0001
+	messageTypeQuery    = 0x01
+	messageTypeResponse = 0x02
+	paddingBlock        = 128
+)
+
+type targetConfig struct {
+	suite     hpkeSuite
+	publicKey []byte
+	keyID     []byte
+}
+
+// parseConfigs selects the first supported config from an ObliviousDoHConfigs structure.
+func parseConfigs(content []byte) (*targetConfig, error) {
+	configs, _, err := readVector(content)
+	if err != nil {
+		return nil, E.Cause(err, "read configs")
+	}
+	for len(configs) > 0 {
+		if len(configs) < 2 {
+			return nil, E.New("read configs: short config")
+		}
+		version := binary.BigEndian.Uint16(configs)
+		var contents []byte
+		contents, configs, err = readVector(configs[2:])
+		if err != nil {
+			return nil, E.Cause(err, "read configs")
+		}
+		if version != configVersion {
+			continue
+		}
+		config, err := parseConfigContents(contents)
+		if err != nil {
+			return nil, err
+		}
+		if config.suite.supported() {
+			return config, nil
+		}
+	}
+	return nil, E.New("no supported config")
+}
+
+func parseConfigContents(contents []byte) (*targetConfig, error) {
+	if len(contents) < 6 {
+		return nil, E.New("read config: short contents")
+	}
+	config := &targetConfig{
+		suite: hpkeSuite{
+			kemID:  binary.BigEndian.Uint16(contents),
+			kdfID:  binary.BigEndian.Uint16(contents[2:]),
+			aeadID: binary.BigEndian.Uint16(contents[4:]),
+		},
+	}
+	publicKey, remaining, err := readVector(contents[6:])
+	if err != nil {
+		return nil, E.Cause(err, "read config")
+	}
+	if len(remaining) > 0 {
+		return nil, E.New("read config: trailing data")
+	}
+	config.publicKey = publicKey
+	prk, err := hkdf.Extract(sha256.New, contents, nil)
+	if err != nil {
+		return nil, err
+	}
+	config.keyID, err = hkdf.Expand(sha256.New, prk, "odoh key id", sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+type queryContext struct {
+	hpke      *hpkeContext
+	plaintext []byte
+}
+
+func (c *targetConfig) encryptQuery(message []byte) ([]byte, *queryContext, error) {
+	plaintext := appendVector(nil, message)
+	plaintext = appendVector(plaintext, make([]byte, (paddingBlock-len(message)%paddingBlock)%paddingBlock))
+	enc, context, err := setupBaseSender(c.suite, c.publicKey, []byte("odoh query"))
+	if err != nil {
+		return nil, nil, err
+	}
+	aad := appendVector([]byte{messageTypeQuery}, c.keyID)
+	query := appendVector([]byte{messageTypeQuery}, c.keyID)
+	query = appendVector(query, append(enc, context.seal(aad, plaintext)...))
+	return query, &queryContext{context, plaintext}, nil
+}
+
+func (c *queryContext) decryptResponse(response []byte) ([]byte, error) {
+	if len(response) < 1 || response[0] != messageTypeResponse {
+		return nil, E.New("unexpected message type")
+	}
+	responseNonce, remaining, err := readVector(response[1:])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, remaining, err := readVector(remaining)
+	if err != nil {
+		return nil, err
+	}
+	if len(remaining) > 0 {
+		return nil, E.New("trailing data")
+	}
+	suite := c.hpke.suite
+	secret := c.hpke.export([]byte("odoh response"), suite.keySize())
+	salt := appendVector(append([]byte{}, c.plaintext...), responseNonce)
+	prk, err := hkdf.Extract(sha256.New, secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hkdf.Expand(sha256.New, prk, "odoh key", suite.keySize())
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "odoh nonce", suite.nonceSize())
+	if err != nil {
+		return nil, err
+	}
+	aead, err := suite.newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, appendVector([]byte{messageTypeResponse}, responseNonce))
+	if err != nil {
+		return nil, E.Cause(err, "decrypt response")
+	}
+	message, _, err := readVector(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func readVector(content []byte) ([]byte, []byte, error) {
+	if len(content) < 2 {
+		return nil, nil, E.New("short vector")
+	}
+	length := int(binary.BigEndian.Uint16(content))
+	if len(content) < 2+length {
+		return nil, nil, E.New("short vector")
+	}
+	return content[2 : 2+length], content[2+length:], nil
+}
+
+func appendVector(content []byte, value []byte) []byte {
+	content = binary.BigEndian.AppendUint16(content, uint16(len(value)))
+	return append(content, value...)
+}
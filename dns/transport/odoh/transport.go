@@ -0,0 +1,315 @@
+package odoh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/dnsstamp"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/dns/transport"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	mDNS "github.com/miekg/dns"
+	"golang.org/x/net/http2"
+)
+
+const (
+	configPath = "/.well-known/odohconfigs"
+	configTTL  = time.Hour
+)
+
+var errConfigRejected = E.New("target rejected the config")
+
+func RegisterTransport(registry *dns.TransportRegistry) {
+	dns.RegisterTransport[option.ODoHDNSServerOptions](registry, C.DNSTypeODoH, NewTransport)
+}
+
+var _ adapter.DNSTransport = (*Transport)(nil)
+
+type Transport struct {
+	dns.TransportAdapter
+	logger          logger.ContextLogger
+	dialer          N.Dialer
+	targetURL       *url.URL
+	relayURL        *url.URL
+	transportAccess sync.Mutex
+	targetTransport *transport.HTTPSTransportWrapper
+	relayTransport  *transport.HTTPSTransportWrapper
+	configAccess    sync.Mutex
+	config          *targetConfig
+	configExpiresAt time.Time
+}
+
+func NewTransport(ctx context.Context, logger log.ContextLogger, tag string, options option.ODoHDNSServerOptions) (adapter.DNSTransport, error) {
+	if options.Stamp == "" {
+		return nil, E.New("missing stamp")
+	}
+	stamp, err := dnsstamp.Parse(options.Stamp)
+	if err != nil {
+		return nil, E.Cause(err, "parse stamp")
+	}
+	if stamp.Protocol != dnsstamp.ProtocolODoHTarget {
+		return nil, E.New("unexpected stamp protocol: ", stamp.Protocol)
+	}
+	targetURL, targetAddr, err := parseURL(stamp.Hostname, stamp.Path)
+	if err != nil {
+		return nil, E.Cause(err, "parse target")
+	}
+	var (
+		relayURL  *url.URL
+		relayAddr M.Socksaddr
+	)
+	if options.Relay != "" {
+		relayStamp, err := dnsstamp.Parse(options.Relay)
+		if err != nil {
+			return nil, E.Cause(err, "parse relay stamp")
+		}
+		if relayStamp.Protocol != dnsstamp.ProtocolODoHRelay {
+			return nil, E.New("unexpected relay stamp protocol: ", relayStamp.Protocol)
+		}
+		relayURL, relayAddr, err = parseURL(relayStamp.Hostname, relayStamp.Path)
+		if err != nil {
+			return nil, E.Cause(err, "parse relay")
+		}
+		if relayStamp.Address != "" {
+			port := relayAddr.Port
+			relayAddr = M.ParseSocksaddr(relayStamp.Address)
+			if relayAddr.Port == 0 {
+				relayAddr.Port = port
+			}
+		}
+		relayURL.RawQuery = url.Values{
+			"targethost": []string{targetURL.Host},
+			"targetpath": []string{targetURL.Path},
+		}.Encode()
+	}
+	transportDialer, err := dialer.NewWithOptions(dialer.Options{
+		Context:        ctx,
+		Options:        options.DialerOptions,
+		RemoteIsDomain: targetAddr.IsFqdn() || relayAddr.IsFqdn(),
+		DirectResolver: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	targetTransport, err := newHTTPSTransport(ctx, logger, transportDialer, targetURL, targetAddr, options.TLS)
+	if err != nil {
+		return nil, err
+	}
+	var relayTransport *transport.HTTPSTransportWrapper
+	if relayURL != nil {
+		relayTransport, err = newHTTPSTransport(ctx, logger, transportDialer, relayURL, relayAddr, options.RelayTLS)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var dependencies []string
+	if options.DomainResolver != nil && options.DomainResolver.Server != "" {
+		dependencies = append(dependencies, options.DomainResolver.Server)
+	}
+	return &Transport{
+		TransportAdapter: dns.NewTransportAdapter(C.DNSTypeODoH, tag, dependencies),
+		logger:           logger,
+		dialer:           transportDialer,
+		targetURL:        targetURL,
+		relayURL:         relayURL,
+		targetTransport:  targetTransport,
+		relayTransport:   relayTransport,
+	}, nil
+}
+
+func parseURL(host string, path string) (*url.URL, M.Socksaddr, error) {
+	if host == "" {
+		return nil, M.Socksaddr{}, E.New("missing hostname")
+	}
+	if path == "" {
+		path = "/dns-query"
+	}
+	serverURL, err := url.Parse("https://" + host + path)
+	if err != nil {
+		return nil, M.Socksaddr{}, err
+	}
+	serverAddr := M.ParseSocksaddr(serverURL.Host)
+	if serverAddr.Port == 0 {
+		serverAddr.Port = 443
+	}
+	return serverURL, serverAddr, nil
+}
+
+func newHTTPSTransport(ctx context.Context, logger log.ContextLogger, dialer N.Dialer, serverURL *url.URL, serverAddr M.Socksaddr, options *option.OutboundTLSOptions) (*transport.HTTPSTransportWrapper, error) {
+	tlsOptions := common.PtrValueOrDefault(options)
+	tlsOptions.Enabled = true
+	tlsConfig, err := tls.NewClient(ctx, logger, serverURL.Hostname(), tlsOptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(tlsConfig.NextProtos()) == 0 {
+		tlsConfig.SetNextProtos([]string{http2.NextProtoTLS, "http/1.1"})
+	}
+	return transport.NewHTTPSTransportWrapper(tls.NewDialer(dialer, tlsConfig), serverAddr), nil
+}
+
+func (t *Transport) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	return dialer.InitializeDetour(t.dialer)
+}
+
+func (t *Transport) Close() error {
+	t.Reset()
+	return nil
+}
+
+func (t *Transport) Reset() {
+	t.transportAccess.Lock()
+	t.targetTransport.CloseIdleConnections()
+	t.targetTransport = t.targetTransport.Clone()
+	if t.relayTransport != nil {
+		t.relayTransport.CloseIdleConnections()
+		t.relayTransport = t.relayTransport.Clone()
+	}
+	t.transportAccess.Unlock()
+	t.configAccess.Lock()
+	t.config = nil
+	t.configAccess.Unlock()
+}
+
+func (t *Transport) Exchange(ctx context.Context, message *mDNS.Msg) (*mDNS.Msg, error) {
+	config, err := t.loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response, err := t.exchange(ctx, config, message)
+	if errors.Is(err, errConfigRejected) {
+		t.logger.DebugContext(ctx, "config rejected by target, fetching again")
+		t.configAccess.Lock()
+		if t.config == config {
+			t.config = nil
+		}
+		t.configAccess.Unlock()
+		config, err = t.loadConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		response, err = t.exchange(ctx, config, message)
+	}
+	return response, err
+}
+
+func (t *Transport) exchange(ctx context.Context, config *targetConfig, message *mDNS.Msg) (*mDNS.Msg, error) {
+	exMessage := *message
+	exMessage.Id = 0
+	exMessage.Compress = true
+	rawMessage, err := exMessage.Pack()
+	if err != nil {
+		return nil, err
+	}
+	query, queryContext, err := config.encryptQuery(rawMessage)
+	if err != nil {
+		return nil, err
+	}
+	t.transportAccess.Lock()
+	currentTransport := t.targetTransport
+	destination := t.targetURL
+	if t.relayTransport != nil {
+		currentTransport = t.relayTransport
+		destination = t.relayURL
+	}
+	t.transportAccess.Unlock()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, destination.String(), bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", MimeType)
+	request.Header.Set("Accept", MimeType)
+	response, err := currentTransport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, errConfigRejected
+	default:
+		return nil, E.New("unexpected status: ", response.Status)
+	}
+	rawResponse, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	rawResponse, err = queryContext.decryptResponse(rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	var responseMessage mDNS.Msg
+	err = responseMessage.Unpack(rawResponse)
+	if err != nil {
+		return nil, err
+	}
+	responseMessage.Id = message.Id
+	return &responseMessage, nil
+}
+
+// loadConfig returns the cached target config, fetching it directly from the target when missing or expired.
+func (t *Transport) loadConfig(ctx context.Context) (*targetConfig, error) {
+	t.configAccess.Lock()
+	config := t.config
+	expiresAt := t.configExpiresAt
+	t.configAccess.Unlock()
+	if config != nil && time.Now().Before(expiresAt) {
+		return config, nil
+	}
+	// the lock is not held while fetching, so that queries are not blocked by a slow or unreachable target
+	config, err := t.fetchConfig(ctx)
+	if err != nil {
+		return nil, E.Cause(err, "fetch target config")
+	}
+	t.configAccess.Lock()
+	t.config = config
+	t.configExpiresAt = time.Now().Add(configTTL)
+	t.configAccess.Unlock()
+	return config, nil
+}
+
+func (t *Transport) fetchConfig(ctx context.Context) (*targetConfig, error) {
+	configURL := *t.targetURL
+	configURL.Path = configPath
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	t.transportAccess.Lock()
+	currentTransport := t.targetTransport
+	t.transportAccess.Unlock()
+	response, err := currentTransport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, E.New("unexpected status: ", response.Status)
+	}
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigs(content)
+}
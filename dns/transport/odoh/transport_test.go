@@ -0,0 +1,243 @@
+package odoh
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+
+	mDNS "github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+type testTarget struct {
+	*httptest.Server
+	suite      hpkeSuite
+	access     sync.Mutex
+	privateKey *ecdh.PrivateKey
+	contents   []byte
+	config     *targetConfig
+}
+
+func startTestTarget(t *testing.T, aeadID uint16) *testTarget {
+	target := &testTarget{
+		suite: hpkeSuite{kemX25519HKDFSHA256, kdfHKDFSHA256, aeadID},
+	}
+	target.rotate(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+configPath, target.serveConfigs)
+	mux.HandleFunc("POST /dns-query", target.serveQuery)
+	target.Server = httptest.NewUnstartedServer(mux)
+	target.EnableHTTP2 = true
+	target.StartTLS()
+	t.Cleanup(target.Close)
+	return target
+}
+
+func (s *testTarget) rotate(t *testing.T) {
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	contents := binary.BigEndian.AppendUint16(nil, s.suite.kemID)
+	contents = binary.BigEndian.AppendUint16(contents, s.suite.kdfID)
+	contents = binary.BigEndian.AppendUint16(contents, s.suite.aeadID)
+	contents = appendVector(contents, privateKey.PublicKey().Bytes())
+	config, err := parseConfigContents(contents)
+	require.NoError(t, err)
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.privateKey = privateKey
+	s.contents = contents
+	s.config = config
+}
+
+func (s *testTarget) serveConfigs(writer http.ResponseWriter, request *http.Request) {
+	s.access.Lock()
+	defer s.access.Unlock()
+	// an unknown version comes first, which must be skipped
+	configs := binary.BigEndian.AppendUint16(nil, 0xff01)
+	configs = appendVector(configs, []byte{0})
+	configs = binary.BigEndian.AppendUint16(configs, configVersion)
+	configs = appendVector(configs, s.contents)
+	writer.Write(appendVector(nil, configs))
+}
+
+func (s *testTarget) serveQuery(writer http.ResponseWriter, request *http.Request) {
+	if request.Header.Get("Content-Type") != MimeType {
+		writer.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(request.Body)
+	if err != nil || len(body) < 1 || body[0] != messageTypeQuery {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	keyID, remaining, err := readVector(body[1:])
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.access.Lock()
+	privateKey, config := s.privateKey, s.config
+	s.access.Unlock()
+	if !bytes.Equal(keyID, config.keyID) {
+		writer.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	encrypted, _, err := readVector(remaining)
+	if err != nil || len(encrypted) < 32 {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	enc := encrypted[:32]
+	senderKey, err := ecdh.X25519().NewPublicKey(enc)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	dh, err := privateKey.ECDH(senderKey)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	context, err := newHPKEContext(s.suite, kemSharedSecret(dh, enc, config.publicKey), []byte("odoh query"))
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	queryPlaintext, err := context.open(appendVector([]byte{messageTypeQuery}, keyID), encrypted[32:])
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rawQuery, _, err := readVector(queryPlaintext)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var query mDNS.Msg
+	err = query.Unpack(rawQuery)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	response := new(mDNS.Msg)
+	response.SetReply(&query)
+	response.Answer = append(response.Answer, &mDNS.A{
+		Hdr: mDNS.RR_Header{Name: query.Question[0].Name, Rrtype: mDNS.TypeA, Class: mDNS.ClassINET, Ttl: 60},
+		A:   net.IPv4(1, 2, 3, 4),
+	})
+	rawResponse, _ := response.Pack()
+	responseNonce := make([]byte, max(s.suite.keySize(), s.suite.nonceSize()))
+	rand.Read(responseNonce)
+	secret := context.export([]byte("odoh response"), s.suite.keySize())
+	prk, _ := hkdf.Extract(sha256.New, secret, appendVector(append([]byte{}, queryPlaintext...), responseNonce))
+	key, _ := hkdf.Expand(sha256.New, prk, "odoh key", s.suite.keySize())
+	nonce, _ := hkdf.Expand(sha256.New, prk, "odoh nonce", s.suite.nonceSize())
+	aead, _ := s.suite.newAEAD(key)
+	responsePlaintext := appendVector(appendVector(nil, rawResponse), nil)
+	ciphertext := aead.Seal(nil, nonce, responsePlaintext, appendVector([]byte{messageTypeResponse}, responseNonce))
+	writer.Header().Set("Content-Type", MimeType)
+	writer.Write(appendVector(appendVector([]byte{messageTypeResponse}, responseNonce), ciphertext))
+}
+
+func startTestRelay(t *testing.T, target *testTarget, relayed *atomic.Int32) *httptest.Server {
+	relay := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		targetURL := url.URL{
+			Scheme: "https",
+			Host:   request.URL.Query().Get("targethost"),
+			Path:   request.URL.Query().Get("targetpath"),
+		}
+		response, err := target.Client().Post(targetURL.String(), request.Header.Get("Content-Type"), request.Body)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer response.Body.Close()
+		relayed.Add(1)
+		writer.WriteHeader(response.StatusCode)
+		io.Copy(writer, response.Body)
+	}))
+	t.Cleanup(relay.Close)
+	return relay
+}
+
+func newTestTransport(t *testing.T, target *testTarget, relay *httptest.Server) *Transport {
+	logger := log.NewNOPFactory().Logger()
+	tlsOptions := &option.OutboundTLSOptions{Insecure: true}
+	targetURL, targetAddr, err := parseURL(target.Listener.Addr().String(), "")
+	require.NoError(t, err)
+	targetTransport, err := newHTTPSTransport(context.Background(), logger, N.SystemDialer, targetURL, targetAddr, tlsOptions)
+	require.NoError(t, err)
+	transport := &Transport{
+		TransportAdapter: dns.NewTransportAdapter(C.DNSTypeODoH, "test", nil),
+		logger:           logger,
+		dialer:           N.SystemDialer,
+		targetURL:        targetURL,
+		targetTransport:  targetTransport,
+	}
+	if relay != nil {
+		relayURL, _, err := parseURL(relay.Listener.Addr().String(), "/proxy")
+		require.NoError(t, err)
+		relayURL.RawQuery = url.Values{"targethost": []string{targetURL.Host}, "targetpath": []string{targetURL.Path}}.Encode()
+		transport.relayURL = relayURL
+		transport.relayTransport, err = newHTTPSTransport(context.Background(), logger, N.SystemDialer, relayURL, M.SocksaddrFromNet(relay.Listener.Addr()), tlsOptions)
+		require.NoError(t, err)
+	}
+	return transport
+}
+
+func testExchange(t *testing.T, transport *Transport) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	query := new(mDNS.Msg)
+	query.SetQuestion("example.com.", mDNS.TypeA)
+	response, err := transport.Exchange(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, query.Id, response.Id)
+	require.Len(t, response.Answer, 1)
+	require.Equal(t, "1.2.3.4", response.Answer[0].(*mDNS.A).A.String())
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+	for _, aeadID := range []uint16{aeadAES128GCM, aeadAES256GCM, aeadChaCha20Poly1305} {
+		testExchange(t, newTestTransport(t, startTestTarget(t, aeadID), nil))
+	}
+}
+
+func TestTransportRelay(t *testing.T) {
+	t.Parallel()
+	var relayed atomic.Int32
+	target := startTestTarget(t, aeadAES128GCM)
+	transport := newTestTransport(t, target, startTestRelay(t, target, &relayed))
+	testExchange(t, transport)
+	require.Equal(t, int32(1), relayed.Load())
+}
+
+func TestTransportKeyRotation(t *testing.T) {
+	t.Parallel()
+	target := startTestTarget(t, aeadAES128GCM)
+	transport := newTestTransport(t, target, nil)
+	testExchange(t, transport)
+	target.rotate(t)
+	testExchange(t, transport)
+}
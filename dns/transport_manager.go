@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 
@@ -201,6 +202,10 @@ func (m *TransportManager) Remove(tag string) error {
 	if !found {
 		return os.ErrInvalid
 	}
+	dependBy := m.dependByTag[tag]
+	if len(dependBy) > 0 {
+		return E.New("server[", tag, "] is depended by ", strings.Join(dependBy, ", "))
+	}
 	delete(m.transportByTag, tag)
 	index := common.Index(m.transports, func(it adapter.DNSTransport) bool {
 		return it == transport
@@ -213,7 +218,7 @@ func (m *TransportManager) Remove(tag string) error {
 	if m.defaultTransport == transport {
 		if len(m.transports) > 0 {
 			nextTransport := m.transports[0]
-			if nextTransport.Type() != C.DNSTypeFakeIP {
+			if nextTransport.Type() == C.DNSTypeFakeIP {
 				return E.New("default server cannot be fakeip")
 			}
 			m.defaultTransport = nextTransport
@@ -222,12 +227,19 @@ func (m *TransportManager) Remove(tag string) error {
 			m.defaultTransport = nil
 		}
 	}
-	dependBy := m.dependByTag[tag]
-	if len(dependBy) > 0 {
-		return E.New("server[", tag, "] is depended by ", strings.Join(dependBy, ", "))
+	if m.fakeIPTransport == transport {
+		m.fakeIPTransport = nil
 	}
-	dependencies := transport.Dependencies()
-	for _, dependency := range dependencies {
+	m.removeDependencies(transport)
+	if started {
+		transport.Close()
+	}
+	return nil
+}
+
+func (m *TransportManager) removeDependencies(transport adapter.DNSTransport) {
+	tag := transport.Tag()
+	for _, dependency := range transport.Dependencies() {
 		if len(m.dependByTag[dependency]) == 1 {
 			delete(m.dependByTag, dependency)
 		} else {
@@ -236,10 +248,13 @@ func (m *TransportManager) Remove(tag string) error {
 			})
 		}
 	}
-	if started {
-		transport.Close()
-	}
-	return nil
+}
+
+// DependedBy returns tags of servers that depend on the given server.
+func (m *TransportManager) DependedBy(tag string) []string {
+	m.access.RLock()
+	defer m.access.RUnlock()
+	return slices.Clone(m.dependByTag[tag])
 }
 
 func (m *TransportManager) Create(ctx context.Context, logger log.ContextLogger, tag string, transportType string, options any) error {
@@ -274,6 +289,13 @@ func (m *TransportManager) Create(ctx context.Context, logger log.ContextLogger,
 			panic("invalid inbound index")
 		}
 		m.transports = append(m.transports[:existsIndex], m.transports[existsIndex+1:]...)
+		m.removeDependencies(existsTransport)
+		if m.defaultTransport == existsTransport {
+			m.defaultTransport = nil
+		}
+		if m.fakeIPTransport == existsTransport {
+			m.fakeIPTransport = nil
+		}
 	}
 	m.transports = append(m.transports, transport)
 	m.transportByTag[tag] = transport
@@ -24,12 +24,18 @@ var (
 	bucketMode     = []byte("clash_mode")
 	bucketRuleSet  = []byte("rule_set")
 
+	bucketOutboundProvider = []byte("outbound_provider")
+	bucketTrafficQuota     = []byte("traffic_quota")
+
 	bucketNameList = []string{
 		string(bucketSelected),
 		string(bucketExpand),
 		string(bucketMode),
 		string(bucketRuleSet),
+		string(bucketOutboundProvider),
+		string(bucketTrafficQuota),
 		string(bucketRDRC),
+		string(bucketDNSCache),
 	}
 
 	cacheIDDefault = []byte("default")
@@ -44,6 +50,7 @@ type CacheFile struct {
 	storeFakeIP       bool
 	storeRDRC         bool
 	rdrcTimeout       time.Duration
+	storeDNS          bool
 	DB                *bbolt.DB
 	saveMetadataTimer *time.Timer
 	saveFakeIPAccess  sync.RWMutex
@@ -52,6 +59,12 @@ type CacheFile struct {
 	saveAddress6      map[string]netip.Addr
 	saveRDRCAccess    sync.RWMutex
 	saveRDRC          map[saveRDRCCacheKey]bool
+	saveDNSAccess     sync.Mutex
+	saveDNS           map[string]saveDNSCacheEntry
+	saveDNSWrite      sync.Mutex
+	saveDNSNotify     chan struct{}
+	saveDNSClose      chan struct{}
+	saveDNSDone       chan struct{}
 }
 
 type saveRDRCCacheKey struct {
@@ -80,16 +93,21 @@ func New(ctx context.Context, options option.CacheFileOptions) *CacheFile {
 		}
 	}
 	return &CacheFile{
-		ctx:          ctx,
-		path:         filemanager.BasePath(ctx, path),
-		cacheID:      cacheIDBytes,
-		storeFakeIP:  options.StoreFakeIP,
-		storeRDRC:    options.StoreRDRC,
-		rdrcTimeout:  rdrcTimeout,
-		saveDomain:   make(map[netip.Addr]string),
-		saveAddress4: make(map[string]netip.Addr),
-		saveAddress6: make(map[string]netip.Addr),
-		saveRDRC:     make(map[saveRDRCCacheKey]bool),
+		ctx:           ctx,
+		path:          filemanager.BasePath(ctx, path),
+		cacheID:       cacheIDBytes,
+		storeFakeIP:   options.StoreFakeIP,
+		storeRDRC:     options.StoreRDRC,
+		rdrcTimeout:   rdrcTimeout,
+		storeDNS:      options.StoreDNS,
+		saveDomain:    make(map[netip.Addr]string),
+		saveAddress4:  make(map[string]netip.Addr),
+		saveAddress6:  make(map[string]netip.Addr),
+		saveRDRC:      make(map[saveRDRCCacheKey]bool),
+		saveDNS:       make(map[string]saveDNSCacheEntry),
+		saveDNSNotify: make(chan struct{}, 1),
+		saveDNSClose:  make(chan struct{}),
+		saveDNSDone:   make(chan struct{}),
 	}
 }
 
@@ -159,6 +177,9 @@ func (c *CacheFile) Start(stage adapter.StartStage) error {
 		return err
 	}
 	c.DB = db
+	if c.storeDNS {
+		go c.loopSaveDNSCache()
+	}
 	return nil
 }
 
@@ -166,6 +187,10 @@ func (c *CacheFile) Close() error {
 	if c.DB == nil {
 		return nil
 	}
+	if c.storeDNS {
+		close(c.saveDNSClose)
+		<-c.saveDNSDone
+	}
 	return c.DB.Close()
 }
 
@@ -316,3 +341,77 @@ func (c *CacheFile) SaveRuleSet(tag string, set *adapter.SavedBinary) error {
 		return bucket.Put([]byte(tag), setBinary)
 	})
 }
+
+func (c *CacheFile) LoadOutboundProvider(tag string) *adapter.SavedBinary {
+	var savedProvider adapter.SavedBinary
+	err := c.DB.View(func(t *bbolt.Tx) error {
+		bucket := c.bucket(t, bucketOutboundProvider)
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+		providerBinary := bucket.Get([]byte(tag))
+		if len(providerBinary) == 0 {
+			return os.ErrInvalid
+		}
+		return savedProvider.UnmarshalBinary(providerBinary)
+	})
+	if err != nil {
+		return nil
+	}
+	return &savedProvider
+}
+
+func (c *CacheFile) SaveOutboundProvider(tag string, provider *adapter.SavedBinary) error {
+	return c.DB.Batch(func(t *bbolt.Tx) error {
+		bucket, err := c.createBucket(t, bucketOutboundProvider)
+		if err != nil {
+			return err
+		}
+		providerBinary, err := provider.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(tag), providerBinary)
+	})
+}
+
+func (c *CacheFile) LoadTrafficQuota(inbound string, user string) *adapter.SavedTrafficQuota {
+	var savedQuota adapter.SavedTrafficQuota
+	err := c.DB.View(func(t *bbolt.Tx) error {
+		bucket := c.bucket(t, bucketTrafficQuota)
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+		bucket = bucket.Bucket([]byte(inbound))
+		if bucket == nil {
+			return os.ErrNotExist
+		}
+		quotaBinary := bucket.Get([]byte(user))
+		if len(quotaBinary) == 0 {
+			return os.ErrInvalid
+		}
+		return savedQuota.UnmarshalBinary(quotaBinary)
+	})
+	if err != nil {
+		return nil
+	}
+	return &savedQuota
+}
+
+func (c *CacheFile) SaveTrafficQuota(inbound string, user string, quota *adapter.SavedTrafficQuota) error {
+	return c.DB.Batch(func(t *bbolt.Tx) error {
+		bucket, err := c.createBucket(t, bucketTrafficQuota)
+		if err != nil {
+			return err
+		}
+		bucket, err = bucket.CreateBucketIfNotExists([]byte(inbound))
+		if err != nil {
+			return err
+		}
+		quotaBinary, err := quota.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(user), quotaBinary)
+	})
+}
@@ -0,0 +1,215 @@
+package cachefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/sagernet/bbolt"
+	bboltErrors "github.com/sagernet/bbolt/errors"
+	"github.com/sagernet/sing/common/logger"
+
+	"github.com/miekg/dns"
+)
+
+var bucketDNSCache = []byte("dns_cache")
+
+func (c *CacheFile) StoreDNS() bool {
+	return c.storeDNS
+}
+
+func dnsCacheKey(transportName string, question dns.Question) []byte {
+	key := make([]byte, len(transportName)+5+len(question.Name))
+	copy(key, transportName)
+	binary.BigEndian.PutUint16(key[len(transportName)+1:], question.Qtype)
+	binary.BigEndian.PutUint16(key[len(transportName)+3:], question.Qclass)
+	copy(key[len(transportName)+5:], question.Name)
+	return key
+}
+
+func parseDNSCacheKey(key []byte) (transportName string, question dns.Question, ok bool) {
+	index := bytes.IndexByte(key, 0)
+	if index == -1 || len(key) < index+5 {
+		return
+	}
+	transportName = string(key[:index])
+	question.Qtype = binary.BigEndian.Uint16(key[index+1:])
+	question.Qclass = binary.BigEndian.Uint16(key[index+3:])
+	question.Name = string(key[index+5:])
+	ok = true
+	return
+}
+
+func (c *CacheFile) LoadDNSCache(loadFunc func(transportName string, question dns.Question, message *dns.Msg, expireAt time.Time) bool) {
+	var deleteKeys [][]byte
+	c.DB.View(func(tx *bbolt.Tx) error {
+		bucket := c.bucket(tx, bucketDNSCache)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(key, content []byte) error {
+			transportName, question, loaded := parseDNSCacheKey(key)
+			if loaded && len(content) > 8 {
+				var expireAt time.Time
+				if expireUnix := binary.BigEndian.Uint64(content); expireUnix > 0 {
+					expireAt = time.Unix(int64(expireUnix), 0)
+				}
+				var message dns.Msg
+				if message.Unpack(content[8:]) == nil && loadFunc(transportName, question, &message, expireAt) {
+					return nil
+				}
+			}
+			deleteKeys = append(deleteKeys, bytes.Clone(key))
+			return nil
+		})
+	})
+	if len(deleteKeys) > 0 {
+		c.DB.Update(func(tx *bbolt.Tx) error {
+			bucket := c.bucket(tx, bucketDNSCache)
+			if bucket == nil {
+				return nil
+			}
+			for _, key := range deleteKeys {
+				err := bucket.Delete(key)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (c *CacheFile) SaveDNSCache(transportName string, question dns.Question, message *dns.Msg, expireAt time.Time) error {
+	content, err := packDNSCache(message, expireAt)
+	if err != nil {
+		return err
+	}
+	key := dnsCacheKey(transportName, question)
+	c.saveDNSWrite.Lock()
+	defer c.saveDNSWrite.Unlock()
+	c.saveDNSAccess.Lock()
+	delete(c.saveDNS, string(key))
+	c.saveDNSAccess.Unlock()
+	return c.DB.Batch(func(tx *bbolt.Tx) error {
+		bucket, err := c.createBucket(tx, bucketDNSCache)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, content)
+	})
+}
+
+func (c *CacheFile) SaveDNSCacheAsync(transportName string, question dns.Question, message *dns.Msg, expireAt time.Time, logger logger.Logger) {
+	content, err := packDNSCache(message, expireAt)
+	if err != nil {
+		logger.Warn("save DNS cache: ", err)
+		return
+	}
+	c.queueDNSCache(dnsCacheKey(transportName, question), content, logger)
+}
+
+func packDNSCache(message *dns.Msg, expireAt time.Time) ([]byte, error) {
+	content := make([]byte, 9+message.Len())
+	if !expireAt.IsZero() {
+		binary.BigEndian.PutUint64(content, uint64(expireAt.Unix()))
+	}
+	packed, err := message.PackBuffer(content[8:])
+	if err != nil {
+		return nil, err
+	}
+	return content[:8+len(packed)], nil
+}
+
+func (c *CacheFile) DeleteDNSCacheAsync(transportName string, question dns.Question, logger logger.Logger) {
+	c.queueDNSCache(dnsCacheKey(transportName, question), nil, logger)
+}
+
+type saveDNSCacheEntry struct {
+	content []byte
+	logger  logger.Logger
+}
+
+// queueDNSCache replaces any pending write of the key, so saves and deletes of the same question are applied in call order.
+func (c *CacheFile) queueDNSCache(key []byte, content []byte, logger logger.Logger) {
+	c.saveDNSAccess.Lock()
+	c.saveDNS[string(key)] = saveDNSCacheEntry{content, logger}
+	c.saveDNSAccess.Unlock()
+	select {
+	case c.saveDNSNotify <- struct{}{}:
+	default:
+	}
+}
+
+func (c *CacheFile) loopSaveDNSCache() {
+	defer close(c.saveDNSDone)
+	for {
+		select {
+		case <-c.saveDNSNotify:
+			c.flushDNSCache()
+		case <-c.saveDNSClose:
+			c.flushDNSCache()
+			return
+		}
+	}
+}
+
+func (c *CacheFile) flushDNSCache() {
+	c.saveDNSWrite.Lock()
+	defer c.saveDNSWrite.Unlock()
+	c.saveDNSAccess.Lock()
+	entries := c.saveDNS
+	c.saveDNS = make(map[string]saveDNSCacheEntry)
+	c.saveDNSAccess.Unlock()
+	if len(entries) == 0 {
+		return
+	}
+	var logger logger.Logger
+	err := c.DB.Update(func(tx *bbolt.Tx) error {
+		bucket, err := c.createBucket(tx, bucketDNSCache)
+		if err != nil {
+			return err
+		}
+		for key, entry := range entries {
+			logger = entry.logger
+			if entry.content == nil {
+				err = bucket.Delete([]byte(key))
+			} else {
+				err = bucket.Put([]byte(key), entry.content)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && logger != nil {
+		logger.Warn("save DNS cache: ", err)
+	}
+}
+
+func (c *CacheFile) ClearDNSCache() error {
+	c.saveDNSWrite.Lock()
+	defer c.saveDNSWrite.Unlock()
+	c.saveDNSAccess.Lock()
+	c.saveDNS = make(map[string]saveDNSCacheEntry)
+	c.saveDNSAccess.Unlock()
+	return c.DB.Batch(func(tx *bbolt.Tx) error {
+		if c.cacheID == nil {
+			err := tx.DeleteBucket(bucketDNSCache)
+			if err == bboltErrors.ErrBucketNotFound {
+				return nil
+			}
+			return err
+		}
+		bucket := tx.Bucket(c.cacheID)
+		if bucket == nil {
+			return nil
+		}
+		err := bucket.DeleteBucket(bucketDNSCache)
+		if err == bboltErrors.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+}
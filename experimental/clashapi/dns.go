@@ -1,21 +1,30 @@
 package clashapi
 
 import (
+	"bytes"
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/sagernet/sing-box/adapter"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/service"
+	"github.com/sagernet/ws"
+	"github.com/sagernet/ws/wsutil"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/miekg/dns"
 )
 
-func dnsRouter(router adapter.DNSRouter) http.Handler {
+func dnsRouter(ctx context.Context, router adapter.DNSRouter) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/query", queryDNS(router))
+	r.Get("/logs", getDNSLogs(ctx))
+	r.Delete("/logs", clearDNSLogs(ctx))
 	return r
 }
 
@@ -80,3 +89,106 @@ func queryDNS(router adapter.DNSRouter) func(w http.ResponseWriter, r *http.Requ
 		render.JSON(w, r, responseData)
 	}
 }
+
+type dnsLogFilter struct {
+	domain string
+	source string
+}
+
+func (f dnsLogFilter) match(entry *adapter.DNSQueryLogEntry) bool {
+	if f.domain != "" && !strings.Contains(entry.Domain, f.domain) {
+		return false
+	}
+	if f.source != "" && !strings.HasPrefix(entry.Source, f.source) {
+		return false
+	}
+	return true
+}
+
+func getDNSLogs(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queryLog := service.FromContext[adapter.DNSQueryLog](ctx)
+		if queryLog == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, newError("DNS query log is disabled"))
+			return
+		}
+		filter := dnsLogFilter{
+			domain: r.URL.Query().Get("domain"),
+			source: r.URL.Query().Get("source"),
+		}
+		if r.Header.Get("Upgrade") != "websocket" {
+			var limit int
+			if limitText := r.URL.Query().Get("limit"); limitText != "" {
+				var err error
+				limit, err = strconv.Atoi(limitText)
+				if err != nil || limit < 0 {
+					render.Status(r, http.StatusBadRequest)
+					render.JSON(w, r, ErrBadRequest)
+					return
+				}
+			}
+			entries := make([]*adapter.DNSQueryLogEntry, 0)
+			for _, entry := range queryLog.Entries() {
+				if filter.match(entry) {
+					entries = append(entries, entry)
+				}
+			}
+			if limit > 0 && len(entries) > limit {
+				entries = entries[len(entries)-limit:]
+			}
+			render.JSON(w, r, render.M{
+				"logs": entries,
+			})
+			return
+		}
+
+		subscription, done, err := queryLog.Subscribe()
+		if err != nil {
+			render.Status(r, http.StatusNoContent)
+			return
+		}
+		defer queryLog.UnSubscribe(subscription)
+
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buf := &bytes.Buffer{}
+		var entry *adapter.DNSQueryLogEntry
+		for {
+			select {
+			case <-done:
+				return
+			case entry = <-subscription:
+			}
+			if entry == nil || !filter.match(entry) {
+				continue
+			}
+			buf.Reset()
+			err = json.NewEncoder(buf).Encode(entry)
+			if err != nil {
+				break
+			}
+			err = wsutil.WriteServerText(conn, buf.Bytes())
+			if err != nil {
+				break
+			}
+		}
+	}
+}
+
+func clearDNSLogs(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queryLog := service.FromContext[adapter.DNSQueryLog](ctx)
+		if queryLog == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, newError("DNS query log is disabled"))
+			return
+		}
+		queryLog.Clear()
+		render.NoContent(w, r)
+	}
+}
@@ -3,50 +3,116 @@ package clashapi
 import (
 	"context"
 	"net/http"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common/batch"
+	"github.com/sagernet/sing/common/json/badjson"
+	"github.com/sagernet/sing/service"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 )
 
-func proxyProviderRouter() http.Handler {
+func proxyProviderRouter(server *Server) http.Handler {
 	r := chi.NewRouter()
-	r.Get("/", getProviders)
+	r.Get("/", getProviders(server))
 
 	r.Route("/{name}", func(r chi.Router) {
-		r.Use(parseProviderName, findProviderByName)
-		r.Get("/", getProvider)
+		r.Use(parseProviderName, findProviderByName(server))
+		r.Get("/", getProvider(server))
 		r.Put("/", updateProvider)
-		r.Get("/healthcheck", healthCheckProvider)
+		r.Get("/healthcheck", healthCheckProvider(server))
 	})
 	return r
 }
 
-func getProviders(w http.ResponseWriter, r *http.Request) {
-	render.JSON(w, r, render.M{
-		"providers": render.M{},
-	})
+func providerInfo(server *Server, provider adapter.OutboundProvider) *badjson.JSONObject {
+	var info badjson.JSONObject
+	info.Put("name", provider.Tag())
+	info.Put("type", "Proxy")
+	info.Put("vehicleType", "HTTP")
+	proxies := make([]*badjson.JSONObject, 0)
+	for _, detour := range provider.Outbounds() {
+		proxies = append(proxies, proxyInfo(server, detour))
+	}
+	info.Put("proxies", proxies)
+	info.Put("updatedAt", provider.UpdatedAt())
+	return &info
 }
 
-func getProvider(w http.ResponseWriter, r *http.Request) {
-	/*provider := r.Context().Value(CtxKeyProvider).(provider.ProxyProvider)
-	render.JSON(w, r, provider)*/
-	render.NoContent(w, r)
+func getProviders(server *Server) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var providerMap badjson.JSONObject
+		providerManager := service.FromContext[adapter.OutboundProviderManager](server.ctx)
+		if providerManager != nil {
+			for _, provider := range providerManager.OutboundProviders() {
+				providerMap.Put(provider.Tag(), providerInfo(server, provider))
+			}
+		}
+		var responseMap badjson.JSONObject
+		responseMap.Put("providers", &providerMap)
+		response, err := responseMap.MarshalJSON()
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		w.Write(response)
+	}
+}
+
+func getProvider(server *Server) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := r.Context().Value(CtxKeyProvider).(adapter.OutboundProvider)
+		response, err := providerInfo(server, provider).MarshalJSON()
+		if err != nil {
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, newError(err.Error()))
+			return
+		}
+		w.Write(response)
+	}
 }
 
 func updateProvider(w http.ResponseWriter, r *http.Request) {
-	/*provider := r.Context().Value(CtxKeyProvider).(provider.ProxyProvider)
-	if err := provider.Update(); err != nil {
+	provider := r.Context().Value(CtxKeyProvider).(adapter.OutboundProvider)
+	if err := provider.Update(r.Context()); err != nil {
 		render.Status(r, http.StatusServiceUnavailable)
 		render.JSON(w, r, newError(err.Error()))
 		return
-	}*/
+	}
 	render.NoContent(w, r)
 }
 
-func healthCheckProvider(w http.ResponseWriter, r *http.Request) {
-	/*provider := r.Context().Value(CtxKeyProvider).(provider.ProxyProvider)
-	provider.HealthCheck()*/
-	render.NoContent(w, r)
+func healthCheckProvider(server *Server) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := r.Context().Value(CtxKeyProvider).(adapter.OutboundProvider)
+		b, _ := batch.New(r.Context(), batch.WithConcurrencyNum[any](10))
+		for _, detour := range provider.Outbounds() {
+			tag := detour.Tag()
+			b.Go(tag, func() (any, error) {
+				testCtx, cancel := context.WithTimeout(r.Context(), C.TCPTimeout)
+				defer cancel()
+				t, err := urltest.URLTest(testCtx, "", detour)
+				if err != nil {
+					server.logger.Debug("outbound ", tag, " unavailable: ", err)
+					server.urlTestHistory.DeleteURLTestHistory(tag)
+				} else {
+					server.logger.Debug("outbound ", tag, " available: ", t, "ms")
+					server.urlTestHistory.StoreURLTestHistory(tag, &adapter.URLTestHistory{
+						Time:  time.Now(),
+						Delay: t,
+					})
+				}
+				return nil, nil
+			})
+		}
+		b.Wait()
+		render.NoContent(w, r)
+	}
 }
 
 func parseProviderName(next http.Handler) http.Handler {
@@ -57,18 +123,24 @@ func parseProviderName(next http.Handler) http.Handler {
 	})
 }
 
-func findProviderByName(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		/*name := r.Context().Value(CtxKeyProviderName).(string)
-		providers := tunnel.ProxyProviders()
-		provider, exist := providers[name]
-		if !exist {*/
-		render.Status(r, http.StatusNotFound)
-		render.JSON(w, r, ErrNotFound)
-		//return
-		//}
-
-		// ctx := context.WithValue(r.Context(), CtxKeyProvider, provider)
-		// next.ServeHTTP(w, r.WithContext(ctx))
-	})
+func findProviderByName(server *Server) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.Context().Value(CtxKeyProviderName).(string)
+			providerManager := service.FromContext[adapter.OutboundProviderManager](server.ctx)
+			if providerManager == nil {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrNotFound)
+				return
+			}
+			provider, exist := providerManager.OutboundProvider(name)
+			if !exist {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, ErrNotFound)
+				return
+			}
+			ctx := context.WithValue(r.Context(), CtxKeyProvider, provider)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
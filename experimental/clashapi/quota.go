@@ -0,0 +1,117 @@
+package clashapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+func quotaRouter(ctx context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", getQuotas(ctx))
+	r.Route("/{inbound}/{user}", func(r chi.Router) {
+		r.Get("/", getQuota(ctx))
+		r.Patch("/", adjustQuota(ctx))
+		r.Delete("/", resetQuota(ctx))
+	})
+	return r
+}
+
+func getQuotas(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotas := make([]adapter.TrafficQuotaStatus, 0)
+		quotaManager := service.FromContext[adapter.TrafficQuotaManager](ctx)
+		if quotaManager != nil {
+			quotas = append(quotas, quotaManager.TrafficQuotas()...)
+		}
+		render.JSON(w, r, render.M{
+			"quotas": quotas,
+		})
+	}
+}
+
+func getQuota(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotaManager := service.FromContext[adapter.TrafficQuotaManager](ctx)
+		if quotaManager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		quota, loaded := quotaManager.TrafficQuota(getEscapeParam(r, "inbound"), getEscapeParam(r, "user"))
+		if !loaded {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		render.JSON(w, r, quota)
+	}
+}
+
+type AdjustQuotaRequest struct {
+	Limit *uint64 `json:"limit"`
+	Used  *uint64 `json:"used"`
+}
+
+func adjustQuota(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := AdjustQuotaRequest{}
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, ErrBadRequest)
+			return
+		}
+		quotaManager := service.FromContext[adapter.TrafficQuotaManager](ctx)
+		if quotaManager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		inbound, user := getEscapeParam(r, "inbound"), getEscapeParam(r, "user")
+		if _, loaded := quotaManager.TrafficQuota(inbound, user); !loaded {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		if req.Limit != nil {
+			err := quotaManager.SetTrafficQuotaLimit(inbound, user, *req.Limit)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, newError(err.Error()))
+				return
+			}
+		}
+		if req.Used != nil {
+			err := quotaManager.SetTrafficQuotaUsed(inbound, user, *req.Used)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, newError(err.Error()))
+				return
+			}
+		}
+		render.NoContent(w, r)
+	}
+}
+
+func resetQuota(ctx context.Context) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quotaManager := service.FromContext[adapter.TrafficQuotaManager](ctx)
+		if quotaManager == nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		err := quotaManager.ResetTrafficQuota(getEscapeParam(r, "inbound"), getEscapeParam(r, "user"))
+		if err != nil {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, ErrNotFound)
+			return
+		}
+		render.NoContent(w, r)
+	}
+}
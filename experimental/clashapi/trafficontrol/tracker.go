@@ -64,19 +64,27 @@ func (t TrackerMetadata) MarshalJSON() ([]byte, error) {
 	} else {
 		rule = "final"
 	}
+	metadata := map[string]any{
+		"network":         t.Metadata.Network,
+		"type":            inbound,
+		"sourceIP":        t.Metadata.Source.Addr,
+		"destinationIP":   t.Metadata.Destination.Addr,
+		"sourcePort":      F.ToString(t.Metadata.Source.Port),
+		"destinationPort": F.ToString(t.Metadata.Destination.Port),
+		"host":            domain,
+		"dnsMode":         "normal",
+		"processPath":     processPath,
+	}
+	if t.Metadata.UserConnectionLimiter != nil {
+		metadata["userConnections"] = t.Metadata.UserConnectionLimiter.Connections()
+		metadata["userDevices"] = t.Metadata.UserConnectionLimiter.Devices()
+	}
+	if t.Metadata.ConnectionLimiter != nil {
+		metadata["routeConnections"] = t.Metadata.ConnectionLimiter.Connections()
+	}
 	return json.Marshal(map[string]any{
-		"id": t.ID,
-		"metadata": map[string]any{
-			"network":         t.Metadata.Network,
-			"type":            inbound,
-			"sourceIP":        t.Metadata.Source.Addr,
-			"destinationIP":   t.Metadata.Destination.Addr,
-			"sourcePort":      F.ToString(t.Metadata.Source.Port),
-			"destinationPort": F.ToString(t.Metadata.Destination.Port),
-			"host":            domain,
-			"dnsMode":         "normal",
-			"processPath":     processPath,
-		},
+		"id":          t.ID,
+		"metadata":    metadata,
 		"upload":      t.Upload.Load(),
 		"download":    t.Download.Load(),
 		"start":       t.CreatedAt,
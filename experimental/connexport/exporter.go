@@ -0,0 +1,193 @@
+package connexport
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+const (
+	defaultFlushInterval = 5 * time.Second
+	maxBufferSize        = 256 * 1024
+	recordQueueSize      = 4096
+)
+
+var (
+	_ adapter.LifecycleService  = (*Exporter)(nil)
+	_ adapter.ConnectionTracker = (*Exporter)(nil)
+)
+
+// Exporter writes a JSON line for each completed routed connection to a file,
+// a unix socket or an HTTP collector.
+type Exporter struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	logger        log.Logger
+	options       option.ConnectionExportOptions
+	outbound      adapter.OutboundManager
+	flushInterval time.Duration
+	sink          sink
+	records       chan *Record
+	dropped       atomic.Uint64
+	closing       chan struct{}
+	done          chan struct{}
+}
+
+func NewExporter(ctx context.Context, logger log.Logger, options option.ConnectionExportOptions) (*Exporter, error) {
+	switch options.Type {
+	case "":
+		options.Type = C.ConnectionExportTypeFile
+		fallthrough
+	case C.ConnectionExportTypeFile, C.ConnectionExportTypeUnix:
+		if options.Path == "" {
+			return nil, E.New("missing path")
+		}
+	case C.ConnectionExportTypeHTTP:
+		if options.URL == "" {
+			return nil, E.New("missing url")
+		}
+	default:
+		return nil, E.New("unknown type: ", options.Type)
+	}
+	flushInterval := time.Duration(options.FlushInterval)
+	if flushInterval == 0 {
+		flushInterval = defaultFlushInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Exporter{
+		ctx:           ctx,
+		cancel:        cancel,
+		logger:        logger,
+		options:       options,
+		outbound:      service.FromContext[adapter.OutboundManager](ctx),
+		flushInterval: flushInterval,
+		records:       make(chan *Record, recordQueueSize),
+		closing:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}, nil
+}
+
+func (e *Exporter) Name() string {
+	return "connection exporter"
+}
+
+func (e *Exporter) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	switch e.options.Type {
+	case C.ConnectionExportTypeFile:
+		fileSink, err := newFileSink(e.ctx, e.options.Path)
+		if err != nil {
+			return E.Cause(err, "open connection export file")
+		}
+		e.sink = fileSink
+	case C.ConnectionExportTypeUnix:
+		e.sink = &unixSink{path: e.options.Path}
+	case C.ConnectionExportTypeHTTP:
+		var dialer N.Dialer
+		if e.options.Detour != "" {
+			outbound, loaded := e.outbound.Outbound(e.options.Detour)
+			if !loaded {
+				return E.New("detour not found: ", e.options.Detour)
+			}
+			dialer = outbound
+		} else {
+			dialer = e.outbound.Default()
+		}
+		e.sink = newHTTPSink(e.ctx, e.options.URL, e.options.Headers.Build(), dialer)
+	}
+	go e.loopExport()
+	return nil
+}
+
+func (e *Exporter) Close() error {
+	if e.sink == nil {
+		return nil
+	}
+	close(e.closing)
+	<-e.done
+	e.cancel()
+	return e.sink.Close()
+}
+
+func (e *Exporter) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) net.Conn {
+	return newConn(conn, e.newTracker(metadata, matchedRule, matchOutbound))
+}
+
+func (e *Exporter) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) N.PacketConn {
+	return newPacketConn(conn, e.newTracker(metadata, matchedRule, matchOutbound))
+}
+
+func (e *Exporter) export(record *Record) {
+	select {
+	case e.records <- record:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+func (e *Exporter) loopExport() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	for {
+		select {
+		case record := <-e.records:
+			e.encode(encoder, &buffer, record)
+		case <-ticker.C:
+			e.flush(&buffer)
+		case <-e.closing:
+			for {
+				select {
+				case record := <-e.records:
+					e.encode(encoder, &buffer, record)
+				default:
+					e.flush(&buffer)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *Exporter) encode(encoder *json.Encoder, buffer *bytes.Buffer, record *Record) {
+	err := encoder.Encode(record)
+	if err != nil {
+		e.logger.Error(E.Cause(err, "encode connection record"))
+		return
+	}
+	if buffer.Len() >= maxBufferSize {
+		e.flush(buffer)
+	}
+}
+
+func (e *Exporter) flush(buffer *bytes.Buffer) {
+	if dropped := e.dropped.Swap(0); dropped > 0 {
+		e.logger.Warn("export queue is full, dropped ", dropped, " connection records")
+	}
+	if buffer.Len() == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, C.TCPTimeout)
+	err := e.sink.Write(ctx, buffer.Bytes())
+	cancel()
+	if err != nil {
+		e.logger.Error(E.Cause(err, "export connection records"))
+	}
+	buffer.Reset()
+}
@@ -0,0 +1,160 @@
+package connexport
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/bufio"
+	F "github.com/sagernet/sing/common/format"
+	N "github.com/sagernet/sing/common/network"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Record describes a completed connection.
+type Record struct {
+	ID          string    `json:"id"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Duration    int64     `json:"duration_ms"`
+	Network     string    `json:"network"`
+	Inbound     string    `json:"inbound,omitempty"`
+	InboundType string    `json:"inbound_type,omitempty"`
+	User        string    `json:"user,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Destination string    `json:"destination"`
+	Domain      string    `json:"domain,omitempty"`
+	Protocol    string    `json:"protocol,omitempty"`
+	Client      string    `json:"client,omitempty"`
+	Rule        string    `json:"rule"`
+	Outbound    string    `json:"outbound"`
+	Chain       []string  `json:"chain"`
+	Upload      int64     `json:"upload"`
+	Download    int64     `json:"download"`
+	CloseReason string    `json:"close_reason"`
+}
+
+type tracker struct {
+	exporter  *Exporter
+	record    Record
+	upload    atomic.Int64
+	download  atomic.Int64
+	closeOnce sync.Once
+}
+
+func (e *Exporter) newTracker(metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) *tracker {
+	id, _ := uuid.NewV4()
+	record := Record{
+		ID:          id.String(),
+		StartTime:   time.Now(),
+		Network:     metadata.Network,
+		Inbound:     metadata.Inbound,
+		InboundType: metadata.InboundType,
+		User:        metadata.User,
+		Destination: metadata.Destination.String(),
+		Domain:      metadata.Domain,
+		Protocol:    metadata.Protocol,
+		Client:      metadata.Client,
+	}
+	if metadata.Source.IsValid() {
+		record.Source = metadata.Source.String()
+	}
+	if matchedRule != nil {
+		record.Rule = F.ToString(matchedRule, " => ", matchedRule.Action())
+	} else {
+		record.Rule = "final"
+	}
+	next := matchOutbound.Tag()
+	for {
+		detour, loaded := e.outbound.Outbound(next)
+		if !loaded {
+			break
+		}
+		record.Chain = append(record.Chain, next)
+		record.Outbound = detour.Tag()
+		group, isGroup := detour.(adapter.OutboundGroup)
+		if !isGroup {
+			break
+		}
+		next = group.Now()
+	}
+	return &tracker{
+		exporter: e,
+		record:   record,
+	}
+}
+
+func (t *tracker) countUpload(n int64) {
+	t.upload.Add(n)
+}
+
+func (t *tracker) countDownload(n int64) {
+	t.download.Add(n)
+}
+
+func (t *tracker) RoutedConnectionClosed(err error) {
+	t.closeOnce.Do(func() {
+		record := t.record
+		record.EndTime = time.Now()
+		record.Duration = record.EndTime.Sub(record.StartTime).Milliseconds()
+		record.Upload = t.upload.Load()
+		record.Download = t.download.Load()
+		if err != nil {
+			record.CloseReason = err.Error()
+		} else {
+			record.CloseReason = "closed"
+		}
+		t.exporter.export(&record)
+	})
+}
+
+type trackedConn struct {
+	net.Conn
+	*tracker
+}
+
+func newConn(conn net.Conn, tracker *tracker) *trackedConn {
+	return &trackedConn{
+		Conn:    bufio.NewCounterConn(conn, []N.CountFunc{tracker.countUpload}, []N.CountFunc{tracker.countDownload}),
+		tracker: tracker,
+	}
+}
+
+func (c *trackedConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *trackedConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *trackedConn) Upstream() any {
+	return c.Conn
+}
+
+type trackedPacketConn struct {
+	N.PacketConn
+	*tracker
+}
+
+func newPacketConn(conn N.PacketConn, tracker *tracker) *trackedPacketConn {
+	return &trackedPacketConn{
+		PacketConn: bufio.NewCounterPacketConn(conn, []N.CountFunc{tracker.countUpload}, []N.CountFunc{tracker.countDownload}),
+		tracker:    tracker,
+	}
+}
+
+func (c *trackedPacketConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *trackedPacketConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *trackedPacketConn) Upstream() any {
+	return c.PacketConn
+}
@@ -0,0 +1,125 @@
+package connexport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/service/filemanager"
+)
+
+type sink interface {
+	Write(ctx context.Context, content []byte) error
+	Close() error
+}
+
+type fileSink struct {
+	file *os.File
+}
+
+func newFileSink(ctx context.Context, path string) (*fileSink, error) {
+	file, err := filemanager.OpenFile(ctx, path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file}, nil
+}
+
+func (s *fileSink) Write(ctx context.Context, content []byte) error {
+	_, err := s.file.Write(content)
+	return err
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// unixSink streams records to a unix socket, reconnecting after write failures.
+type unixSink struct {
+	path string
+	conn net.Conn
+}
+
+func (s *unixSink) Write(ctx context.Context, content []byte) error {
+	if s.conn == nil {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "unix", s.path)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	_, err := s.conn.Write(content)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *unixSink) Close() error {
+	return common.Close(s.conn)
+}
+
+type httpSink struct {
+	url     string
+	headers http.Header
+	client  *http.Client
+}
+
+func newHTTPSink(ctx context.Context, url string, headers http.Header, dialer N.Dialer) *httpSink {
+	return &httpSink{
+		url:     url,
+		headers: headers,
+		client: &http.Client{
+			Transport: &http.Transport{
+				ForceAttemptHTTP2:   true,
+				TLSHandshakeTimeout: C.TCPTimeout,
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return dialer.DialContext(ctx, network, M.ParseSocksaddr(addr))
+				},
+				TLSClientConfig: &tls.Config{
+					Time:    ntp.TimeFuncFromContext(ctx),
+					RootCAs: adapter.RootPoolFromContext(ctx),
+				},
+			},
+		},
+	}
+}
+
+func (s *httpSink) Write(ctx context.Context, content []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.headers {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/x-ndjson")
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return E.New("unexpected status: ", response.Status)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
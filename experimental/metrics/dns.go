@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+
+	"github.com/miekg/dns"
+)
+
+// dnsLatencyBuckets are upper bounds in seconds of the DNS query duration histogram.
+var dnsLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type dnsCounter struct {
+	cached    atomic.Uint64
+	succeeded atomic.Uint64
+	failed    atomic.Uint64
+	buckets   []atomic.Uint64
+	latency   atomic.Int64
+}
+
+type dnsCounters struct {
+	access   sync.Mutex
+	counters map[string]*dnsCounter
+}
+
+func (c *dnsCounters) load(transport string) *dnsCounter {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.counters == nil {
+		c.counters = make(map[string]*dnsCounter)
+	}
+	counter, loaded := c.counters[transport]
+	if !loaded {
+		counter = &dnsCounter{
+			buckets: make([]atomic.Uint64, len(dnsLatencyBuckets)),
+		}
+		c.counters[transport] = counter
+	}
+	return counter
+}
+
+func (s *Server) DNSQueryCached(ctx context.Context, transport adapter.DNSTransport, question dns.Question) {
+	s.dns.load(transport.Tag()).cached.Add(1)
+}
+
+func (s *Server) DNSQueryExchanged(ctx context.Context, transport adapter.DNSTransport, question dns.Question, latency time.Duration, err error) {
+	counter := s.dns.load(transport.Tag())
+	if err != nil {
+		counter.failed.Add(1)
+	} else {
+		counter.succeeded.Add(1)
+	}
+	seconds := latency.Seconds()
+	for i, bound := range dnsLatencyBuckets {
+		if seconds <= bound {
+			counter.buckets[i].Add(1)
+		}
+	}
+	counter.latency.Add(int64(latency))
+}
+
+func (c *dnsCounters) write(w *writer) {
+	c.access.Lock()
+	transports := make([]string, 0, len(c.counters))
+	for transport := range c.counters {
+		transports = append(transports, transport)
+	}
+	sort.Strings(transports)
+	counters := make([]*dnsCounter, 0, len(transports))
+	for _, transport := range transports {
+		counters = append(counters, c.counters[transport])
+	}
+	c.access.Unlock()
+	w.family("sing_box_dns_queries_total", "counter", "DNS queries by transport and result.")
+	for i, counter := range counters {
+		transport := label{"transport", transports[i]}
+		w.sample("sing_box_dns_queries_total", float64(counter.cached.Load()), transport, label{"result", "cached"})
+		w.sample("sing_box_dns_queries_total", float64(counter.succeeded.Load()), transport, label{"result", "success"})
+		w.sample("sing_box_dns_queries_total", float64(counter.failed.Load()), transport, label{"result", "failure"})
+	}
+	w.family("sing_box_dns_cache_hit_ratio", "gauge", "Ratio of DNS queries answered from cache.")
+	for i, counter := range counters {
+		cached := counter.cached.Load()
+		total := cached + counter.succeeded.Load() + counter.failed.Load()
+		if total == 0 {
+			continue
+		}
+		w.sample("sing_box_dns_cache_hit_ratio", float64(cached)/float64(total), label{"transport", transports[i]})
+	}
+	w.family("sing_box_dns_query_duration_seconds", "histogram", "Latency of DNS exchanges by transport.")
+	for i, counter := range counters {
+		transport := label{"transport", transports[i]}
+		count := counter.succeeded.Load() + counter.failed.Load()
+		for j, bound := range dnsLatencyBuckets {
+			w.sample("sing_box_dns_query_duration_seconds_bucket", float64(counter.buckets[j].Load()), transport, label{"le", formatValue(bound)})
+		}
+		w.sample("sing_box_dns_query_duration_seconds_bucket", float64(count), transport, label{"le", "+Inf"})
+		w.sample("sing_box_dns_query_duration_seconds_sum", time.Duration(counter.latency.Load()).Seconds(), transport)
+		w.sample("sing_box_dns_query_duration_seconds_count", float64(count), transport)
+	}
+}
+
+func (s *Server) writeDNSCache(w *writer) {
+	if s.dnsRouter == nil {
+		return
+	}
+	statistics := s.dnsRouter.CacheStatistics()
+	transports := make([]string, 0, len(statistics))
+	for transport := range statistics {
+		transports = append(transports, transport)
+	}
+	sort.Strings(transports)
+	w.family("sing_box_dns_cache_lookups_total", "counter", "DNS cache lookups by transport and result.")
+	for _, transport := range transports {
+		counter := statistics[transport]
+		transportLabel := label{"transport", transport}
+		w.sample("sing_box_dns_cache_lookups_total", float64(counter.Hit), transportLabel, label{"result", "hit"})
+		w.sample("sing_box_dns_cache_lookups_total", float64(counter.Miss), transportLabel, label{"result", "miss"})
+		w.sample("sing_box_dns_cache_lookups_total", float64(counter.Stale), transportLabel, label{"result", "stale"})
+	}
+}
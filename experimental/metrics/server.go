@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/service"
+)
+
+var (
+	_ adapter.LifecycleService  = (*Server)(nil)
+	_ adapter.ConnectionTracker = (*Server)(nil)
+	_ adapter.DNSQueryTracker   = (*Server)(nil)
+)
+
+type Server struct {
+	ctx        context.Context
+	logger     log.Logger
+	listen     string
+	path       string
+	router     adapter.Router
+	dnsRouter  adapter.DNSRouter
+	outbound   adapter.OutboundManager
+	history    *urltest.HistoryStorage
+	httpServer *http.Server
+	startedAt  time.Time
+	inbounds   trafficCounters
+	outbounds  trafficCounters
+	rules      trafficCounters
+	dns        dnsCounters
+}
+
+func NewServer(ctx context.Context, logger log.Logger, options option.MetricsOptions) (*Server, error) {
+	if options.Listen == "" {
+		return nil, E.New("missing listen address")
+	}
+	server := &Server{
+		ctx:       ctx,
+		logger:    logger,
+		listen:    options.Listen,
+		path:      options.Path,
+		router:    service.FromContext[adapter.Router](ctx),
+		dnsRouter: service.FromContext[adapter.DNSRouter](ctx),
+		outbound:  service.FromContext[adapter.OutboundManager](ctx),
+		history:   service.PtrFromContext[urltest.HistoryStorage](ctx),
+		startedAt: time.Now(),
+	}
+	if server.path == "" {
+		server.path = "/metrics"
+	}
+	server.httpServer = &http.Server{
+		Handler: server,
+	}
+	return server, nil
+}
+
+func (s *Server) Name() string {
+	return "metrics server"
+}
+
+func (s *Server) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStatePostStart {
+		return nil
+	}
+	listener, err := net.Listen("tcp", s.listen)
+	if err != nil {
+		return E.Cause(err, "metrics server listen error")
+	}
+	s.logger.Info("metrics server started at ", listener.Addr())
+	go func() {
+		err = s.httpServer.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("metrics server serve error: ", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Close() error {
+	return common.Close(
+		common.PtrOrNil(s.httpServer),
+	)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != s.path {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	var content writer
+	s.writeMetrics(&content)
+	w.Header().Set("Content-Type", contentType)
+	w.Write(content.Bytes())
+}
+
+func (s *Server) writeMetrics(w *writer) {
+	w.family("sing_box_info", "gauge", "Version information of sing-box.")
+	w.sample("sing_box_info", 1, label{"version", C.Version})
+	w.family("process_start_time_seconds", "gauge", "Start time of the process since unix epoch in seconds.")
+	w.sample("process_start_time_seconds", float64(s.startedAt.UnixMilli())/1000)
+	s.inbounds.write(w, "inbound")
+	s.outbounds.write(w, "outbound")
+	s.rules.write(w, "rule")
+	s.writeURLTest(w)
+	s.dns.write(w)
+	s.writeDNSCache(w)
+	s.writeRuleSets(w)
+	writeRuntime(w)
+}
+
+func (s *Server) writeURLTest(w *writer) {
+	if s.history == nil || s.outbound == nil {
+		return
+	}
+	var (
+		tags      []string
+		histories []*adapter.URLTestHistory
+	)
+	for _, outbound := range s.outbound.Outbounds() {
+		history := s.history.LoadURLTestHistory(outbound.Tag())
+		if history == nil {
+			continue
+		}
+		tags = append(tags, outbound.Tag())
+		histories = append(histories, history)
+	}
+	w.family("sing_box_outbound_delay_milliseconds", "gauge", "Last URL test delay of outbound.")
+	for i, history := range histories {
+		w.sample("sing_box_outbound_delay_milliseconds", float64(history.Delay), label{"outbound", tags[i]})
+	}
+	w.family("sing_box_outbound_health_score", "gauge", "Health score of outbound from URL tests and real traffic.")
+	for i, history := range histories {
+		w.sample("sing_box_outbound_health_score", float64(history.Score), label{"outbound", tags[i]})
+	}
+	w.family("sing_box_outbound_last_test_timestamp_seconds", "gauge", "Time of the last URL test of outbound since unix epoch in seconds.")
+	for i, history := range histories {
+		w.sample("sing_box_outbound_last_test_timestamp_seconds", float64(history.Time.Unix()), label{"outbound", tags[i]})
+	}
+}
+
+func (s *Server) writeRuleSets(w *writer) {
+	if s.router == nil {
+		return
+	}
+	var ruleSets []adapter.RemoteRuleSet
+	for _, ruleSet := range s.router.RuleSets() {
+		if remoteRuleSet, isRemote := ruleSet.(adapter.RemoteRuleSet); isRemote {
+			ruleSets = append(ruleSets, remoteRuleSet)
+		}
+	}
+	w.family("sing_box_rule_set_last_update_timestamp_seconds", "gauge", "Time of the last successful rule-set update since unix epoch in seconds.")
+	for _, ruleSet := range ruleSets {
+		lastUpdated := ruleSet.LastUpdated()
+		if lastUpdated.IsZero() {
+			continue
+		}
+		w.sample("sing_box_rule_set_last_update_timestamp_seconds", float64(lastUpdated.Unix()), label{"rule_set", ruleSet.Name()})
+	}
+	w.family("sing_box_rule_set_update_success", "gauge", "Whether the last rule-set update succeeded.")
+	for _, ruleSet := range ruleSets {
+		var success float64
+		if ruleSet.LastUpdateError() == nil {
+			success = 1
+		}
+		w.sample("sing_box_rule_set_update_success", success, label{"rule_set", ruleSet.Name()})
+	}
+}
+
+func writeRuntime(w *writer) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	w.family("go_goroutines", "gauge", "Number of goroutines that currently exist.")
+	w.sample("go_goroutines", float64(runtime.NumGoroutine()))
+	w.family("go_memstats_alloc_bytes", "gauge", "Number of bytes allocated and still in use.")
+	w.sample("go_memstats_alloc_bytes", float64(memStats.Alloc))
+	w.family("go_memstats_heap_inuse_bytes", "gauge", "Number of heap bytes that are in use.")
+	w.sample("go_memstats_heap_inuse_bytes", float64(memStats.HeapInuse))
+	w.family("go_memstats_sys_bytes", "gauge", "Number of bytes obtained from system.")
+	w.sample("go_memstats_sys_bytes", float64(memStats.Sys))
+	w.family("go_gc_cycles_total", "counter", "Number of completed GC cycles.")
+	w.sample("go_gc_cycles_total", float64(memStats.NumGC))
+	w.family("go_gc_pause_seconds_total", "counter", "Total time spent in GC stop-the-world pauses.")
+	w.sample("go_gc_pause_seconds_total", time.Duration(memStats.PauseTotalNs).Seconds())
+}
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing/common/bufio"
+	F "github.com/sagernet/sing/common/format"
+	N "github.com/sagernet/sing/common/network"
+)
+
+type trafficCounter struct {
+	connections atomic.Uint64
+	active      atomic.Int64
+	upload      atomic.Uint64
+	download    atomic.Uint64
+}
+
+func (c *trafficCounter) addUpload(n int64) {
+	c.upload.Add(uint64(n))
+}
+
+func (c *trafficCounter) addDownload(n int64) {
+	c.download.Add(uint64(n))
+}
+
+type trafficCounters struct {
+	access   sync.Mutex
+	counters map[string]*trafficCounter
+}
+
+func (c *trafficCounters) load(name string) *trafficCounter {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.counters == nil {
+		c.counters = make(map[string]*trafficCounter)
+	}
+	counter, loaded := c.counters[name]
+	if !loaded {
+		counter = new(trafficCounter)
+		c.counters[name] = counter
+	}
+	return counter
+}
+
+func (c *trafficCounters) write(w *writer, kind string) {
+	c.access.Lock()
+	names := make([]string, 0, len(c.counters))
+	for name := range c.counters {
+		names = append(names, name)
+	}
+	counters := make([]*trafficCounter, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		counters = append(counters, c.counters[name])
+	}
+	c.access.Unlock()
+	prefix := "sing_box_" + kind + "_"
+	w.family(prefix+"connections_total", "counter", "Total connections routed by "+kind+".")
+	for i, counter := range counters {
+		w.sample(prefix+"connections_total", float64(counter.connections.Load()), label{kind, names[i]})
+	}
+	w.family(prefix+"active_connections", "gauge", "Open connections routed by "+kind+".")
+	for i, counter := range counters {
+		w.sample(prefix+"active_connections", float64(counter.active.Load()), label{kind, names[i]})
+	}
+	w.family(prefix+"upload_bytes_total", "counter", "Bytes sent by clients, by "+kind+".")
+	for i, counter := range counters {
+		w.sample(prefix+"upload_bytes_total", float64(counter.upload.Load()), label{kind, names[i]})
+	}
+	w.family(prefix+"download_bytes_total", "counter", "Bytes received by clients, by "+kind+".")
+	for i, counter := range counters {
+		w.sample(prefix+"download_bytes_total", float64(counter.download.Load()), label{kind, names[i]})
+	}
+}
+
+func (s *Server) routedCounters(metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) []*trafficCounter {
+	var counters []*trafficCounter
+	if metadata.Inbound != "" {
+		counters = append(counters, s.inbounds.load(metadata.Inbound))
+	}
+	counters = append(counters, s.outbounds.load(matchOutbound.Tag()))
+	var rule string
+	if matchedRule != nil {
+		rule = F.ToString(matchedRule, " => ", matchedRule.Action())
+	} else {
+		rule = "final"
+	}
+	counters = append(counters, s.rules.load(rule))
+	for _, counter := range counters {
+		counter.connections.Add(1)
+		counter.active.Add(1)
+	}
+	return counters
+}
+
+func countFuncs(counters []*trafficCounter) (upload []N.CountFunc, download []N.CountFunc) {
+	for _, counter := range counters {
+		upload = append(upload, counter.addUpload)
+		download = append(download, counter.addDownload)
+	}
+	return
+}
+
+func (s *Server) RoutedConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) net.Conn {
+	counters := s.routedCounters(metadata, matchedRule, matchOutbound)
+	upload, download := countFuncs(counters)
+	return &trackedConn{
+		Conn:     bufio.NewCounterConn(conn, upload, download),
+		counters: counters,
+	}
+}
+
+func (s *Server) RoutedPacketConnection(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, matchedRule adapter.Rule, matchOutbound adapter.Outbound) N.PacketConn {
+	counters := s.routedCounters(metadata, matchedRule, matchOutbound)
+	upload, download := countFuncs(counters)
+	return &trackedPacketConn{
+		PacketConn: bufio.NewCounterPacketConn(conn, upload, download),
+		counters:   counters,
+	}
+}
+
+func closeCounters(counters []*trafficCounter) {
+	for _, counter := range counters {
+		counter.active.Add(-1)
+	}
+}
+
+type trackedConn struct {
+	net.Conn
+	counters  []*trafficCounter
+	closeOnce sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() {
+		closeCounters(c.counters)
+	})
+	return c.Conn.Close()
+}
+
+func (c *trackedConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *trackedConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *trackedConn) Upstream() any {
+	return c.Conn
+}
+
+type trackedPacketConn struct {
+	N.PacketConn
+	counters  []*trafficCounter
+	closeOnce sync.Once
+}
+
+func (c *trackedPacketConn) Close() error {
+	c.closeOnce.Do(func() {
+		closeCounters(c.counters)
+	})
+	return c.PacketConn.Close()
+}
+
+func (c *trackedPacketConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *trackedPacketConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *trackedPacketConn) Upstream() any {
+	return c.PacketConn
+}
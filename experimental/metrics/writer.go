@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+// writer renders metrics in the Prometheus text exposition format.
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) family(name string, metricType string, help string) {
+	w.WriteString("# HELP ")
+	w.WriteString(name)
+	w.WriteByte(' ')
+	helpEscaper.WriteString(w, help)
+	w.WriteString("\n# TYPE ")
+	w.WriteString(name)
+	w.WriteByte(' ')
+	w.WriteString(metricType)
+	w.WriteByte('\n')
+}
+
+func (w *writer) sample(name string, value float64, labels ...label) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, it := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(it.name)
+			w.WriteString(`="`)
+			labelValueEscaper.WriteString(w, it.value)
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
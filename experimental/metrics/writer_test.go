@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+	var w writer
+	w.family("test_total", "counter", "Test\nhelp \\ text.")
+	w.sample("test_total", 42, label{"rule", `domain="a\b"` + "\n"}, label{"le", "+Inf"})
+	w.sample("test_total", 0.25)
+	w.sample("test_total", math.Inf(1))
+	require.Equal(t, `# HELP test_total Test\nhelp \\ text.
+# TYPE test_total counter
+test_total{rule="domain=\"a\\b\"\n",le="+Inf"} 42
+test_total 0.25
+test_total +Inf
+`, w.String())
+}
@@ -8,6 +8,7 @@ import (
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/experimental/deprecated"
 	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/byteformats"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/json/badjson"
@@ -19,10 +20,11 @@ import (
 )
 
 type RawDNSOptions struct {
-	Servers        []DNSServerOptions `json:"servers,omitempty"`
-	Rules          []DNSRule          `json:"rules,omitempty"`
-	Final          string             `json:"final,omitempty"`
-	ReverseMapping bool               `json:"reverse_mapping,omitempty"`
+	Servers        []DNSServerOptions  `json:"servers,omitempty"`
+	Rules          []DNSRule           `json:"rules,omitempty"`
+	Final          string              `json:"final,omitempty"`
+	ReverseMapping bool                `json:"reverse_mapping,omitempty"`
+	QueryLog       *DNSQueryLogOptions `json:"query_log,omitempty"`
 	DNSClientOptions
 }
 
@@ -107,8 +109,41 @@ type DNSClientOptions struct {
 	DisableCache     bool                  `json:"disable_cache,omitempty"`
 	DisableExpire    bool                  `json:"disable_expire,omitempty"`
 	IndependentCache bool                  `json:"independent_cache,omitempty"`
+	ServeStale       bool                  `json:"serve_stale,omitempty"`
+	ServeStaleWindow badoption.Duration    `json:"serve_stale_window,omitempty"`
+	Prefetch         bool                  `json:"prefetch,omitempty"`
 	CacheCapacity    uint32                `json:"cache_capacity,omitempty"`
 	ClientSubnet     *badoption.Prefixable `json:"client_subnet,omitempty"`
+	DNSSEC           *DNSSECOptions        `json:"dnssec,omitempty"`
+}
+
+type _DNSSECOptions struct {
+	Enabled     bool                                 `json:"enabled,omitempty"`
+	TrustAnchor badoption.Listable[DNSRecordOptions] `json:"trust_anchor,omitempty"`
+}
+
+type DNSSECOptions _DNSSECOptions
+
+func (o *DNSSECOptions) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, (*_DNSSECOptions)(o))
+	if err != nil {
+		return err
+	}
+	for _, anchor := range o.TrustAnchor {
+		switch anchor.RR.(type) {
+		case *dns.DS, *dns.DNSKEY:
+		default:
+			return E.New("trust anchor must be a DS or DNSKEY record: ", anchor.RR.String())
+		}
+	}
+	return nil
+}
+
+type DNSQueryLogOptions struct {
+	Enabled    bool               `json:"enabled,omitempty"`
+	MaxEntries uint32             `json:"max_entries,omitempty"`
+	Path       string             `json:"path,omitempty"`
+	MaxSize    *byteformats.Bytes `json:"max_size,omitempty"`
 }
 
 type LegacyDNSFakeIPOptions struct {
@@ -365,6 +400,26 @@ type HostsDNSServerOptions struct {
 	Predefined *badjson.TypedMap[string, badoption.Listable[netip.Addr]] `json:"predefined,omitempty"`
 }
 
+type GroupDNSServerOptions struct {
+	Servers  badoption.Listable[string] `json:"servers,omitempty"`
+	Strategy string                     `json:"strategy,omitempty"`
+	Timeout  badoption.Duration         `json:"timeout,omitempty"`
+}
+
+type DNSCryptDNSServerOptions struct {
+	DialerOptions
+	Stamp string `json:"stamp"`
+	Relay string `json:"relay,omitempty"`
+}
+
+type ODoHDNSServerOptions struct {
+	DialerOptions
+	Stamp    string              `json:"stamp"`
+	Relay    string              `json:"relay,omitempty"`
+	RelayTLS *OutboundTLSOptions `json:"relay_tls,omitempty"`
+	OutboundTLSOptionsContainer
+}
+
 type RawLocalDNSServerOptions struct {
 	DialerOptions
 	Legacy              bool           `json:"-"`
@@ -0,0 +1,11 @@
+package option
+
+type DNSInboundOptions struct {
+	ListenOptions
+	Network    NetworkList `json:"network,omitempty"`
+	Protocol   string      `json:"protocol,omitempty"`
+	Path       string      `json:"path,omitempty"`
+	QueryLimit int         `json:"query_limit,omitempty"`
+	QueryBurst int         `json:"query_burst,omitempty"`
+	InboundTLSOptionsContainer
+}
@@ -3,10 +3,12 @@ package option
 import "github.com/sagernet/sing/common/json/badoption"
 
 type ExperimentalOptions struct {
-	CacheFile *CacheFileOptions `json:"cache_file,omitempty"`
-	ClashAPI  *ClashAPIOptions  `json:"clash_api,omitempty"`
-	V2RayAPI  *V2RayAPIOptions  `json:"v2ray_api,omitempty"`
-	Debug     *DebugOptions     `json:"debug,omitempty"`
+	CacheFile        *CacheFileOptions        `json:"cache_file,omitempty"`
+	ClashAPI         *ClashAPIOptions         `json:"clash_api,omitempty"`
+	V2RayAPI         *V2RayAPIOptions         `json:"v2ray_api,omitempty"`
+	Metrics          *MetricsOptions          `json:"metrics,omitempty"`
+	ConnectionExport *ConnectionExportOptions `json:"connection_export,omitempty"`
+	Debug            *DebugOptions            `json:"debug,omitempty"`
 }
 
 type CacheFileOptions struct {
@@ -16,6 +18,7 @@ type CacheFileOptions struct {
 	StoreFakeIP bool               `json:"store_fakeip,omitempty"`
 	StoreRDRC   bool               `json:"store_rdrc,omitempty"`
 	RDRCTimeout badoption.Duration `json:"rdrc_timeout,omitempty"`
+	StoreDNS    bool               `json:"store_dns,omitempty"`
 }
 
 type ClashAPIOptions struct {
@@ -52,3 +55,17 @@ type V2RayStatsServiceOptions struct {
 	Outbounds []string `json:"outbounds,omitempty"`
 	Users     []string `json:"users,omitempty"`
 }
+
+type MetricsOptions struct {
+	Listen string `json:"listen,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+type ConnectionExportOptions struct {
+	Type          string               `json:"type,omitempty"`
+	Path          string               `json:"path,omitempty"`
+	URL           string               `json:"url,omitempty"`
+	Headers       badoption.HTTPHeader `json:"headers,omitempty"`
+	Detour        string               `json:"detour,omitempty"`
+	FlushInterval badoption.Duration   `json:"flush_interval,omitempty"`
+}
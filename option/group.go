@@ -4,15 +4,34 @@ import "github.com/sagernet/sing/common/json/badoption"
 
 type SelectorOutboundOptions struct {
 	Outbounds                 []string `json:"outbounds"`
+	Providers                 []string `json:"providers,omitempty"`
 	Default                   string   `json:"default,omitempty"`
 	InterruptExistConnections bool     `json:"interrupt_exist_connections,omitempty"`
 }
 
 type URLTestOutboundOptions struct {
 	Outbounds                 []string           `json:"outbounds"`
+	Providers                 []string           `json:"providers,omitempty"`
 	URL                       string             `json:"url,omitempty"`
 	Interval                  badoption.Duration `json:"interval,omitempty"`
 	Tolerance                 uint16             `json:"tolerance,omitempty"`
 	IdleTimeout               badoption.Duration `json:"idle_timeout,omitempty"`
 	InterruptExistConnections bool               `json:"interrupt_exist_connections,omitempty"`
 }
+
+type LoadBalanceOutboundOptions struct {
+	Outbounds   []string           `json:"outbounds"`
+	Strategy    string             `json:"strategy,omitempty"`
+	URL         string             `json:"url,omitempty"`
+	Interval    badoption.Duration `json:"interval,omitempty"`
+	IdleTimeout badoption.Duration `json:"idle_timeout,omitempty"`
+}
+
+type FallbackOutboundOptions struct {
+	Outbounds                 []string           `json:"outbounds"`
+	URL                       string             `json:"url,omitempty"`
+	Interval                  badoption.Duration `json:"interval,omitempty"`
+	IdleTimeout               badoption.Duration `json:"idle_timeout,omitempty"`
+	RecoveryDelay             badoption.Duration `json:"recovery_delay,omitempty"`
+	InterruptExistConnections bool               `json:"interrupt_exist_connections,omitempty"`
+}
@@ -28,8 +28,12 @@ type Hysteria2Obfs struct {
 }
 
 type Hysteria2User struct {
-	Name     string `json:"name,omitempty"`
-	Password string `json:"password,omitempty"`
+	Name           string               `json:"name,omitempty"`
+	Password       string               `json:"password,omitempty"`
+	SpeedLimit     *SpeedLimitOptions   `json:"speed_limit,omitempty"`
+	TrafficQuota   *TrafficQuotaOptions `json:"traffic_quota,omitempty"`
+	MaxConnections int                  `json:"max_connections,omitempty"`
+	MaxDevices     int                  `json:"max_devices,omitempty"`
 }
 
 type _Hysteria2Masquerade struct {
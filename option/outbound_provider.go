@@ -0,0 +1,12 @@
+package option
+
+import "github.com/sagernet/sing/common/json/badoption"
+
+type OutboundProvider struct {
+	Tag            string                     `json:"tag"`
+	URL            string                     `json:"url"`
+	DownloadDetour string                     `json:"download_detour,omitempty"`
+	UpdateInterval badoption.Duration         `json:"update_interval,omitempty"`
+	Filter         badoption.Listable[string] `json:"filter,omitempty"`
+	Exclude        badoption.Listable[string] `json:"exclude,omitempty"`
+}
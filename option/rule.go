@@ -73,6 +73,11 @@ type RawDefaultRule struct {
 	AuthUser                 badoption.Listable[string]                                                  `json:"auth_user,omitempty"`
 	Protocol                 badoption.Listable[string]                                                  `json:"protocol,omitempty"`
 	Client                   badoption.Listable[string]                                                  `json:"client,omitempty"`
+	ClientJA3                badoption.Listable[string]                                                  `json:"client_ja3,omitempty"`
+	ClientJA4                badoption.Listable[string]                                                  `json:"client_ja4,omitempty"`
+	HTTPMethod               badoption.Listable[string]                                                  `json:"http_method,omitempty"`
+	HTTPPathRegex            badoption.Listable[string]                                                  `json:"http_path_regex,omitempty"`
+	HTTPUserAgent            badoption.Listable[string]                                                  `json:"http_user_agent,omitempty"`
 	Domain                   badoption.Listable[string]                                                  `json:"domain,omitempty"`
 	DomainSuffix             badoption.Listable[string]                                                  `json:"domain_suffix,omitempty"`
 	DomainKeyword            badoption.Listable[string]                                                  `json:"domain_keyword,omitempty"`
@@ -82,8 +87,12 @@ type RawDefaultRule struct {
 	GeoIP                    badoption.Listable[string]                                                  `json:"geoip,omitempty"`
 	SourceIPCIDR             badoption.Listable[string]                                                  `json:"source_ip_cidr,omitempty"`
 	SourceIPIsPrivate        bool                                                                        `json:"source_ip_is_private,omitempty"`
+	SourceIPASN              badoption.Listable[uint32]                                                  `json:"source_ip_asn,omitempty"`
+	SourceMACAddress         badoption.Listable[string]                                                  `json:"source_mac_address,omitempty"`
+	SourceHostname           badoption.Listable[string]                                                  `json:"source_hostname,omitempty"`
 	IPCIDR                   badoption.Listable[string]                                                  `json:"ip_cidr,omitempty"`
 	IPIsPrivate              bool                                                                        `json:"ip_is_private,omitempty"`
+	IPASN                    badoption.Listable[uint32]                                                  `json:"ip_asn,omitempty"`
 	SourcePort               badoption.Listable[uint16]                                                  `json:"source_port,omitempty"`
 	SourcePortRange          badoption.Listable[string]                                                  `json:"source_port_range,omitempty"`
 	Port                     badoption.Listable[uint16]                                                  `json:"port,omitempty"`
@@ -103,6 +112,7 @@ type RawDefaultRule struct {
 	InterfaceAddress         *badjson.TypedMap[string, badoption.Listable[*badoption.Prefixable]]        `json:"interface_address,omitempty"`
 	NetworkInterfaceAddress  *badjson.TypedMap[InterfaceType, badoption.Listable[*badoption.Prefixable]] `json:"network_interface_address,omitempty"`
 	DefaultInterfaceAddress  badoption.Listable[*badoption.Prefixable]                                   `json:"default_interface_address,omitempty"`
+	TimeRange                *TimeRangeOptions                                                           `json:"time_range,omitempty"`
 	PreferredBy              badoption.Listable[string]                                                  `json:"preferred_by,omitempty"`
 	RuleSet                  badoption.Listable[string]                                                  `json:"rule_set,omitempty"`
 	RuleSetIPCIDRMatchSource bool                                                                        `json:"rule_set_ip_cidr_match_source,omitempty"`
@@ -161,3 +171,10 @@ func (r *LogicalRule) UnmarshalJSON(data []byte) error {
 func (r *LogicalRule) IsValid() bool {
 	return len(r.Rules) > 0 && common.All(r.Rules, Rule.IsValid)
 }
+
+type TimeRangeOptions struct {
+	Timezone string                     `json:"timezone,omitempty"`
+	Weekday  badoption.Listable[string] `json:"weekday,omitempty"`
+	Start    string                     `json:"start,omitempty"`
+	End      string                     `json:"end,omitempty"`
+}
@@ -6,6 +6,7 @@ import (
 	"net/netip"
 	"time"
 
+	"github.com/sagernet/sing-box/common/dns64"
 	C "github.com/sagernet/sing-box/constant"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/json"
@@ -102,6 +103,7 @@ type _DNSRuleAction struct {
 	RouteOptionsOptions DNSRouteOptionsActionOptions `json:"-"`
 	RejectOptions       RejectActionOptions          `json:"-"`
 	PredefinedOptions   DNSRouteActionPredefined     `json:"-"`
+	RewriteOptions      DNSRouteActionRewrite        `json:"-"`
 }
 
 type DNSRuleAction _DNSRuleAction
@@ -121,6 +123,8 @@ func (r DNSRuleAction) MarshalJSON() ([]byte, error) {
 		v = r.RejectOptions
 	case C.RuleActionTypePredefined:
 		v = r.PredefinedOptions
+	case C.RuleActionTypeRewrite:
+		v = r.RewriteOptions
 	default:
 		return nil, E.New("unknown DNS rule action: " + r.Action)
 	}
@@ -143,6 +147,8 @@ func (r *DNSRuleAction) UnmarshalJSONContext(ctx context.Context, data []byte) e
 		v = &r.RejectOptions
 	case C.RuleActionTypePredefined:
 		v = &r.PredefinedOptions
+	case C.RuleActionTypeRewrite:
+		v = &r.RewriteOptions
 	default:
 		return E.New("unknown DNS rule action: " + r.Action)
 	}
@@ -168,6 +174,9 @@ type RawRouteOptionsActionOptions struct {
 	TLSFragment              bool               `json:"tls_fragment,omitempty"`
 	TLSFragmentFallbackDelay badoption.Duration `json:"tls_fragment_fallback_delay,omitempty"`
 	TLSRecordFragment        bool               `json:"tls_record_fragment,omitempty"`
+
+	SpeedLimit     *SpeedLimitOptions `json:"speed_limit,omitempty"`
+	MaxConnections int                `json:"max_connections,omitempty"`
 }
 
 type RouteOptionsActionOptions RawRouteOptionsActionOptions
@@ -187,18 +196,42 @@ func (r *RouteOptionsActionOptions) UnmarshalJSON(data []byte) error {
 }
 
 type DNSRouteActionOptions struct {
-	Server       string                `json:"server,omitempty"`
-	Strategy     DomainStrategy        `json:"strategy,omitempty"`
-	DisableCache bool                  `json:"disable_cache,omitempty"`
-	RewriteTTL   *uint32               `json:"rewrite_ttl,omitempty"`
-	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
+	Server         string                `json:"server,omitempty"`
+	Strategy       DomainStrategy        `json:"strategy,omitempty"`
+	DisableCache   bool                  `json:"disable_cache,omitempty"`
+	RewriteTTL     *uint32               `json:"rewrite_ttl,omitempty"`
+	ClientSubnet   *badoption.Prefixable `json:"client_subnet,omitempty"`
+	ValidateDNSSEC bool                  `json:"validate_dnssec,omitempty"`
+	DNS64          *DNS64Options         `json:"dns64,omitempty"`
+	FastestIP      *FastestIPOptions     `json:"fastest_ip,omitempty"`
+}
+
+type _DNS64Options struct {
+	Enabled bool              `json:"enabled,omitempty"`
+	Prefix  *badoption.Prefix `json:"prefix,omitempty"`
+}
+
+type DNS64Options _DNS64Options
+
+func (o *DNS64Options) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, (*_DNS64Options)(o))
+	if err != nil {
+		return err
+	}
+	prefix := o.Prefix.Build(netip.Prefix{})
+	if o.Prefix != nil && !dns64.ValidPrefix(prefix) {
+		return E.New("invalid DNS64 prefix: ", prefix, ", length must be one of 32, 40, 48, 56, 64 or 96")
+	}
+	return nil
 }
 
 type _DNSRouteOptionsActionOptions struct {
-	Strategy     DomainStrategy        `json:"strategy,omitempty"`
-	DisableCache bool                  `json:"disable_cache,omitempty"`
-	RewriteTTL   *uint32               `json:"rewrite_ttl,omitempty"`
-	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
+	Strategy       DomainStrategy        `json:"strategy,omitempty"`
+	DisableCache   bool                  `json:"disable_cache,omitempty"`
+	RewriteTTL     *uint32               `json:"rewrite_ttl,omitempty"`
+	ClientSubnet   *badoption.Prefixable `json:"client_subnet,omitempty"`
+	ValidateDNSSEC bool                  `json:"validate_dnssec,omitempty"`
+	FastestIP      *FastestIPOptions     `json:"fastest_ip,omitempty"`
 }
 
 type DNSRouteOptionsActionOptions _DNSRouteOptionsActionOptions
@@ -312,6 +345,15 @@ type RouteActionResolve struct {
 	DisableCache bool                  `json:"disable_cache,omitempty"`
 	RewriteTTL   *uint32               `json:"rewrite_ttl,omitempty"`
 	ClientSubnet *badoption.Prefixable `json:"client_subnet,omitempty"`
+	FastestIP    *FastestIPOptions     `json:"fastest_ip,omitempty"`
+}
+
+type FastestIPOptions struct {
+	Enabled      bool               `json:"enabled,omitempty"`
+	Method       string             `json:"method,omitempty"`
+	Port         uint16             `json:"port,omitempty"`
+	Timeout      badoption.Duration `json:"timeout,omitempty"`
+	MaxAddresses int                `json:"max_addresses,omitempty"`
 }
 
 type DNSRouteActionPredefined struct {
@@ -320,3 +362,55 @@ type DNSRouteActionPredefined struct {
 	Ns     badoption.Listable[DNSRecordOptions] `json:"ns,omitempty"`
 	Extra  badoption.Listable[DNSRecordOptions] `json:"extra,omitempty"`
 }
+
+type _DNSRouteActionRewrite struct {
+	DropType badoption.Listable[DNSQueryType] `json:"drop_type,omitempty"`
+	StripECH bool                             `json:"strip_ech,omitempty"`
+	// answer filters are prefixed to avoid conflicts with rule items
+	AnswerIPCIDR  badoption.Listable[string]                   `json:"answer_ip_cidr,omitempty"`
+	AnswerRuleSet badoption.Listable[string]                   `json:"answer_rule_set,omitempty"`
+	AnswerInvert  bool                                         `json:"answer_invert,omitempty"`
+	MinTTL        uint32                                       `json:"min_ttl,omitempty"`
+	MaxTTL        uint32                                       `json:"max_ttl,omitempty"`
+	Replace       badoption.Listable[DNSRewriteReplaceOptions] `json:"replace,omitempty"`
+}
+
+type DNSRouteActionRewrite _DNSRouteActionRewrite
+
+func (r *DNSRouteActionRewrite) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, (*_DNSRouteActionRewrite)(r))
+	if err != nil {
+		return err
+	}
+	if len(r.DropType) == 0 && !r.StripECH && len(r.AnswerIPCIDR) == 0 && len(r.AnswerRuleSet) == 0 && r.MinTTL == 0 && r.MaxTTL == 0 && len(r.Replace) == 0 {
+		return E.New("empty rewrite action")
+	}
+	if r.AnswerInvert && len(r.AnswerIPCIDR) == 0 && len(r.AnswerRuleSet) == 0 {
+		return E.New("`answer_invert` requires `answer_ip_cidr` or `answer_rule_set`")
+	}
+	if r.MaxTTL > 0 && r.MinTTL > r.MaxTTL {
+		return E.New("`min_ttl` must not be greater than `max_ttl`")
+	}
+	return nil
+}
+
+type _DNSRewriteReplaceOptions struct {
+	From *badoption.Prefixable `json:"from,omitempty"`
+	To   *badoption.Addr       `json:"to,omitempty"`
+}
+
+type DNSRewriteReplaceOptions _DNSRewriteReplaceOptions
+
+func (o *DNSRewriteReplaceOptions) UnmarshalJSON(data []byte) error {
+	err := json.Unmarshal(data, (*_DNSRewriteReplaceOptions)(o))
+	if err != nil {
+		return err
+	}
+	if o.From == nil || o.To == nil {
+		return E.New("missing `from` or `to` in replace")
+	}
+	if o.From.Build(netip.Prefix{}).Addr().Is4() != o.To.Build(netip.Addr{}).Is4() {
+		return E.New("`from` and `to` in replace must be of the same address family")
+	}
+	return nil
+}
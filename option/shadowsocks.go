@@ -12,8 +12,12 @@ type ShadowsocksInboundOptions struct {
 }
 
 type ShadowsocksUser struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
+	Name           string               `json:"name"`
+	Password       string               `json:"password"`
+	SpeedLimit     *SpeedLimitOptions   `json:"speed_limit,omitempty"`
+	TrafficQuota   *TrafficQuotaOptions `json:"traffic_quota,omitempty"`
+	MaxConnections int                  `json:"max_connections,omitempty"`
+	MaxDevices     int                  `json:"max_devices,omitempty"`
 }
 
 type ShadowsocksDestination struct {
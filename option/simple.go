@@ -1,24 +1,132 @@
 package option
 
 import (
+	"context"
+
 	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/common/json"
 	"github.com/sagernet/sing/common/json/badoption"
 )
 
 type SocksInboundOptions struct {
 	ListenOptions
-	Users          []auth.User           `json:"users,omitempty"`
+	Users []auth.User `json:"users,omitempty"`
+	// UserOptions is parallel to Users and read from the same users entries.
+	UserOptions    []AuthUserOptions     `json:"-"`
 	DomainResolver *DomainResolveOptions `json:"domain_resolver,omitempty"`
 }
 
+type _SocksInboundOptions SocksInboundOptions
+
+func (o SocksInboundOptions) MarshalJSONContext(ctx context.Context) ([]byte, error) {
+	return json.MarshalContext(ctx, struct {
+		_SocksInboundOptions
+		Users []authUser `json:"users,omitempty"`
+	}{_SocksInboundOptions(o), mergeAuthUsers(o.Users, o.UserOptions)})
+}
+
+func (o *SocksInboundOptions) UnmarshalJSONContext(ctx context.Context, content []byte) error {
+	var options struct {
+		*_SocksInboundOptions
+		Users []authUser `json:"users,omitempty"`
+	}
+	options._SocksInboundOptions = (*_SocksInboundOptions)(o)
+	err := json.UnmarshalContextDisallowUnknownFields(ctx, content, &options)
+	if err != nil {
+		return err
+	}
+	o.Users, o.UserOptions = splitAuthUsers(options.Users)
+	return nil
+}
+
+// UserOption returns the options of the user at index.
+func (o *SocksInboundOptions) UserOption(index int) AuthUserOptions {
+	return authUserOption(o.UserOptions, index)
+}
+
+type AuthUserOptions struct {
+	SpeedLimit     *SpeedLimitOptions   `json:"speed_limit,omitempty"`
+	TrafficQuota   *TrafficQuotaOptions `json:"traffic_quota,omitempty"`
+	MaxConnections int                  `json:"max_connections,omitempty"`
+	MaxDevices     int                  `json:"max_devices,omitempty"`
+}
+
+func authUserOption(userOptions []AuthUserOptions, index int) AuthUserOptions {
+	if index < len(userOptions) {
+		return userOptions[index]
+	}
+	return AuthUserOptions{}
+}
+
+type authUser struct {
+	auth.User
+	AuthUserOptions
+}
+
+func mergeAuthUsers(users []auth.User, userOptions []AuthUserOptions) []authUser {
+	merged := make([]authUser, 0, len(users))
+	for index, user := range users {
+		merged = append(merged, authUser{user, authUserOption(userOptions, index)})
+	}
+	return merged
+}
+
+func splitAuthUsers(users []authUser) ([]auth.User, []AuthUserOptions) {
+	if len(users) == 0 {
+		return nil, nil
+	}
+	authUsers := make([]auth.User, 0, len(users))
+	var userOptions []AuthUserOptions
+	for index, user := range users {
+		authUsers = append(authUsers, user.User)
+		if user.AuthUserOptions != (AuthUserOptions{}) {
+			if userOptions == nil {
+				userOptions = make([]AuthUserOptions, len(users))
+			}
+			userOptions[index] = user.AuthUserOptions
+		}
+	}
+	return authUsers, userOptions
+}
+
 type HTTPMixedInboundOptions struct {
 	ListenOptions
-	Users          []auth.User           `json:"users,omitempty"`
+	Users []auth.User `json:"users,omitempty"`
+	// UserOptions is parallel to Users and read from the same users entries.
+	UserOptions    []AuthUserOptions     `json:"-"`
 	DomainResolver *DomainResolveOptions `json:"domain_resolver,omitempty"`
 	SetSystemProxy bool                  `json:"set_system_proxy,omitempty"`
 	InboundTLSOptionsContainer
 }
 
+// UserOption returns the options of the user at index.
+func (o *HTTPMixedInboundOptions) UserOption(index int) AuthUserOptions {
+	return authUserOption(o.UserOptions, index)
+}
+
+type _HTTPMixedInboundOptions HTTPMixedInboundOptions
+
+func (o HTTPMixedInboundOptions) MarshalJSONContext(ctx context.Context) ([]byte, error) {
+	return json.MarshalContext(ctx, struct {
+		_HTTPMixedInboundOptions
+		Users []authUser `json:"users,omitempty"`
+	}{_HTTPMixedInboundOptions(o), mergeAuthUsers(o.Users, o.UserOptions)})
+}
+
+func (o *HTTPMixedInboundOptions) UnmarshalJSONContext(ctx context.Context, content []byte) error {
+	var options struct {
+		*_HTTPMixedInboundOptions
+		Users []authUser `json:"users,omitempty"`
+	}
+	options._HTTPMixedInboundOptions = (*_HTTPMixedInboundOptions)(o)
+	err := json.UnmarshalContextDisallowUnknownFields(ctx, content, &options)
+	if err != nil {
+		return err
+	}
+	o.Users, o.UserOptions = splitAuthUsers(options.Users)
+	return nil
+}
+
 type SOCKSOutboundOptions struct {
 	DialerOptions
 	ServerOptions
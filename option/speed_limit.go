@@ -0,0 +1,8 @@
+package option
+
+import "github.com/sagernet/sing/common/byteformats"
+
+type SpeedLimitOptions struct {
+	Upload   *byteformats.NetworkBytesCompat `json:"upload,omitempty"`
+	Download *byteformats.NetworkBytesCompat `json:"download,omitempty"`
+}
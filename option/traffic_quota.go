@@ -0,0 +1,13 @@
+package option
+
+import (
+	"github.com/sagernet/sing/common/byteformats"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+type TrafficQuotaOptions struct {
+	Limit    *byteformats.Bytes `json:"limit"`
+	Period   string             `json:"period,omitempty"`
+	ResetDay int                `json:"reset_day,omitempty"`
+	Interval badoption.Duration `json:"interval,omitempty"`
+}
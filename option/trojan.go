@@ -11,8 +11,12 @@ type TrojanInboundOptions struct {
 }
 
 type TrojanUser struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
+	Name           string               `json:"name"`
+	Password       string               `json:"password"`
+	SpeedLimit     *SpeedLimitOptions   `json:"speed_limit,omitempty"`
+	TrafficQuota   *TrafficQuotaOptions `json:"traffic_quota,omitempty"`
+	MaxConnections int                  `json:"max_connections,omitempty"`
+	MaxDevices     int                  `json:"max_devices,omitempty"`
 }
 
 type TrojanOutboundOptions struct {
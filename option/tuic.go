@@ -13,9 +13,13 @@ type TUICInboundOptions struct {
 }
 
 type TUICUser struct {
-	Name     string `json:"name,omitempty"`
-	UUID     string `json:"uuid,omitempty"`
-	Password string `json:"password,omitempty"`
+	Name           string               `json:"name,omitempty"`
+	UUID           string               `json:"uuid,omitempty"`
+	Password       string               `json:"password,omitempty"`
+	SpeedLimit     *SpeedLimitOptions   `json:"speed_limit,omitempty"`
+	TrafficQuota   *TrafficQuotaOptions `json:"traffic_quota,omitempty"`
+	MaxConnections int                  `json:"max_connections,omitempty"`
+	MaxDevices     int                  `json:"max_devices,omitempty"`
 }
 
 type TUICOutboundOptions struct {
@@ -9,9 +9,13 @@ type VLESSInboundOptions struct {
 }
 
 type VLESSUser struct {
-	Name string `json:"name"`
-	UUID string `json:"uuid"`
-	Flow string `json:"flow,omitempty"`
+	Name           string               `json:"name"`
+	UUID           string               `json:"uuid"`
+	Flow           string               `json:"flow,omitempty"`
+	SpeedLimit     *SpeedLimitOptions   `json:"speed_limit,omitempty"`
+	TrafficQuota   *TrafficQuotaOptions `json:"traffic_quota,omitempty"`
+	MaxConnections int                  `json:"max_connections,omitempty"`
+	MaxDevices     int                  `json:"max_devices,omitempty"`
 }
 
 type VLESSOutboundOptions struct {
@@ -9,9 +9,13 @@ type VMessInboundOptions struct {
 }
 
 type VMessUser struct {
-	Name    string `json:"name"`
-	UUID    string `json:"uuid"`
-	AlterId int    `json:"alterId,omitempty"`
+	Name           string               `json:"name"`
+	UUID           string               `json:"uuid"`
+	AlterId        int                  `json:"alterId,omitempty"`
+	SpeedLimit     *SpeedLimitOptions   `json:"speed_limit,omitempty"`
+	TrafficQuota   *TrafficQuotaOptions `json:"traffic_quota,omitempty"`
+	MaxConnections int                  `json:"max_connections,omitempty"`
+	MaxDevices     int                  `json:"max_devices,omitempty"`
 }
 
 type VMessOutboundOptions struct {
@@ -161,6 +161,11 @@ func (h *Outbound) NewDirectRouteConnection(metadata adapter.InboundContext, rou
 	return destination, nil
 }
 
+// DialerForICMPDestination returns the dialer used to send ICMP probes to the destination.
+func (h *Outbound) DialerForICMPDestination(destination netip.Addr) net.Dialer {
+	return common.MustCast[*dialer.DefaultDialer](h.dialer).DialerForICMPDestination(destination)
+}
+
 func (h *Outbound) DialParallel(ctx context.Context, network string, destination M.Socksaddr, destinationAddresses []netip.Addr) (net.Conn, error) {
 	ctx, metadata := adapter.ExtendContext(ctx)
 	metadata.Outbound = h.Tag()
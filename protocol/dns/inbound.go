@@ -0,0 +1,350 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/tls"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/dns"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/common/buf"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+
+	mDNS "github.com/miekg/dns"
+)
+
+func RegisterInbound(registry *inbound.Registry) {
+	inbound.Register[option.DNSInboundOptions](registry, C.TypeDNS, NewInbound)
+}
+
+// QUICStreamHandler handles a DNS-over-QUIC stream carrying a single query.
+type QUICStreamHandler = func(ctx context.Context, stream io.ReadWriteCloser, source M.Socksaddr)
+
+// ListenQUICFunc serves DNS over QUIC, it is nil if QUIC is not included in this build.
+var ListenQUICFunc func(ctx context.Context, logger logger.ContextLogger, listener *listener.Listener, tlsConfig tls.ServerConfig, handler QUICStreamHandler) (io.Closer, error)
+
+// streamIdleTimeout closes TCP and DNS-over-TLS connections without queries.
+const streamIdleTimeout = 10 * time.Second
+
+var (
+	_ adapter.TCPInjectableInbound = (*Inbound)(nil)
+	_ adapter.PacketHandlerEx      = (*Inbound)(nil)
+)
+
+type Inbound struct {
+	inbound.Adapter
+	ctx          context.Context
+	router       adapter.DNSRouter
+	logger       logger.ContextLogger
+	listener     *listener.Listener
+	protocol     string
+	path         string
+	queryLimiter *queryLimiter
+	querySlots   querySlots
+	tlsConfig    tls.ServerConfig
+	httpServer   *http.Server
+	quicServer   io.Closer
+}
+
+func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.DNSInboundOptions) (adapter.Inbound, error) {
+	inbound := &Inbound{
+		Adapter:      inbound.NewAdapter(C.TypeDNS, tag),
+		ctx:          ctx,
+		router:       service.FromContext[adapter.DNSRouter](ctx),
+		logger:       logger,
+		protocol:     options.Protocol,
+		path:         options.Path,
+		queryLimiter: newQueryLimiter(options.QueryLimit, options.QueryBurst),
+		querySlots:   newQuerySlots(),
+	}
+	tlsEnabled := options.TLS != nil && options.TLS.Enabled
+	var network []string
+	switch options.Protocol {
+	case "":
+		if tlsEnabled {
+			return nil, E.New("TLS is only supported by tls, https and quic protocol")
+		}
+		network = options.Network.Build()
+	case C.DNSTypeTLS:
+		if !tlsEnabled {
+			return nil, E.New("TLS is required for DNS over TLS")
+		}
+		network = []string{N.NetworkTCP}
+	case C.DNSTypeHTTPS:
+		if inbound.path == "" {
+			inbound.path = "/dns-query"
+		}
+	case C.DNSTypeQUIC:
+		if !tlsEnabled {
+			return nil, E.New("TLS is required for DNS over QUIC")
+		}
+	default:
+		return nil, E.New("unknown protocol: ", options.Protocol)
+	}
+	if tlsEnabled {
+		tlsConfig, err := tls.NewServer(ctx, logger, common.PtrValueOrDefault(options.TLS))
+		if err != nil {
+			return nil, err
+		}
+		inbound.tlsConfig = tlsConfig
+	}
+	inbound.listener = listener.New(listener.Options{
+		Context:                  ctx,
+		Logger:                   logger,
+		Network:                  network,
+		Listen:                   options.ListenOptions,
+		ConnectionHandler:        inbound,
+		PacketHandler:            inbound,
+		ThreadUnsafePacketWriter: true,
+	})
+	return inbound, nil
+}
+
+func (i *Inbound) Start(stage adapter.StartStage) error {
+	if stage != adapter.StartStateStart {
+		return nil
+	}
+	if i.tlsConfig != nil {
+		err := i.tlsConfig.Start()
+		if err != nil {
+			return E.Cause(err, "create TLS config")
+		}
+	}
+	switch i.protocol {
+	case C.DNSTypeHTTPS:
+		return i.startHTTPServer()
+	case C.DNSTypeQUIC:
+		if ListenQUICFunc == nil {
+			return E.New("QUIC is not included in this build, rebuild with -tags with_quic")
+		}
+		if len(i.tlsConfig.NextProtos()) == 0 {
+			i.tlsConfig.SetNextProtos([]string{"doq"})
+		}
+		quicServer, err := ListenQUICFunc(i.ctx, i.logger, i.listener, i.tlsConfig, i.newQUICStream)
+		if err != nil {
+			return err
+		}
+		i.quicServer = quicServer
+		return nil
+	default:
+		return i.listener.Start()
+	}
+}
+
+func (i *Inbound) Close() error {
+	return common.Close(
+		common.PtrOrNil(i.httpServer),
+		i.quicServer,
+		i.listener,
+		i.tlsConfig,
+	)
+}
+
+func (i *Inbound) newMetadata(source M.Socksaddr, network string) adapter.InboundContext {
+	var metadata adapter.InboundContext
+	metadata.Inbound = i.Tag()
+	metadata.InboundType = i.Type()
+	metadata.Network = network
+	metadata.Source = source
+	metadata.Protocol = C.ProtocolDNS
+	return metadata
+}
+
+// exchange answers the query through the DNS router, a nil response means the query should be dropped.
+func (i *Inbound) exchange(ctx context.Context, message *mDNS.Msg, metadata adapter.InboundContext) *mDNS.Msg {
+	if !i.queryLimiter.Allow(metadata.Source.Addr) {
+		i.logger.DebugContext(ctx, "query rate limit exceeded for ", metadata.Source.Addr)
+		return dns.FixedResponseStatus(message, mDNS.RcodeRefused)
+	}
+	response, err := i.router.Exchange(adapter.WithContext(ctx, &metadata), message, adapter.DNSQueryOptions{})
+	if err != nil {
+		if errors.Is(err, tun.ErrDrop) {
+			return nil
+		}
+		var rcodeError dns.RcodeError
+		if errors.As(err, &rcodeError) {
+			return dns.FixedResponseStatus(message, int(rcodeError))
+		}
+		return dns.FixedResponseStatus(message, mDNS.RcodeServerFailure)
+	}
+	return response
+}
+
+func (i *Inbound) NewPacketEx(buffer *buf.Buffer, source M.Socksaddr) {
+	defer buffer.Release()
+	var message mDNS.Msg
+	err := message.Unpack(buffer.Bytes())
+	if err != nil {
+		i.logger.Debug(E.Cause(err, "parse query from ", source))
+		return
+	}
+	if !i.querySlots.TryAcquire() {
+		i.logger.Debug("too many concurrent queries, drop query from ", source)
+		return
+	}
+	go func() {
+		defer i.querySlots.Release()
+		ctx := log.ContextWithNewID(i.ctx)
+		response := i.exchange(ctx, &message, i.newMetadata(source, N.NetworkUDP))
+		if response == nil {
+			return
+		}
+		responseBuffer, err := dns.TruncateDNSMessage(&message, response, 0)
+		if err != nil {
+			i.logger.ErrorContext(ctx, E.Cause(err, "pack response"))
+			return
+		}
+		err = i.listener.PacketWriter().WritePacket(responseBuffer, source)
+		if err != nil {
+			i.logger.DebugContext(ctx, E.Cause(err, "write response to ", source))
+		}
+	}()
+}
+
+func (i *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	err := i.newConnection(ctx, conn, metadata.Source)
+	conn.Close()
+	if err != nil && !E.IsClosedOrCanceled(err) {
+		i.logger.DebugContext(ctx, E.Cause(err, "process connection from ", metadata.Source))
+	}
+}
+
+func (i *Inbound) newConnection(ctx context.Context, conn net.Conn, source M.Socksaddr) error {
+	if i.tlsConfig != nil {
+		tlsConn, err := tls.ServerHandshake(ctx, conn, i.tlsConfig)
+		if err != nil {
+			return E.Cause(err, "TLS handshake")
+		}
+		conn = tlsConn
+	}
+	metadata := i.newMetadata(source, N.NetworkTCP)
+	var (
+		writeAccess sync.Mutex
+		queryGroup  sync.WaitGroup
+	)
+	// in-flight responses are still written after the client half-closes the connection
+	defer waitGroupTimeout(&queryGroup, C.DNSTimeout)
+	for {
+		err := conn.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+		if err != nil {
+			return err
+		}
+		message, err := readStreamMessage(conn)
+		if err != nil {
+			return err
+		}
+		err = conn.SetReadDeadline(time.Time{})
+		if err != nil {
+			return err
+		}
+		err = i.querySlots.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		queryGroup.Add(1)
+		go func() {
+			defer queryGroup.Done()
+			defer i.querySlots.Release()
+			response := i.exchange(ctx, message, metadata)
+			if response == nil {
+				return
+			}
+			writeAccess.Lock()
+			defer writeAccess.Unlock()
+			err := writeStreamMessage(conn, response)
+			if err != nil {
+				conn.Close()
+			}
+		}()
+	}
+}
+
+func waitGroupTimeout(group *sync.WaitGroup, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+func (i *Inbound) newQUICStream(ctx context.Context, stream io.ReadWriteCloser, source M.Socksaddr) {
+	defer stream.Close()
+	message, err := readStreamMessage(stream)
+	if err != nil {
+		i.logger.DebugContext(ctx, E.Cause(err, "read query from ", source))
+		return
+	}
+	err = i.querySlots.Acquire(ctx)
+	if err != nil {
+		return
+	}
+	defer i.querySlots.Release()
+	response := i.exchange(ctx, message, i.newMetadata(source, N.NetworkUDP))
+	if response == nil {
+		return
+	}
+	err = writeStreamMessage(stream, response)
+	if err != nil {
+		i.logger.DebugContext(ctx, E.Cause(err, "write response to ", source))
+	}
+}
+
+func readStreamMessage(reader io.Reader) (*mDNS.Msg, error) {
+	var queryLength uint16
+	err := binary.Read(reader, binary.BigEndian, &queryLength)
+	if err != nil {
+		return nil, err
+	}
+	if queryLength == 0 {
+		return nil, dns.RcodeFormatError
+	}
+	buffer := buf.NewSize(int(queryLength))
+	defer buffer.Release()
+	_, err = buffer.ReadFullFrom(reader, int(queryLength))
+	if err != nil {
+		return nil, err
+	}
+	var message mDNS.Msg
+	err = message.Unpack(buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func writeStreamMessage(writer io.Writer, message *mDNS.Msg) error {
+	responseBuffer := buf.NewSize(3 + message.Len())
+	defer responseBuffer.Release()
+	responseBuffer.Resize(2, 0)
+	content, err := message.PackBuffer(responseBuffer.FreeBytes())
+	if err != nil {
+		return err
+	}
+	responseBuffer.Truncate(len(content))
+	binary.BigEndian.PutUint16(responseBuffer.ExtendHeader(2), uint16(len(content)))
+	_, err = writer.Write(responseBuffer.Bytes())
+	return err
+}
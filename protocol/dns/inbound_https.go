@@ -0,0 +1,109 @@
+package dns
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	aTLS "github.com/sagernet/sing/common/tls"
+
+	mDNS "github.com/miekg/dns"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const mimeTypeDNSMessage = "application/dns-message"
+
+func (i *Inbound) startHTTPServer() error {
+	tcpListener, err := i.listener.ListenTCP()
+	if err != nil {
+		return err
+	}
+	i.httpServer = &http.Server{
+		Handler:           h2c.NewHandler(http.HandlerFunc(i.serveHTTP), &http2.Server{}),
+		ReadHeaderTimeout: C.TCPTimeout,
+		IdleTimeout:       streamIdleTimeout,
+		BaseContext: func(listener net.Listener) context.Context {
+			return i.ctx
+		},
+	}
+	go func() {
+		listener := net.Listener(tcpListener)
+		if i.tlsConfig != nil {
+			if len(i.tlsConfig.NextProtos()) == 0 {
+				i.tlsConfig.SetNextProtos([]string{http2.NextProtoTLS, "http/1.1"})
+			} else if !common.Contains(i.tlsConfig.NextProtos(), http2.NextProtoTLS) {
+				i.tlsConfig.SetNextProtos(append([]string{http2.NextProtoTLS}, i.tlsConfig.NextProtos()...))
+			}
+			listener = aTLS.NewListener(tcpListener, i.tlsConfig)
+		}
+		sErr := i.httpServer.Serve(listener)
+		if sErr != nil && !errors.Is(sErr, http.ErrServerClosed) && !E.IsClosed(sErr) {
+			i.logger.Error("http server serve error: ", sErr)
+		}
+	}()
+	return nil
+}
+
+func (i *Inbound) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != i.path {
+		http.NotFound(writer, request)
+		return
+	}
+	var (
+		content []byte
+		err     error
+	)
+	switch request.Method {
+	case http.MethodGet:
+		content, err = base64.RawURLEncoding.DecodeString(request.URL.Query().Get("dns"))
+	case http.MethodPost:
+		if request.Header.Get("Content-Type") != mimeTypeDNSMessage {
+			http.Error(writer, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+			return
+		}
+		content, err = io.ReadAll(io.LimitReader(request.Body, mDNS.MaxMsgSize))
+	default:
+		writer.Header().Set("Allow", "GET, POST")
+		http.Error(writer, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := log.ContextWithNewID(request.Context())
+	source := M.ParseSocksaddr(request.RemoteAddr).Unwrap()
+	var message mDNS.Msg
+	if err == nil {
+		err = message.Unpack(content)
+	}
+	if err != nil {
+		i.logger.DebugContext(ctx, E.Cause(err, "parse query from ", source))
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	err = i.querySlots.Acquire(ctx)
+	if err != nil {
+		return
+	}
+	response := i.exchange(ctx, &message, i.newMetadata(source, N.NetworkTCP))
+	i.querySlots.Release()
+	if response == nil {
+		http.Error(writer, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	responseContent, err := response.Pack()
+	if err != nil {
+		i.logger.ErrorContext(ctx, E.Cause(err, "pack response"))
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", mimeTypeDNSMessage)
+	writer.Write(responseContent)
+}
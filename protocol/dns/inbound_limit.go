@@ -0,0 +1,81 @@
+package dns
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/sagernet/sing/common"
+	"github.com/sagernet/sing/contrab/freelru"
+	"github.com/sagernet/sing/contrab/maphash"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	queryLimiterCapacity = 4096
+	queryLimiterIdle     = 10 * time.Minute
+	maxConcurrentQueries = 1024
+)
+
+// queryLimiter limits queries per second of each client address.
+type queryLimiter struct {
+	limit    rate.Limit
+	burst    int
+	limiters freelru.Cache[netip.Addr, *rate.Limiter]
+}
+
+func newQueryLimiter(limit int, burst int) *queryLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = limit
+	}
+	limiters := common.Must1(freelru.NewSharded[netip.Addr, *rate.Limiter](queryLimiterCapacity, maphash.NewHasher[netip.Addr]().Hash32))
+	limiters.SetLifetime(queryLimiterIdle)
+	return &queryLimiter{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		limiters: limiters,
+	}
+}
+
+func (l *queryLimiter) Allow(source netip.Addr) bool {
+	if l == nil {
+		return true
+	}
+	limiter, _, _ := l.limiters.GetAndRefreshOrAdd(source, func() (*rate.Limiter, bool) {
+		return rate.NewLimiter(l.limit, l.burst), true
+	})
+	return limiter.Allow()
+}
+
+// querySlots caps queries being answered at the same time.
+type querySlots chan struct{}
+
+func newQuerySlots() querySlots {
+	return make(querySlots, maxConcurrentQueries)
+}
+
+func (s querySlots) TryAcquire() bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s querySlots) Acquire(ctx context.Context) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s querySlots) Release() {
+	<-s
+}
@@ -0,0 +1,58 @@
+package quic
+
+import (
+	"context"
+	"io"
+
+	"github.com/sagernet/quic-go"
+	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/protocol/dns"
+	"github.com/sagernet/sing-quic"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/logger"
+	M "github.com/sagernet/sing/common/metadata"
+)
+
+func init() {
+	dns.ListenQUICFunc = func(ctx context.Context, logger logger.ContextLogger, listener *listener.Listener, tlsConfig tls.ServerConfig, handler dns.QUICStreamHandler) (io.Closer, error) {
+		udpConn, err := listener.ListenUDP()
+		if err != nil {
+			return nil, err
+		}
+		// quic-go limits each connection to 100 concurrent streams by default
+		quicListener, err := qtls.Listen(udpConn, tlsConfig, &quic.Config{})
+		if err != nil {
+			udpConn.Close()
+			return nil, err
+		}
+		go func() {
+			for {
+				conn, aErr := quicListener.Accept(ctx)
+				if aErr != nil {
+					udpConn.Close()
+					if !E.IsClosedOrCanceled(aErr) {
+						logger.Error("quic listener closed: ", aErr)
+					}
+					return
+				}
+				go serveConn(ctx, logger, conn, handler)
+			}
+		}()
+		return quicListener, nil
+	}
+}
+
+func serveConn(ctx context.Context, logger logger.ContextLogger, conn *quic.Conn, handler dns.QUICStreamHandler) {
+	source := M.SocksaddrFromNet(conn.RemoteAddr()).Unwrap()
+	ctx = log.ContextWithNewID(ctx)
+	logger.InfoContext(ctx, "inbound connection from ", source)
+	for {
+		stream, err := conn.AcceptStream(ctx)
+		if err != nil {
+			return
+		}
+		go handler(ctx, stream, source)
+	}
+}
@@ -0,0 +1,253 @@
+package group
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	"github.com/sagernet/sing-box/common/interrupt"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+func RegisterFallback(registry *outbound.Registry) {
+	outbound.Register[option.FallbackOutboundOptions](registry, C.TypeFallback, NewFallback)
+}
+
+var (
+	_ adapter.OutboundGroup             = (*Fallback)(nil)
+	_ adapter.ConnectionHandlerEx       = (*Fallback)(nil)
+	_ adapter.PacketConnectionHandlerEx = (*Fallback)(nil)
+)
+
+type Fallback struct {
+	outbound.Adapter
+	ctx                          context.Context
+	outbound                     adapter.OutboundManager
+	connection                   adapter.ConnectionManager
+	logger                       log.ContextLogger
+	tags                         []string
+	link                         string
+	interval                     time.Duration
+	idleTimeout                  time.Duration
+	recoveryDelay                time.Duration
+	group                        *URLTestGroup
+	interruptExternalConnections bool
+}
+
+func NewFallback(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.FallbackOutboundOptions) (adapter.Outbound, error) {
+	outbound := &Fallback{
+		Adapter:                      outbound.NewAdapter(C.TypeFallback, tag, []string{N.NetworkTCP, N.NetworkUDP}, options.Outbounds),
+		ctx:                          ctx,
+		outbound:                     service.FromContext[adapter.OutboundManager](ctx),
+		connection:                   service.FromContext[adapter.ConnectionManager](ctx),
+		logger:                       logger,
+		tags:                         options.Outbounds,
+		link:                         options.URL,
+		interval:                     time.Duration(options.Interval),
+		idleTimeout:                  time.Duration(options.IdleTimeout),
+		recoveryDelay:                time.Duration(options.RecoveryDelay),
+		interruptExternalConnections: options.InterruptExistConnections,
+	}
+	if len(outbound.tags) == 0 {
+		return nil, E.New("missing tags")
+	}
+	return outbound, nil
+}
+
+func (s *Fallback) Start() error {
+	outbounds := make([]adapter.Outbound, 0, len(s.tags))
+	for i, tag := range s.tags {
+		detour, loaded := s.outbound.Outbound(tag)
+		if !loaded {
+			return E.New("outbound ", i, " not found: ", tag)
+		}
+		outbounds = append(outbounds, detour)
+	}
+	group, err := NewURLTestGroup(s.ctx, s.outbound, s.logger, outbounds, s.link, s.interval, 0, s.idleTimeout, s.interruptExternalConnections)
+	if err != nil {
+		return err
+	}
+	group.ordered = true
+	group.recoveryDelay = s.recoveryDelay
+	group.healthySince = make(map[string]time.Time)
+	s.group = group
+	return nil
+}
+
+func (s *Fallback) PostStart() error {
+	s.group.PostStart()
+	return nil
+}
+
+func (s *Fallback) Close() error {
+	return common.Close(
+		common.PtrOrNil(s.group),
+	)
+}
+
+func (s *Fallback) Now() string {
+	if s.group.selectedOutboundTCP != nil {
+		return s.group.selectedOutboundTCP.Tag()
+	} else if s.group.selectedOutboundUDP != nil {
+		return s.group.selectedOutboundUDP.Tag()
+	}
+	return ""
+}
+
+func (s *Fallback) All() []string {
+	return s.tags
+}
+
+func (s *Fallback) URLTest(ctx context.Context) (map[string]uint16, error) {
+	return s.group.URLTest(ctx)
+}
+
+func (s *Fallback) CheckOutbounds() {
+	s.group.CheckOutbounds(true)
+}
+
+func (s *Fallback) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	s.group.Touch()
+	var outbound adapter.Outbound
+	switch N.NetworkName(network) {
+	case N.NetworkTCP:
+		outbound = s.group.selectedOutboundTCP
+	case N.NetworkUDP:
+		outbound = s.group.selectedOutboundUDP
+	default:
+		return nil, E.Extend(N.ErrUnknownNetwork, network)
+	}
+	if outbound == nil {
+		outbound, _ = s.group.Select(network)
+	}
+	if outbound == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	conn, err := outbound.DialContext(ctx, network, destination)
+	if err == nil {
+		return s.group.newHealthConn(s.group.interruptGroup.NewConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), RealTag(outbound)), nil
+	}
+	s.logger.ErrorContext(ctx, err)
+	s.group.markUnavailable(RealTag(outbound))
+	return nil, err
+}
+
+func (s *Fallback) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	s.group.Touch()
+	outbound := s.group.selectedOutboundUDP
+	if outbound == nil {
+		outbound, _ = s.group.Select(N.NetworkUDP)
+	}
+	if outbound == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	conn, err := outbound.ListenPacket(ctx, destination)
+	if err == nil {
+		return s.group.interruptGroup.NewPacketConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+	}
+	s.logger.ErrorContext(ctx, err)
+	s.group.markUnavailable(RealTag(outbound))
+	return nil, err
+}
+
+func (s *Fallback) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	s.connection.NewConnection(ctx, s, conn, metadata, onClose)
+}
+
+func (s *Fallback) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	ctx = interrupt.ContextWithIsExternalConnection(ctx)
+	s.connection.NewPacketConnection(ctx, s, conn, metadata, onClose)
+}
+
+func (s *Fallback) NewDirectRouteConnection(metadata adapter.InboundContext, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
+	s.group.Touch()
+	selected := s.group.selectedOutboundTCP
+	if selected == nil {
+		selected, _ = s.group.Select(N.NetworkTCP)
+	}
+	if selected == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	if !common.Contains(selected.Network(), metadata.Network) {
+		return nil, E.New(metadata.Network, " is not supported by outbound: ", selected.Tag())
+	}
+	return selected.(adapter.DirectRouteOutbound).NewDirectRouteConnection(metadata, routeContext, timeout)
+}
+
+// selectOrdered returns the first available outbound in configured order.
+// A recovered outbound only takes over from an available one after it has
+// stayed available for the recovery delay.
+func (g *URLTestGroup) selectOrdered(network string) (adapter.Outbound, bool) {
+	var selected adapter.Outbound
+	switch network {
+	case N.NetworkTCP:
+		selected = g.selectedOutboundTCP
+	case N.NetworkUDP:
+		selected = g.selectedOutboundUDP
+	}
+	selectedAvailable := selected != nil && urltest.IsHealthy(g.history.LoadURLTestHistory(RealTag(selected)))
+	for _, detour := range g.Outbounds() {
+		if !common.Contains(detour.Network(), network) {
+			continue
+		}
+		if selectedAvailable && detour == selected {
+			return selected, true
+		}
+		realTag := RealTag(detour)
+		if !urltest.IsHealthy(g.history.LoadURLTestHistory(realTag)) {
+			continue
+		}
+		if selectedAvailable && !g.recovered(realTag) {
+			continue
+		}
+		return detour, true
+	}
+	for _, detour := range g.Outbounds() {
+		if !common.Contains(detour.Network(), network) {
+			continue
+		}
+		return detour, false
+	}
+	return nil, false
+}
+
+func (g *URLTestGroup) recovered(tag string) bool {
+	if g.recoveryDelay == 0 {
+		return true
+	}
+	g.healthAccess.Lock()
+	defer g.healthAccess.Unlock()
+	since, loaded := g.healthySince[tag]
+	return loaded && time.Since(since) >= g.recoveryDelay
+}
+
+func (g *URLTestGroup) updateHealth(tag string, available bool) {
+	if g.healthySince == nil {
+		return
+	}
+	g.healthAccess.Lock()
+	defer g.healthAccess.Unlock()
+	if !available {
+		delete(g.healthySince, tag)
+	} else if _, loaded := g.healthySince[tag]; !loaded {
+		g.healthySince[tag] = time.Now()
+	}
+}
+
+func (g *URLTestGroup) markUnavailable(tag string) {
+	g.history.DeleteURLTestHistory(tag)
+	g.updateHealth(tag, false)
+	g.performUpdateCheck()
+}
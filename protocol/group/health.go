@@ -0,0 +1,60 @@
+package group
+
+import (
+	"net"
+	"sync/atomic"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/urltest"
+)
+
+var _ adapter.ConnectionHealthReporter = (*healthConn)(nil)
+
+// healthConn carries the member that opened a connection,
+// so that the connection manager can score it by real traffic.
+type healthConn struct {
+	net.Conn
+	group    *URLTestGroup
+	tag      string
+	reported atomic.Bool
+}
+
+func (g *URLTestGroup) newHealthConn(conn net.Conn, tag string) net.Conn {
+	return &healthConn{
+		Conn:  conn,
+		group: g,
+		tag:   tag,
+	}
+}
+
+func (c *healthConn) ReportHealth(success bool) {
+	if c.reported.Swap(true) {
+		return
+	}
+	c.group.reportConnection(c.tag, success)
+}
+
+func (c *healthConn) ReaderReplaceable() bool {
+	return true
+}
+
+func (c *healthConn) WriterReplaceable() bool {
+	return true
+}
+
+func (c *healthConn) Upstream() any {
+	return c.Conn
+}
+
+// reportConnection scores a member by a real connection, and selects
+// another member at once if it became unhealthy, without waiting for the next test.
+func (g *URLTestGroup) reportConnection(tag string, success bool) {
+	healthy := urltest.IsHealthy(g.history.LoadURLTestHistory(tag))
+	history := g.history.ReportConnection(tag, success)
+	if !healthy || history == nil || urltest.IsHealthy(history) {
+		return
+	}
+	g.logger.Warn("outbound ", tag, " unhealthy by real traffic, score: ", history.Score)
+	g.updateHealth(tag, false)
+	g.performUpdateCheck()
+}
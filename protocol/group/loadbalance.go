@@ -0,0 +1,244 @@
+package group
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/adapter/outbound"
+	"github.com/sagernet/sing-box/common/urltest"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing-tun"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/service"
+)
+
+func RegisterLoadBalance(registry *outbound.Registry) {
+	outbound.Register[option.LoadBalanceOutboundOptions](registry, C.TypeLoadBalance, NewLoadBalance)
+}
+
+var (
+	_ adapter.OutboundGroup             = (*LoadBalance)(nil)
+	_ adapter.ConnectionHandlerEx       = (*LoadBalance)(nil)
+	_ adapter.PacketConnectionHandlerEx = (*LoadBalance)(nil)
+)
+
+type LoadBalance struct {
+	outbound.Adapter
+	ctx         context.Context
+	outbound    adapter.OutboundManager
+	connection  adapter.ConnectionManager
+	logger      log.ContextLogger
+	tags        []string
+	strategy    string
+	link        string
+	interval    time.Duration
+	idleTimeout time.Duration
+	group       *URLTestGroup
+	counter     atomic.Uint32
+	selected    common.TypedValue[adapter.Outbound]
+}
+
+func NewLoadBalance(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.LoadBalanceOutboundOptions) (adapter.Outbound, error) {
+	outbound := &LoadBalance{
+		Adapter:     outbound.NewAdapter(C.TypeLoadBalance, tag, []string{N.NetworkTCP, N.NetworkUDP}, options.Outbounds),
+		ctx:         ctx,
+		outbound:    service.FromContext[adapter.OutboundManager](ctx),
+		connection:  service.FromContext[adapter.ConnectionManager](ctx),
+		logger:      logger,
+		tags:        options.Outbounds,
+		strategy:    options.Strategy,
+		link:        options.URL,
+		interval:    time.Duration(options.Interval),
+		idleTimeout: time.Duration(options.IdleTimeout),
+	}
+	if len(outbound.tags) == 0 {
+		return nil, E.New("missing tags")
+	}
+	switch outbound.strategy {
+	case "":
+		outbound.strategy = C.LoadBalanceStrategyRoundRobin
+	case C.LoadBalanceStrategyRoundRobin, C.LoadBalanceStrategyRandom, C.LoadBalanceStrategyConsistentHashing, C.LoadBalanceStrategyStickySessions:
+	default:
+		return nil, E.New("unknown load balance strategy: ", outbound.strategy)
+	}
+	return outbound, nil
+}
+
+func (s *LoadBalance) Start() error {
+	outbounds := make([]adapter.Outbound, 0, len(s.tags))
+	for i, tag := range s.tags {
+		detour, loaded := s.outbound.Outbound(tag)
+		if !loaded {
+			return E.New("outbound ", i, " not found: ", tag)
+		}
+		outbounds = append(outbounds, detour)
+	}
+	group, err := NewURLTestGroup(s.ctx, s.outbound, s.logger, outbounds, s.link, s.interval, 0, s.idleTimeout, false)
+	if err != nil {
+		return err
+	}
+	s.group = group
+	return nil
+}
+
+func (s *LoadBalance) PostStart() error {
+	s.group.PostStart()
+	return nil
+}
+
+func (s *LoadBalance) Close() error {
+	return common.Close(
+		common.PtrOrNil(s.group),
+	)
+}
+
+func (s *LoadBalance) Now() string {
+	if selected := s.selected.Load(); selected != nil {
+		return selected.Tag()
+	}
+	return s.tags[0]
+}
+
+func (s *LoadBalance) All() []string {
+	return s.tags
+}
+
+func (s *LoadBalance) URLTest(ctx context.Context) (map[string]uint16, error) {
+	return s.group.URLTest(ctx)
+}
+
+func (s *LoadBalance) CheckOutbounds() {
+	s.group.CheckOutbounds(true)
+}
+
+func (s *LoadBalance) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
+	s.group.Touch()
+	outbound := s.pick(ctx, N.NetworkName(network), destination)
+	if outbound == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	conn, err := outbound.DialContext(ctx, network, destination)
+	if err == nil {
+		return s.group.newHealthConn(conn, RealTag(outbound)), nil
+	}
+	s.logger.ErrorContext(ctx, err)
+	s.group.markUnavailable(RealTag(outbound))
+	return nil, err
+}
+
+func (s *LoadBalance) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
+	s.group.Touch()
+	outbound := s.pick(ctx, N.NetworkUDP, destination)
+	if outbound == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	conn, err := outbound.ListenPacket(ctx, destination)
+	if err == nil {
+		return conn, nil
+	}
+	s.logger.ErrorContext(ctx, err)
+	s.group.markUnavailable(RealTag(outbound))
+	return nil, err
+}
+
+func (s *LoadBalance) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	s.connection.NewConnection(ctx, s, conn, metadata, onClose)
+}
+
+func (s *LoadBalance) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+	s.connection.NewPacketConnection(ctx, s, conn, metadata, onClose)
+}
+
+func (s *LoadBalance) NewDirectRouteConnection(metadata adapter.InboundContext, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
+	s.group.Touch()
+	selected := s.pick(adapter.WithContext(s.ctx, &metadata), metadata.Network, metadata.Destination)
+	if selected == nil {
+		return nil, E.New("missing supported outbound")
+	}
+	directRouteOutbound, isDirectRoute := selected.(adapter.DirectRouteOutbound)
+	if !isDirectRoute {
+		return nil, E.New("outbound does not support direct route: ", selected.Tag())
+	}
+	return directRouteOutbound.NewDirectRouteConnection(metadata, routeContext, timeout)
+}
+
+func (s *LoadBalance) pick(ctx context.Context, network string, destination M.Socksaddr) adapter.Outbound {
+	var supported, available []adapter.Outbound
+	for _, detour := range s.group.Outbounds() {
+		if !common.Contains(detour.Network(), network) {
+			continue
+		}
+		supported = append(supported, detour)
+		if urltest.IsHealthy(s.group.history.LoadURLTestHistory(RealTag(detour))) {
+			available = append(available, detour)
+		}
+	}
+	// before the first check completes, or when every member is down,
+	// spread over all members instead of failing the connection
+	if len(available) == 0 {
+		available = supported
+	}
+	if len(available) == 0 {
+		return nil
+	}
+	var selected adapter.Outbound
+	switch s.strategy {
+	case C.LoadBalanceStrategyRandom:
+		selected = available[rand.Intn(len(available))]
+	case C.LoadBalanceStrategyConsistentHashing:
+		selected = pickByHash(available, loadBalanceKey(ctx, destination, false))
+	case C.LoadBalanceStrategyStickySessions:
+		selected = pickByHash(available, loadBalanceKey(ctx, destination, true))
+	default:
+		selected = available[int(s.counter.Add(1)-1)%len(available)]
+	}
+	s.selected.Store(selected)
+	return selected
+}
+
+func loadBalanceKey(ctx context.Context, destination M.Socksaddr, withSource bool) string {
+	metadata := adapter.ContextFrom(ctx)
+	var key string
+	if destination.IsFqdn() {
+		key = destination.Fqdn
+	} else if metadata != nil && metadata.Domain != "" {
+		key = metadata.Domain
+	} else {
+		key = destination.AddrString()
+	}
+	if withSource && metadata != nil && metadata.Source.IsValid() {
+		key = metadata.Source.AddrString() + "-" + key
+	}
+	return key
+}
+
+// pickByHash uses rendezvous hashing, so a key only moves to another member
+// when the member it was assigned to becomes unavailable.
+func pickByHash(outbounds []adapter.Outbound, key string) adapter.Outbound {
+	var (
+		selected  adapter.Outbound
+		maxWeight uint64
+	)
+	for _, detour := range outbounds {
+		hash := fnv.New64a()
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write([]byte(detour.Tag()))
+		weight := hash.Sum64()
+		if selected == nil || weight > maxWeight {
+			selected = detour
+			maxWeight = weight
+		}
+	}
+	return selected
+}
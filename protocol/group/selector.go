@@ -3,6 +3,8 @@ package group
 import (
 	"context"
 	"net"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
@@ -17,6 +19,7 @@ import (
 	"github.com/sagernet/sing/common/logger"
 	M "github.com/sagernet/sing/common/metadata"
 	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/x/list"
 	"github.com/sagernet/sing/service"
 )
 
@@ -37,7 +40,12 @@ type Selector struct {
 	connection                   adapter.ConnectionManager
 	logger                       logger.ContextLogger
 	tags                         []string
+	providerTags                 []string
+	providers                    []adapter.OutboundProvider
+	providerCallbacks            []*list.Element[adapter.OutboundProviderUpdateCallback]
 	defaultTag                   string
+	access                       sync.RWMutex
+	members                      []string
 	outbounds                    map[string]adapter.Outbound
 	selected                     common.TypedValue[adapter.Outbound]
 	interruptGroup               *interrupt.Group
@@ -52,12 +60,14 @@ func NewSelector(ctx context.Context, router adapter.Router, logger log.ContextL
 		connection:                   service.FromContext[adapter.ConnectionManager](ctx),
 		logger:                       logger,
 		tags:                         options.Outbounds,
+		providerTags:                 options.Providers,
 		defaultTag:                   options.Default,
+		members:                      options.Outbounds,
 		outbounds:                    make(map[string]adapter.Outbound),
 		interruptGroup:               interrupt.NewGroup(),
 		interruptExternalConnections: options.InterruptExistConnections,
 	}
-	if len(outbound.tags) == 0 {
+	if len(outbound.tags) == 0 && len(outbound.providerTags) == 0 {
 		return nil, E.New("missing tags")
 	}
 	return outbound, nil
@@ -79,7 +89,41 @@ func (s *Selector) Start() error {
 		}
 		s.outbounds[tag] = detour
 	}
+	if len(s.providerTags) > 0 {
+		providerManager := service.FromContext[adapter.OutboundProviderManager](s.ctx)
+		for _, tag := range s.providerTags {
+			provider, loaded := providerManager.OutboundProvider(tag)
+			if !loaded {
+				return E.New("outbound provider not found: ", tag)
+			}
+			s.providers = append(s.providers, provider)
+		}
+		s.updateProviderOutbounds()
+		for _, provider := range s.providers {
+			s.providerCallbacks = append(s.providerCallbacks, provider.RegisterCallback(s.providerUpdated))
+		}
+	}
+	if s.defaultTag != "" && len(s.providers) == 0 {
+		if _, loaded := s.outbounds[s.defaultTag]; !loaded {
+			return E.New("default outbound not found: ", s.defaultTag)
+		}
+	}
+	if detour := s.selectInitial(); detour != nil {
+		s.selected.Store(detour)
+	}
+	return nil
+}
 
+func (s *Selector) Close() error {
+	for i, provider := range s.providers {
+		provider.UnregisterCallback(s.providerCallbacks[i])
+	}
+	return nil
+}
+
+func (s *Selector) selectInitial() adapter.Outbound {
+	s.access.RLock()
+	defer s.access.RUnlock()
 	if s.Tag() != "" {
 		cacheFile := service.FromContext[adapter.CacheFile](s.ctx)
 		if cacheFile != nil {
@@ -87,40 +131,86 @@ func (s *Selector) Start() error {
 			if selected != "" {
 				detour, loaded := s.outbounds[selected]
 				if loaded {
-					s.selected.Store(detour)
-					return nil
+					return detour
 				}
 			}
 		}
 	}
-
 	if s.defaultTag != "" {
 		detour, loaded := s.outbounds[s.defaultTag]
-		if !loaded {
-			return E.New("default outbound not found: ", s.defaultTag)
+		if loaded {
+			return detour
 		}
-		s.selected.Store(detour)
+	}
+	if len(s.members) == 0 {
 		return nil
 	}
+	return s.outbounds[s.members[0]]
+}
 
-	s.selected.Store(s.outbounds[s.tags[0]])
-	return nil
+func (s *Selector) updateProviderOutbounds() {
+	members := slices.Clone(s.tags)
+	outbounds := make(map[string]adapter.Outbound)
+	for _, tag := range s.tags {
+		outbounds[tag] = s.outbounds[tag]
+	}
+	for _, provider := range s.providers {
+		for _, detour := range provider.Outbounds() {
+			if _, loaded := outbounds[detour.Tag()]; loaded {
+				continue
+			}
+			members = append(members, detour.Tag())
+			outbounds[detour.Tag()] = detour
+		}
+	}
+	s.access.Lock()
+	s.members = members
+	s.outbounds = outbounds
+	s.access.Unlock()
+}
+
+func (s *Selector) providerUpdated(_ adapter.OutboundProvider) {
+	s.updateProviderOutbounds()
+	selected := s.selected.Load()
+	var detour adapter.Outbound
+	if selected != nil {
+		s.access.RLock()
+		detour = s.outbounds[selected.Tag()]
+		s.access.RUnlock()
+	}
+	if detour == nil {
+		detour = s.selectInitial()
+	}
+	if detour == nil || detour == selected {
+		return
+	}
+	s.selected.Store(detour)
+	s.interruptGroup.Interrupt(s.interruptExternalConnections)
 }
 
 func (s *Selector) Now() string {
 	selected := s.selected.Load()
 	if selected == nil {
-		return s.tags[0]
+		s.access.RLock()
+		defer s.access.RUnlock()
+		if len(s.members) == 0 {
+			return ""
+		}
+		return s.members[0]
 	}
 	return selected.Tag()
 }
 
 func (s *Selector) All() []string {
-	return s.tags
+	s.access.RLock()
+	defer s.access.RUnlock()
+	return s.members
 }
 
 func (s *Selector) SelectOutbound(tag string) bool {
+	s.access.RLock()
 	detour, loaded := s.outbounds[tag]
+	s.access.RUnlock()
 	if !loaded {
 		return false
 	}
@@ -141,7 +231,11 @@ func (s *Selector) SelectOutbound(tag string) bool {
 }
 
 func (s *Selector) DialContext(ctx context.Context, network string, destination M.Socksaddr) (net.Conn, error) {
-	conn, err := s.selected.Load().DialContext(ctx, network, destination)
+	selected := s.selected.Load()
+	if selected == nil {
+		return nil, E.New("missing selected outbound")
+	}
+	conn, err := selected.DialContext(ctx, network, destination)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +243,11 @@ func (s *Selector) DialContext(ctx context.Context, network string, destination
 }
 
 func (s *Selector) ListenPacket(ctx context.Context, destination M.Socksaddr) (net.PacketConn, error) {
-	conn, err := s.selected.Load().ListenPacket(ctx, destination)
+	selected := s.selected.Load()
+	if selected == nil {
+		return nil, E.New("missing selected outbound")
+	}
+	conn, err := selected.ListenPacket(ctx, destination)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +257,9 @@ func (s *Selector) ListenPacket(ctx context.Context, destination M.Socksaddr) (n
 func (s *Selector) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
 	ctx = interrupt.ContextWithIsExternalConnection(ctx)
 	selected := s.selected.Load()
-	if outboundHandler, isHandler := selected.(adapter.ConnectionHandlerEx); isHandler {
+	if selected == nil {
+		s.connection.NewConnection(ctx, s, conn, metadata, onClose)
+	} else if outboundHandler, isHandler := selected.(adapter.ConnectionHandlerEx); isHandler {
 		outboundHandler.NewConnectionEx(ctx, conn, metadata, onClose)
 	} else {
 		s.connection.NewConnection(ctx, selected, conn, metadata, onClose)
@@ -169,7 +269,9 @@ func (s *Selector) NewConnectionEx(ctx context.Context, conn net.Conn, metadata
 func (s *Selector) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
 	ctx = interrupt.ContextWithIsExternalConnection(ctx)
 	selected := s.selected.Load()
-	if outboundHandler, isHandler := selected.(adapter.PacketConnectionHandlerEx); isHandler {
+	if selected == nil {
+		s.connection.NewPacketConnection(ctx, s, conn, metadata, onClose)
+	} else if outboundHandler, isHandler := selected.(adapter.PacketConnectionHandlerEx); isHandler {
 		outboundHandler.NewPacketConnectionEx(ctx, conn, metadata, onClose)
 	} else {
 		s.connection.NewPacketConnection(ctx, selected, conn, metadata, onClose)
@@ -178,6 +280,9 @@ func (s *Selector) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 
 func (s *Selector) NewDirectRouteConnection(metadata adapter.InboundContext, routeContext tun.DirectRouteContext, timeout time.Duration) (tun.DirectRouteDestination, error) {
 	selected := s.selected.Load()
+	if selected == nil {
+		return nil, E.New("missing selected outbound")
+	}
 	if !common.Contains(selected.Network(), metadata.Network) {
 		return nil, E.New(metadata.Network, " is not supported by outbound: ", selected.Tag())
 	}
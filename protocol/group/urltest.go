@@ -39,6 +39,9 @@ type URLTest struct {
 	connection                   adapter.ConnectionManager
 	logger                       log.ContextLogger
 	tags                         []string
+	providerTags                 []string
+	providers                    []adapter.OutboundProvider
+	providerCallbacks            []*list.Element[adapter.OutboundProviderUpdateCallback]
 	link                         string
 	interval                     time.Duration
 	tolerance                    uint16
@@ -56,13 +59,14 @@ func NewURLTest(ctx context.Context, router adapter.Router, logger log.ContextLo
 		connection:                   service.FromContext[adapter.ConnectionManager](ctx),
 		logger:                       logger,
 		tags:                         options.Outbounds,
+		providerTags:                 options.Providers,
 		link:                         options.URL,
 		interval:                     time.Duration(options.Interval),
 		tolerance:                    options.Tolerance,
 		idleTimeout:                  time.Duration(options.IdleTimeout),
 		interruptExternalConnections: options.InterruptExistConnections,
 	}
-	if len(outbound.tags) == 0 {
+	if len(outbound.tags) == 0 && len(outbound.providerTags) == 0 {
 		return nil, E.New("missing tags")
 	}
 	return outbound, nil
@@ -77,20 +81,48 @@ func (s *URLTest) Start() error {
 		}
 		outbounds = append(outbounds, detour)
 	}
+	if len(s.providerTags) > 0 {
+		providerManager := service.FromContext[adapter.OutboundProviderManager](s.ctx)
+		for _, tag := range s.providerTags {
+			provider, loaded := providerManager.OutboundProvider(tag)
+			if !loaded {
+				return E.New("outbound provider not found: ", tag)
+			}
+			s.providers = append(s.providers, provider)
+		}
+		outbounds = appendProviderOutbounds(outbounds, s.providers)
+	}
 	group, err := NewURLTestGroup(s.ctx, s.outbound, s.logger, outbounds, s.link, s.interval, s.tolerance, s.idleTimeout, s.interruptExternalConnections)
 	if err != nil {
 		return err
 	}
 	s.group = group
+	for _, provider := range s.providers {
+		s.providerCallbacks = append(s.providerCallbacks, provider.RegisterCallback(s.providerUpdated))
+	}
 	return nil
 }
 
+func (s *URLTest) providerUpdated(_ adapter.OutboundProvider) {
+	outbounds := make([]adapter.Outbound, 0, len(s.tags))
+	for _, detour := range s.group.Outbounds() {
+		if common.Contains(s.tags, detour.Tag()) {
+			outbounds = append(outbounds, detour)
+		}
+	}
+	s.group.SetOutbounds(appendProviderOutbounds(outbounds, s.providers))
+	go s.group.CheckOutbounds(false)
+}
+
 func (s *URLTest) PostStart() error {
 	s.group.PostStart()
 	return nil
 }
 
 func (s *URLTest) Close() error {
+	for i, provider := range s.providers {
+		provider.UnregisterCallback(s.providerCallbacks[i])
+	}
 	return common.Close(
 		common.PtrOrNil(s.group),
 	)
@@ -106,7 +138,10 @@ func (s *URLTest) Now() string {
 }
 
 func (s *URLTest) All() []string {
-	return s.tags
+	if len(s.providers) == 0 {
+		return s.tags
+	}
+	return common.Map(s.group.Outbounds(), adapter.Outbound.Tag)
 }
 
 func (s *URLTest) URLTest(ctx context.Context) (map[string]uint16, error) {
@@ -136,10 +171,10 @@ func (s *URLTest) DialContext(ctx context.Context, network string, destination M
 	}
 	conn, err := outbound.DialContext(ctx, network, destination)
 	if err == nil {
-		return s.group.interruptGroup.NewConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
+		return s.group.newHealthConn(s.group.interruptGroup.NewConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), RealTag(outbound)), nil
 	}
 	s.logger.ErrorContext(ctx, err)
-	s.group.history.DeleteURLTestHistory(outbound.Tag())
+	s.group.markUnavailable(RealTag(outbound))
 	return nil, err
 }
 
@@ -157,7 +192,7 @@ func (s *URLTest) ListenPacket(ctx context.Context, destination M.Socksaddr) (ne
 		return s.group.interruptGroup.NewPacketConn(conn, interrupt.IsExternalConnectionFromContext(ctx)), nil
 	}
 	s.logger.ErrorContext(ctx, err)
-	s.group.history.DeleteURLTestHistory(outbound.Tag())
+	s.group.markUnavailable(RealTag(outbound))
 	return nil, err
 }
 
@@ -209,6 +244,11 @@ type URLTestGroup struct {
 	close                        chan struct{}
 	started                      bool
 	lastActive                   common.TypedValue[time.Time]
+	outboundsAccess              sync.RWMutex
+	ordered                      bool
+	recoveryDelay                time.Duration
+	healthAccess                 sync.Mutex
+	healthySince                 map[string]time.Time
 }
 
 func NewURLTestGroup(ctx context.Context, outboundManager adapter.OutboundManager, logger log.Logger, outbounds []adapter.Outbound, link string, interval time.Duration, tolerance uint16, idleTimeout time.Duration, interruptExternalConnections bool) (*URLTestGroup, error) {
@@ -284,31 +324,60 @@ func (g *URLTestGroup) Close() error {
 	return nil
 }
 
+func (g *URLTestGroup) Outbounds() []adapter.Outbound {
+	g.outboundsAccess.RLock()
+	defer g.outboundsAccess.RUnlock()
+	return g.outbounds
+}
+
+// SetOutbounds replaces members of the group, dropping the selected outbound if it was removed.
+func (g *URLTestGroup) SetOutbounds(outbounds []adapter.Outbound) {
+	g.outboundsAccess.Lock()
+	g.outbounds = outbounds
+	g.outboundsAccess.Unlock()
+	var removed bool
+	if g.selectedOutboundTCP != nil && !common.Contains(outbounds, g.selectedOutboundTCP) {
+		g.selectedOutboundTCP = nil
+		removed = true
+	}
+	if g.selectedOutboundUDP != nil && !common.Contains(outbounds, g.selectedOutboundUDP) {
+		g.selectedOutboundUDP = nil
+		removed = true
+	}
+	g.performUpdateCheck()
+	if removed {
+		g.interruptGroup.Interrupt(g.interruptExternalConnections)
+	}
+}
+
 func (g *URLTestGroup) Select(network string) (adapter.Outbound, bool) {
+	if g.ordered {
+		return g.selectOrdered(network)
+	}
 	var minDelay uint16
 	var minOutbound adapter.Outbound
 	switch network {
 	case N.NetworkTCP:
 		if g.selectedOutboundTCP != nil {
-			if history := g.history.LoadURLTestHistory(RealTag(g.selectedOutboundTCP)); history != nil {
+			if history := g.history.LoadURLTestHistory(RealTag(g.selectedOutboundTCP)); urltest.IsHealthy(history) {
 				minOutbound = g.selectedOutboundTCP
 				minDelay = history.Delay
 			}
 		}
 	case N.NetworkUDP:
 		if g.selectedOutboundUDP != nil {
-			if history := g.history.LoadURLTestHistory(RealTag(g.selectedOutboundUDP)); history != nil {
+			if history := g.history.LoadURLTestHistory(RealTag(g.selectedOutboundUDP)); urltest.IsHealthy(history) {
 				minOutbound = g.selectedOutboundUDP
 				minDelay = history.Delay
 			}
 		}
 	}
-	for _, detour := range g.outbounds {
+	for _, detour := range g.Outbounds() {
 		if !common.Contains(detour.Network(), network) {
 			continue
 		}
 		history := g.history.LoadURLTestHistory(RealTag(detour))
-		if history == nil {
+		if !urltest.IsHealthy(history) {
 			continue
 		}
 		if minDelay == 0 || minDelay > history.Delay+g.tolerance {
@@ -317,7 +386,7 @@ func (g *URLTestGroup) Select(network string) (adapter.Outbound, bool) {
 		}
 	}
 	if minOutbound == nil {
-		for _, detour := range g.outbounds {
+		for _, detour := range g.Outbounds() {
 			if !common.Contains(detour.Network(), network) {
 				continue
 			}
@@ -369,7 +438,7 @@ func (g *URLTestGroup) urlTest(ctx context.Context, force bool) (map[string]uint
 	b, _ := batch.New(ctx, batch.WithConcurrencyNum[any](10))
 	checked := make(map[string]bool)
 	var resultAccess sync.Mutex
-	for _, detour := range g.outbounds {
+	for _, detour := range g.Outbounds() {
 		tag := detour.Tag()
 		realTag := RealTag(detour)
 		if checked[realTag] {
@@ -391,12 +460,14 @@ func (g *URLTestGroup) urlTest(ctx context.Context, force bool) (map[string]uint
 			if err != nil {
 				g.logger.Debug("outbound ", tag, " unavailable: ", err)
 				g.history.DeleteURLTestHistory(realTag)
+				g.updateHealth(realTag, false)
 			} else {
 				g.logger.Debug("outbound ", tag, " available: ", t, "ms")
 				g.history.StoreURLTestHistory(realTag, &adapter.URLTestHistory{
 					Time:  time.Now(),
 					Delay: t,
 				})
+				g.updateHealth(realTag, true)
 				resultAccess.Lock()
 				result[tag] = t
 				resultAccess.Unlock()
@@ -427,3 +498,17 @@ func (g *URLTestGroup) performUpdateCheck() {
 		g.interruptGroup.Interrupt(g.interruptExternalConnections)
 	}
 }
+
+func appendProviderOutbounds(outbounds []adapter.Outbound, providers []adapter.OutboundProvider) []adapter.Outbound {
+	for _, provider := range providers {
+		for _, detour := range provider.Outbounds() {
+			if common.Any(outbounds, func(it adapter.Outbound) bool {
+				return it.Tag() == detour.Tag()
+			}) {
+				continue
+			}
+			outbounds = append(outbounds, detour)
+		}
+	}
+	return outbounds
+}
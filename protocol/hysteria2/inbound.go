@@ -10,8 +10,11 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
@@ -30,12 +33,15 @@ func RegisterInbound(registry *inbound.Registry) {
 
 type Inbound struct {
 	inbound.Adapter
-	router       adapter.Router
-	logger       log.ContextLogger
-	listener     *listener.Listener
-	tlsConfig    tls.ServerConfig
-	service      *hysteria2.Service[int]
-	userNameList []string
+	router             adapter.Router
+	logger             log.ContextLogger
+	listener           *listener.Listener
+	tlsConfig          tls.ServerConfig
+	service            *hysteria2.Service[int]
+	userNameList       []string
+	speedLimiters      []adapter.SpeedLimiter
+	connectionLimiters []adapter.ConnectionLimiter
+	trafficQuotas      *trafficquota.Registration
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.Hysteria2InboundOptions) (adapter.Inbound, error) {
@@ -132,14 +138,26 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 	userList := make([]int, 0, len(options.Users))
 	userNameList := make([]string, 0, len(options.Users))
 	userPasswordList := make([]string, 0, len(options.Users))
+	speedLimiters := make([]adapter.SpeedLimiter, 0, len(options.Users))
+	connectionLimiters := make([]adapter.ConnectionLimiter, 0, len(options.Users))
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
 	for index, user := range options.Users {
 		userList = append(userList, index)
 		userNameList = append(userNameList, user.Name)
 		userPasswordList = append(userPasswordList, user.Password)
+		speedLimiters = append(speedLimiters, speedlimit.New(user.SpeedLimit))
+		connectionLimiters = append(connectionLimiters, connlimit.New(user.MaxConnections, user.MaxDevices))
+		err = inbound.trafficQuotas.Register(user.Name, user.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
 	}
 	service.UpdateUsers(userList, userPasswordList)
 	inbound.service = service
 	inbound.userNameList = userNameList
+	inbound.speedLimiters = speedLimiters
+	inbound.connectionLimiters = connectionLimiters
 	return inbound, nil
 }
 
@@ -157,6 +175,8 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, source M.S
 	metadata.Destination = destination
 	h.logger.InfoContext(ctx, "inbound connection from ", metadata.Source)
 	userID, _ := auth.UserFromContext[int](ctx)
+	metadata.UserSpeedLimiter = h.speedLimiters[userID]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userID]
 	if userName := h.userNameList[userID]; userName != "" {
 		metadata.User = userName
 		h.logger.InfoContext(ctx, "[", userName, "] inbound connection to ", metadata.Destination)
@@ -180,6 +200,8 @@ func (h *Inbound) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 	metadata.Destination = destination
 	h.logger.InfoContext(ctx, "inbound packet connection from ", metadata.Source)
 	userID, _ := auth.UserFromContext[int](ctx)
+	metadata.UserSpeedLimiter = h.speedLimiters[userID]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userID]
 	if userName := h.userNameList[userID]; userName != "" {
 		metadata.User = userName
 		h.logger.InfoContext(ctx, "[", userName, "] inbound packet connection to ", metadata.Destination)
@@ -211,5 +233,6 @@ func (h *Inbound) Close() error {
 		h.listener,
 		h.tlsConfig,
 		common.PtrOrNil(h.service),
+		h.trafficQuotas,
 	)
 }
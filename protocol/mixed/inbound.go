@@ -7,14 +7,19 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/sniff"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	"github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/auth"
+	"github.com/sagernet/sing/common/bufio"
 	E "github.com/sagernet/sing/common/exceptions"
 	N "github.com/sagernet/sing/common/network"
 	"github.com/sagernet/sing/protocol/http"
@@ -31,19 +36,41 @@ var _ adapter.TCPInjectableInbound = (*Inbound)(nil)
 
 type Inbound struct {
 	inbound.Adapter
-	router        adapter.ConnectionRouterEx
-	logger        log.ContextLogger
-	listener      *listener.Listener
-	authenticator *auth.Authenticator
-	tlsConfig     tls.ServerConfig
+	router             adapter.ConnectionRouterEx
+	needSniffHTTP      func() bool
+	logger             log.ContextLogger
+	listener           *listener.Listener
+	authenticator      *auth.Authenticator
+	speedLimiters      map[string]adapter.SpeedLimiter
+	connectionLimiters map[string]adapter.ConnectionLimiter
+	trafficQuotas      *trafficquota.Registration
+	tlsConfig          tls.ServerConfig
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.HTTPMixedInboundOptions) (adapter.Inbound, error) {
 	inbound := &Inbound{
-		Adapter:       inbound.NewAdapter(C.TypeMixed, tag),
-		router:        uot.NewRouter(router, logger),
-		logger:        logger,
-		authenticator: auth.NewAuthenticator(options.Users),
+		Adapter:            inbound.NewAdapter(C.TypeMixed, tag),
+		router:             uot.NewRouter(router, logger),
+		needSniffHTTP:      router.NeedSniffHTTP,
+		logger:             logger,
+		authenticator:      auth.NewAuthenticator(options.Users),
+		speedLimiters:      make(map[string]adapter.SpeedLimiter),
+		connectionLimiters: make(map[string]adapter.ConnectionLimiter),
+	}
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
+	for index, user := range options.Users {
+		userOptions := options.UserOption(index)
+		if limiter := speedlimit.New(userOptions.SpeedLimit); limiter != nil {
+			inbound.speedLimiters[user.Username] = limiter
+		}
+		if limiter := connlimit.New(userOptions.MaxConnections, userOptions.MaxDevices); limiter != nil {
+			inbound.connectionLimiters[user.Username] = limiter
+		}
+		err := inbound.trafficQuotas.Register(user.Username, userOptions.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
 	}
 	if options.TLS != nil {
 		tlsConfig, err := tls.NewServerWithOptions(tls.ServerOptions{
@@ -86,6 +113,7 @@ func (h *Inbound) Close() error {
 	return common.Close(
 		h.listener,
 		h.tlsConfig,
+		h.trafficQuotas,
 	)
 }
 
@@ -118,8 +146,30 @@ func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, metadata ada
 	case socks4.Version, socks5.Version:
 		return socks.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), h.listener, metadata.Source, onClose)
 	default:
-		return http.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newUserConnection, h.streamUserPacketConnection), metadata.Source, onClose)
+		return http.HandleConnectionEx(ctx, conn, reader, h.authenticator, adapter.NewUpstreamHandlerEx(metadata, h.newHTTPConnection(conn), h.streamUserPacketConnection), metadata.Source, onClose)
+	}
+}
+
+// newHTTPConnection sniffs requests forwarded by the HTTP proxy if route rules match HTTP requests,
+// CONNECT requests are handed the client connection itself and are not sniffed.
+func (h *Inbound) newHTTPConnection(clientConn net.Conn) adapter.ConnectionHandlerFuncEx {
+	return func(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
+		if h.needSniffHTTP() && !isClientConn(conn, clientConn) {
+			var err error
+			conn, err = sniff.HTTPProxyRequest(ctx, &metadata, conn)
+			if err != nil {
+				h.logger.DebugContext(ctx, "sniff HTTP request: ", err)
+			}
+		}
+		h.newUserConnection(ctx, conn, metadata, onClose)
+	}
+}
+
+func isClientConn(conn net.Conn, clientConn net.Conn) bool {
+	if cachedConn, isCached := conn.(*bufio.CachedConn); isCached {
+		return cachedConn.Upstream() == clientConn
 	}
+	return conn == clientConn
 }
 
 func (h *Inbound) newUserConnection(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
@@ -132,6 +182,8 @@ func (h *Inbound) newUserConnection(ctx context.Context, conn net.Conn, metadata
 		return
 	}
 	metadata.User = user
+	metadata.UserSpeedLimiter = h.speedLimiters[user]
+	metadata.UserConnectionLimiter = h.connectionLimiters[user]
 	h.logger.InfoContext(ctx, "[", user, "] inbound connection to ", metadata.Destination)
 	h.router.RouteConnectionEx(ctx, conn, metadata, onClose)
 }
@@ -150,6 +202,8 @@ func (h *Inbound) streamUserPacketConnection(ctx context.Context, conn N.PacketC
 		return
 	}
 	metadata.User = user
+	metadata.UserSpeedLimiter = h.speedLimiters[user]
+	metadata.UserConnectionLimiter = h.connectionLimiters[user]
 	if !metadata.Destination.IsValid() {
 		h.logger.InfoContext(ctx, "[", user, "] inbound packet connection")
 	} else {
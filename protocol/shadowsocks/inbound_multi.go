@@ -4,12 +4,16 @@ import (
 	"context"
 	"net"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
+	"github.com/sagernet/sing-box/common/speedlimit"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	"github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -35,13 +39,18 @@ var (
 
 type MultiInbound struct {
 	inbound.Adapter
-	ctx      context.Context
-	router   adapter.ConnectionRouterEx
-	logger   logger.ContextLogger
-	listener *listener.Listener
-	service  shadowsocks.MultiService[int]
-	users    []option.ShadowsocksUser
-	tracker  adapter.SSMTracker
+	ctx                context.Context
+	router             adapter.ConnectionRouterEx
+	logger             logger.ContextLogger
+	listener           *listener.Listener
+	service            shadowsocks.MultiService[int]
+	configuredUsers    []option.ShadowsocksUser
+	userAccess         sync.RWMutex
+	users              []option.ShadowsocksUser
+	speedLimiters      []adapter.SpeedLimiter
+	connectionLimiters []adapter.ConnectionLimiter
+	trafficQuotas      *trafficquota.Registration
+	tracker            adapter.SSMTracker
 }
 
 func newMultiInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.ShadowsocksInboundOptions) (*MultiInbound, error) {
@@ -94,7 +103,22 @@ func newMultiInbound(ctx context.Context, router adapter.Router, logger log.Cont
 		}
 	}
 	inbound.service = service
+	inbound.configuredUsers = options.Users
 	inbound.users = options.Users
+	inbound.speedLimiters = common.Map(options.Users, func(it option.ShadowsocksUser) adapter.SpeedLimiter {
+		return speedlimit.New(it.SpeedLimit)
+	})
+	inbound.connectionLimiters = common.Map(options.Users, func(it option.ShadowsocksUser) adapter.ConnectionLimiter {
+		return connlimit.New(it.MaxConnections, it.MaxDevices)
+	})
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
+	for _, user := range options.Users {
+		err := inbound.trafficQuotas.Register(user.Name, user.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
+	}
 	inbound.listener = listener.New(listener.Options{
 		Context:                  ctx,
 		Logger:                   logger,
@@ -115,14 +139,36 @@ func (h *MultiInbound) Start(stage adapter.StartStage) error {
 }
 
 func (h *MultiInbound) Close() error {
-	return h.listener.Close()
+	return common.Close(
+		h.listener,
+		h.trafficQuotas,
+	)
 }
 
 func (h *MultiInbound) SetTracker(tracker adapter.SSMTracker) {
 	h.tracker = tracker
 }
 
+// UpdateUsers replaces users managed by the SSM API, limits of users are kept by name,
+// or taken from the configured user of the same name.
 func (h *MultiInbound) UpdateUsers(users []string, uPSKs []string) error {
+	h.userAccess.Lock()
+	defer h.userAccess.Unlock()
+	speedLimiters := make([]adapter.SpeedLimiter, len(users))
+	connectionLimiters := make([]adapter.ConnectionLimiter, len(users))
+	for index, user := range users {
+		if existingIndex := common.Index(h.users, func(it option.ShadowsocksUser) bool {
+			return it.Name == user
+		}); existingIndex != -1 {
+			speedLimiters[index] = h.speedLimiters[existingIndex]
+			connectionLimiters[index] = h.connectionLimiters[existingIndex]
+		} else if configuredIndex := common.Index(h.configuredUsers, func(it option.ShadowsocksUser) bool {
+			return it.Name == user
+		}); configuredIndex != -1 {
+			speedLimiters[index] = speedlimit.New(h.configuredUsers[configuredIndex].SpeedLimit)
+			connectionLimiters[index] = connlimit.New(h.configuredUsers[configuredIndex].MaxConnections, h.configuredUsers[configuredIndex].MaxDevices)
+		}
+	}
 	err := h.service.UpdateUsersWithPasswords(common.MapIndexed(users, func(index int, user string) int {
 		return index
 	}), uPSKs)
@@ -134,9 +180,22 @@ func (h *MultiInbound) UpdateUsers(users []string, uPSKs []string) error {
 			Name: user,
 		}
 	})
+	h.speedLimiters = speedLimiters
+	h.connectionLimiters = connectionLimiters
 	return nil
 }
 
+// loadUser returns the name and limiters of an authenticated user,
+// connections authenticated before the users were updated are rejected.
+func (h *MultiInbound) loadUser(userIndex int) (string, adapter.SpeedLimiter, adapter.ConnectionLimiter, error) {
+	h.userAccess.RLock()
+	defer h.userAccess.RUnlock()
+	if userIndex >= len(h.users) {
+		return "", nil, nil, E.New("user removed: ", userIndex)
+	}
+	return h.users[userIndex].Name, h.speedLimiters[userIndex], h.connectionLimiters[userIndex], nil
+}
+
 //nolint:staticcheck
 func (h *MultiInbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
 	err := h.service.NewConnection(ctx, conn, adapter.UpstreamMetadata(metadata))
@@ -163,7 +222,12 @@ func (h *MultiInbound) newConnection(ctx context.Context, conn net.Conn, metadat
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, speedLimiter, connectionLimiter, err := h.loadUser(userIndex)
+	if err != nil {
+		return err
+	}
+	metadata.UserSpeedLimiter = speedLimiter
+	metadata.UserConnectionLimiter = connectionLimiter
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
@@ -187,7 +251,12 @@ func (h *MultiInbound) newPacketConnection(ctx context.Context, conn N.PacketCon
 	if !loaded {
 		return os.ErrInvalid
 	}
-	user := h.users[userIndex].Name
+	user, speedLimiter, connectionLimiter, err := h.loadUser(userIndex)
+	if err != nil {
+		return err
+	}
+	metadata.UserSpeedLimiter = speedLimiter
+	metadata.UserConnectionLimiter = connectionLimiter
 	if user == "" {
 		user = F.ToString(userIndex)
 	} else {
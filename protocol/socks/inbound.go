@@ -7,11 +7,15 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/speedlimit"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	"github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
 	"github.com/sagernet/sing/common/auth"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/logger"
@@ -27,18 +31,38 @@ var _ adapter.TCPInjectableInbound = (*Inbound)(nil)
 
 type Inbound struct {
 	inbound.Adapter
-	router        adapter.ConnectionRouterEx
-	logger        logger.ContextLogger
-	listener      *listener.Listener
-	authenticator *auth.Authenticator
+	router             adapter.ConnectionRouterEx
+	logger             logger.ContextLogger
+	listener           *listener.Listener
+	authenticator      *auth.Authenticator
+	speedLimiters      map[string]adapter.SpeedLimiter
+	connectionLimiters map[string]adapter.ConnectionLimiter
+	trafficQuotas      *trafficquota.Registration
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.SocksInboundOptions) (adapter.Inbound, error) {
 	inbound := &Inbound{
-		Adapter:       inbound.NewAdapter(C.TypeSOCKS, tag),
-		router:        uot.NewRouter(router, logger),
-		logger:        logger,
-		authenticator: auth.NewAuthenticator(options.Users),
+		Adapter:            inbound.NewAdapter(C.TypeSOCKS, tag),
+		router:             uot.NewRouter(router, logger),
+		logger:             logger,
+		authenticator:      auth.NewAuthenticator(options.Users),
+		speedLimiters:      make(map[string]adapter.SpeedLimiter),
+		connectionLimiters: make(map[string]adapter.ConnectionLimiter),
+	}
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
+	for index, user := range options.Users {
+		userOptions := options.UserOption(index)
+		if limiter := speedlimit.New(userOptions.SpeedLimit); limiter != nil {
+			inbound.speedLimiters[user.Username] = limiter
+		}
+		if limiter := connlimit.New(userOptions.MaxConnections, userOptions.MaxDevices); limiter != nil {
+			inbound.connectionLimiters[user.Username] = limiter
+		}
+		err := inbound.trafficQuotas.Register(user.Username, userOptions.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
 	}
 	inbound.listener = listener.New(listener.Options{
 		Context:           ctx,
@@ -58,7 +82,10 @@ func (h *Inbound) Start(stage adapter.StartStage) error {
 }
 
 func (h *Inbound) Close() error {
-	return h.listener.Close()
+	return common.Close(
+		h.listener,
+		h.trafficQuotas,
+	)
 }
 
 func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, metadata adapter.InboundContext, onClose N.CloseHandlerFunc) {
@@ -83,6 +110,8 @@ func (h *Inbound) newUserConnection(ctx context.Context, conn net.Conn, metadata
 		return
 	}
 	metadata.User = user
+	metadata.UserSpeedLimiter = h.speedLimiters[user]
+	metadata.UserConnectionLimiter = h.connectionLimiters[user]
 	h.logger.InfoContext(ctx, "[", user, "] inbound connection to ", metadata.Destination)
 	h.router.RouteConnectionEx(ctx, conn, metadata, onClose)
 }
@@ -101,6 +130,8 @@ func (h *Inbound) streamUserPacketConnection(ctx context.Context, conn N.PacketC
 		return
 	}
 	metadata.User = user
+	metadata.UserSpeedLimiter = h.speedLimiters[user]
+	metadata.UserConnectionLimiter = h.connectionLimiters[user]
 	if !metadata.Destination.IsValid() {
 		h.logger.InfoContext(ctx, "[", user, "] inbound packet connection")
 	} else {
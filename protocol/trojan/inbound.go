@@ -7,9 +7,12 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
@@ -36,6 +39,9 @@ type Inbound struct {
 	listener                 *listener.Listener
 	service                  *trojan.Service[int]
 	users                    []option.TrojanUser
+	speedLimiters            []adapter.SpeedLimiter
+	connectionLimiters       []adapter.ConnectionLimiter
+	trafficQuotas            *trafficquota.Registration
 	tlsConfig                tls.ServerConfig
 	fallbackAddr             M.Socksaddr
 	fallbackAddrTLSNextProto map[string]M.Socksaddr
@@ -48,6 +54,20 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		router:  router,
 		logger:  logger,
 		users:   options.Users,
+		speedLimiters: common.Map(options.Users, func(it option.TrojanUser) adapter.SpeedLimiter {
+			return speedlimit.New(it.SpeedLimit)
+		}),
+		connectionLimiters: common.Map(options.Users, func(it option.TrojanUser) adapter.ConnectionLimiter {
+			return connlimit.New(it.MaxConnections, it.MaxDevices)
+		}),
+	}
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
+	for _, user := range options.Users {
+		err := inbound.trafficQuotas.Register(user.Name, user.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
 	}
 	if options.TLS != nil {
 		tlsConfig, err := tls.NewServerWithOptions(tls.ServerOptions{
@@ -161,6 +181,7 @@ func (h *Inbound) Close() error {
 		h.listener,
 		h.tlsConfig,
 		h.transport,
+		h.trafficQuotas,
 	)
 }
 
@@ -189,6 +210,8 @@ func (h *Inbound) newConnection(ctx context.Context, conn net.Conn, metadata ada
 		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
 		return
 	}
+	metadata.UserSpeedLimiter = h.speedLimiters[userIndex]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userIndex]
 	user := h.users[userIndex].Name
 	if user == "" {
 		user = F.ToString(userIndex)
@@ -207,6 +230,8 @@ func (h *Inbound) newPacketConnection(ctx context.Context, conn N.PacketConn, me
 		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
 		return
 	}
+	metadata.UserSpeedLimiter = h.speedLimiters[userIndex]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userIndex]
 	user := h.users[userIndex].Name
 	if user == "" {
 		user = F.ToString(userIndex)
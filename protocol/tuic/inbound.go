@@ -7,8 +7,11 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	"github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -29,12 +32,15 @@ func RegisterInbound(registry *inbound.Registry) {
 
 type Inbound struct {
 	inbound.Adapter
-	router       adapter.ConnectionRouterEx
-	logger       log.ContextLogger
-	listener     *listener.Listener
-	tlsConfig    tls.ServerConfig
-	server       *tuic.Service[int]
-	userNameList []string
+	router             adapter.ConnectionRouterEx
+	logger             log.ContextLogger
+	listener           *listener.Listener
+	tlsConfig          tls.ServerConfig
+	server             *tuic.Service[int]
+	userNameList       []string
+	speedLimiters      []adapter.SpeedLimiter
+	connectionLimiters []adapter.ConnectionLimiter
+	trafficQuotas      *trafficquota.Registration
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.TUICInboundOptions) (adapter.Inbound, error) {
@@ -81,6 +87,9 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 	var userNameList []string
 	var userUUIDList [][16]byte
 	var userPasswordList []string
+	var speedLimiters []adapter.SpeedLimiter
+	var connectionLimiters []adapter.ConnectionLimiter
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
 	for index, user := range options.Users {
 		if user.UUID == "" {
 			return nil, E.New("missing uuid for user ", index)
@@ -93,10 +102,19 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		userNameList = append(userNameList, user.Name)
 		userUUIDList = append(userUUIDList, userUUID)
 		userPasswordList = append(userPasswordList, user.Password)
+		speedLimiters = append(speedLimiters, speedlimit.New(user.SpeedLimit))
+		connectionLimiters = append(connectionLimiters, connlimit.New(user.MaxConnections, user.MaxDevices))
+		err = inbound.trafficQuotas.Register(user.Name, user.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
 	}
 	service.UpdateUsers(userList, userUUIDList, userPasswordList)
 	inbound.server = service
 	inbound.userNameList = userNameList
+	inbound.speedLimiters = speedLimiters
+	inbound.connectionLimiters = connectionLimiters
 	return inbound, nil
 }
 
@@ -114,6 +132,8 @@ func (h *Inbound) NewConnectionEx(ctx context.Context, conn net.Conn, source M.S
 	metadata.Destination = destination
 	h.logger.InfoContext(ctx, "inbound connection from ", metadata.Source)
 	userID, _ := auth.UserFromContext[int](ctx)
+	metadata.UserSpeedLimiter = h.speedLimiters[userID]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userID]
 	if userName := h.userNameList[userID]; userName != "" {
 		metadata.User = userName
 		h.logger.InfoContext(ctx, "[", userName, "] inbound connection to ", metadata.Destination)
@@ -137,6 +157,8 @@ func (h *Inbound) NewPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 	metadata.Destination = destination
 	h.logger.InfoContext(ctx, "inbound packet connection from ", metadata.Source)
 	userID, _ := auth.UserFromContext[int](ctx)
+	metadata.UserSpeedLimiter = h.speedLimiters[userID]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userID]
 	if userName := h.userNameList[userID]; userName != "" {
 		metadata.User = userName
 		h.logger.InfoContext(ctx, "[", userName, "] inbound packet connection to ", metadata.Destination)
@@ -168,5 +190,6 @@ func (h *Inbound) Close() error {
 		h.listener,
 		h.tlsConfig,
 		common.PtrOrNil(h.server),
+		h.trafficQuotas,
 	)
 }
@@ -7,9 +7,12 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	"github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -35,14 +38,17 @@ var _ adapter.TCPInjectableInbound = (*Inbound)(nil)
 
 type Inbound struct {
 	inbound.Adapter
-	ctx       context.Context
-	router    adapter.ConnectionRouterEx
-	logger    logger.ContextLogger
-	listener  *listener.Listener
-	users     []option.VLESSUser
-	service   *vless.Service[int]
-	tlsConfig tls.ServerConfig
-	transport adapter.V2RayServerTransport
+	ctx                context.Context
+	router             adapter.ConnectionRouterEx
+	logger             logger.ContextLogger
+	listener           *listener.Listener
+	users              []option.VLESSUser
+	speedLimiters      []adapter.SpeedLimiter
+	connectionLimiters []adapter.ConnectionLimiter
+	trafficQuotas      *trafficquota.Registration
+	service            *vless.Service[int]
+	tlsConfig          tls.ServerConfig
+	transport          adapter.V2RayServerTransport
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.VLESSInboundOptions) (adapter.Inbound, error) {
@@ -52,6 +58,20 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		router:  uot.NewRouter(router, logger),
 		logger:  logger,
 		users:   options.Users,
+		speedLimiters: common.Map(options.Users, func(it option.VLESSUser) adapter.SpeedLimiter {
+			return speedlimit.New(it.SpeedLimit)
+		}),
+		connectionLimiters: common.Map(options.Users, func(it option.VLESSUser) adapter.ConnectionLimiter {
+			return connlimit.New(it.MaxConnections, it.MaxDevices)
+		}),
+	}
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
+	for _, user := range options.Users {
+		err := inbound.trafficQuotas.Register(user.Name, user.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
 	}
 	var err error
 	inbound.router, err = mux.NewRouterWithOptions(inbound.router, logger, common.PtrValueOrDefault(options.Multiplex))
@@ -144,6 +164,7 @@ func (h *Inbound) Close() error {
 		h.listener,
 		h.tlsConfig,
 		h.transport,
+		h.trafficQuotas,
 	)
 }
 
@@ -172,6 +193,8 @@ func (h *Inbound) newConnectionEx(ctx context.Context, conn net.Conn, metadata a
 		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
 		return
 	}
+	metadata.UserSpeedLimiter = h.speedLimiters[userIndex]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userIndex]
 	user := h.users[userIndex].Name
 	if user == "" {
 		user = F.ToString(userIndex)
@@ -190,6 +213,8 @@ func (h *Inbound) newPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
 		return
 	}
+	metadata.UserSpeedLimiter = h.speedLimiters[userIndex]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userIndex]
 	user := h.users[userIndex].Name
 	if user == "" {
 		user = F.ToString(userIndex)
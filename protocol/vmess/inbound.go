@@ -7,9 +7,12 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/adapter/inbound"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/listener"
 	"github.com/sagernet/sing-box/common/mux"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	"github.com/sagernet/sing-box/common/tls"
+	"github.com/sagernet/sing-box/common/trafficquota"
 	"github.com/sagernet/sing-box/common/uot"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
@@ -36,14 +39,17 @@ var _ adapter.TCPInjectableInbound = (*Inbound)(nil)
 
 type Inbound struct {
 	inbound.Adapter
-	ctx       context.Context
-	router    adapter.ConnectionRouterEx
-	logger    logger.ContextLogger
-	listener  *listener.Listener
-	service   *vmess.Service[int]
-	users     []option.VMessUser
-	tlsConfig tls.ServerConfig
-	transport adapter.V2RayServerTransport
+	ctx                context.Context
+	router             adapter.ConnectionRouterEx
+	logger             logger.ContextLogger
+	listener           *listener.Listener
+	service            *vmess.Service[int]
+	users              []option.VMessUser
+	speedLimiters      []adapter.SpeedLimiter
+	connectionLimiters []adapter.ConnectionLimiter
+	trafficQuotas      *trafficquota.Registration
+	tlsConfig          tls.ServerConfig
+	transport          adapter.V2RayServerTransport
 }
 
 func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLogger, tag string, options option.VMessInboundOptions) (adapter.Inbound, error) {
@@ -53,6 +59,20 @@ func NewInbound(ctx context.Context, router adapter.Router, logger log.ContextLo
 		router:  uot.NewRouter(router, logger),
 		logger:  logger,
 		users:   options.Users,
+		speedLimiters: common.Map(options.Users, func(it option.VMessUser) adapter.SpeedLimiter {
+			return speedlimit.New(it.SpeedLimit)
+		}),
+		connectionLimiters: common.Map(options.Users, func(it option.VMessUser) adapter.ConnectionLimiter {
+			return connlimit.New(it.MaxConnections, it.MaxDevices)
+		}),
+	}
+	inbound.trafficQuotas = trafficquota.NewRegistration(ctx, tag)
+	for _, user := range options.Users {
+		err := inbound.trafficQuotas.Register(user.Name, user.TrafficQuota)
+		if err != nil {
+			inbound.trafficQuotas.Close()
+			return nil, err
+		}
 	}
 	var err error
 	inbound.router, err = mux.NewRouterWithOptions(inbound.router, logger, common.PtrValueOrDefault(options.Multiplex))
@@ -150,6 +170,7 @@ func (h *Inbound) Close() error {
 		h.listener,
 		h.tlsConfig,
 		h.transport,
+		h.trafficQuotas,
 	)
 }
 
@@ -178,6 +199,8 @@ func (h *Inbound) newConnectionEx(ctx context.Context, conn net.Conn, metadata a
 		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
 		return
 	}
+	metadata.UserSpeedLimiter = h.speedLimiters[userIndex]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userIndex]
 	user := h.users[userIndex].Name
 	if user == "" {
 		user = F.ToString(userIndex)
@@ -196,6 +219,8 @@ func (h *Inbound) newPacketConnectionEx(ctx context.Context, conn N.PacketConn,
 		N.CloseOnHandshakeFailure(conn, onClose, os.ErrInvalid)
 		return
 	}
+	metadata.UserSpeedLimiter = h.speedLimiters[userIndex]
+	metadata.UserConnectionLimiter = h.connectionLimiters[userIndex]
 	user := h.users[userIndex].Name
 	if user == "" {
 		user = F.ToString(userIndex)
@@ -0,0 +1,435 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	"github.com/sagernet/sing/common/json/badoption"
+)
+
+func parseLink(link string) (option.Outbound, error) {
+	scheme, _, found := strings.Cut(link, "://")
+	if !found {
+		return option.Outbound{}, E.New("invalid link")
+	}
+	switch strings.ToLower(scheme) {
+	case "ss":
+		return parseShadowsocksLink(link)
+	case "vmess":
+		return parseVMessLink(link)
+	case "vless":
+		return parseVLESSLink(link)
+	case "trojan":
+		return parseTrojanLink(link)
+	case "hysteria2", "hy2":
+		return parseHysteria2Link(link)
+	case "tuic":
+		return parseTUICLink(link)
+	default:
+		return option.Outbound{}, E.New("unsupported link scheme: ", scheme)
+	}
+}
+
+func parseShadowsocksLink(link string) (option.Outbound, error) {
+	var (
+		options option.ShadowsocksOutboundOptions
+		tag     string
+	)
+	linkURL, err := url.Parse(link)
+	if err == nil && linkURL.User != nil {
+		options.ServerOptions, err = parseLinkServer(linkURL)
+		if err != nil {
+			return option.Outbound{}, err
+		}
+		if password, loaded := linkURL.User.Password(); loaded {
+			options.Method = linkURL.User.Username()
+			options.Password = password
+		} else {
+			userInfo, err := decodeBase64(linkURL.User.Username())
+			if err != nil {
+				return option.Outbound{}, E.Cause(err, "decode user info")
+			}
+			options.Method, options.Password, _ = strings.Cut(userInfo, ":")
+		}
+		if plugin := linkURL.Query().Get("plugin"); plugin != "" {
+			options.Plugin, options.PluginOptions, _ = strings.Cut(plugin, ";")
+		}
+		tag = linkURL.Fragment
+	} else {
+		// legacy format: ss://base64(method:password@server:port)#tag
+		content, fragment, _ := strings.Cut(strings.TrimPrefix(link, "ss://"), "#")
+		tag, _ = url.PathUnescape(fragment)
+		decoded, err := decodeBase64(content)
+		if err != nil {
+			return option.Outbound{}, E.Cause(err, "decode link")
+		}
+		index := strings.LastIndex(decoded, "@")
+		if index == -1 {
+			return option.Outbound{}, E.New("missing server address")
+		}
+		options.Method, options.Password, _ = strings.Cut(decoded[:index], ":")
+		options.ServerOptions, err = parseServerAddress(decoded[index+1:])
+		if err != nil {
+			return option.Outbound{}, err
+		}
+	}
+	if options.Method == "" {
+		return option.Outbound{}, E.New("missing method")
+	}
+	return option.Outbound{
+		Type:    C.TypeShadowsocks,
+		Tag:     linkTag(tag, options.ServerOptions),
+		Options: &options,
+	}, nil
+}
+
+func parseVMessLink(link string) (option.Outbound, error) {
+	content, err := decodeBase64(strings.TrimPrefix(link, "vmess://"))
+	if err != nil {
+		return option.Outbound{}, E.Cause(err, "decode link")
+	}
+	var rawLink map[string]any
+	err = json.Unmarshal([]byte(content), &rawLink)
+	if err != nil {
+		return option.Outbound{}, E.Cause(err, "parse link")
+	}
+	field := func(name string) string {
+		value, loaded := rawLink[name]
+		if !loaded || value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	var options option.VMessOutboundOptions
+	options.ServerOptions, err = parseServerAddress(net.JoinHostPort(field("add"), field("port")))
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	options.UUID = field("id")
+	options.Security = field("scy")
+	if options.Security == "" {
+		options.Security = "auto"
+	}
+	if alterID := field("aid"); alterID != "" {
+		options.AlterId, err = strconv.Atoi(alterID)
+		if err != nil {
+			return option.Outbound{}, E.Cause(err, "parse alter id")
+		}
+	}
+	network := field("net")
+	if network == "grpc" {
+		options.Transport, err = parseLinkTransport(network, field("host"), field("path"), field("path"))
+	} else {
+		options.Transport, err = parseLinkTransport(network, field("host"), field("path"), "")
+	}
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	if field("tls") == "tls" {
+		options.TLS = &option.OutboundTLSOptions{
+			Enabled:    true,
+			ServerName: field("sni"),
+			Insecure:   linkBool(field("allowInsecure")),
+		}
+		if alpn := field("alpn"); alpn != "" {
+			options.TLS.ALPN = strings.Split(alpn, ",")
+		}
+		if fingerprint := field("fp"); fingerprint != "" {
+			options.TLS.UTLS = &option.OutboundUTLSOptions{
+				Enabled:     true,
+				Fingerprint: fingerprint,
+			}
+		}
+	}
+	return option.Outbound{
+		Type:    C.TypeVMess,
+		Tag:     linkTag(field("ps"), options.ServerOptions),
+		Options: &options,
+	}, nil
+}
+
+func parseVLESSLink(link string) (option.Outbound, error) {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	var options option.VLESSOutboundOptions
+	options.ServerOptions, err = parseLinkServer(linkURL)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	if linkURL.User == nil {
+		return option.Outbound{}, E.New("missing uuid")
+	}
+	options.UUID = linkURL.User.Username()
+	query := linkURL.Query()
+	options.Flow = query.Get("flow")
+	options.TLS = parseLinkTLS(query, false)
+	options.Transport, err = parseLinkTransport(query.Get("type"), query.Get("host"), query.Get("path"), query.Get("serviceName"))
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	if packetEncoding := query.Get("packetEncoding"); packetEncoding != "" {
+		options.PacketEncoding = &packetEncoding
+	}
+	return option.Outbound{
+		Type:    C.TypeVLESS,
+		Tag:     linkTag(linkURL.Fragment, options.ServerOptions),
+		Options: &options,
+	}, nil
+}
+
+func parseTrojanLink(link string) (option.Outbound, error) {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	var options option.TrojanOutboundOptions
+	options.ServerOptions, err = parseLinkServer(linkURL)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	if linkURL.User == nil {
+		return option.Outbound{}, E.New("missing password")
+	}
+	options.Password = linkURL.User.Username()
+	query := linkURL.Query()
+	options.TLS = parseLinkTLS(query, true)
+	options.Transport, err = parseLinkTransport(query.Get("type"), query.Get("host"), query.Get("path"), query.Get("serviceName"))
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	return option.Outbound{
+		Type:    C.TypeTrojan,
+		Tag:     linkTag(linkURL.Fragment, options.ServerOptions),
+		Options: &options,
+	}, nil
+}
+
+func parseHysteria2Link(link string) (option.Outbound, error) {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	var options option.Hysteria2OutboundOptions
+	options.ServerOptions, err = parseLinkServer(linkURL)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	if linkURL.User != nil {
+		options.Password = linkURL.User.Username()
+		if password, loaded := linkURL.User.Password(); loaded {
+			options.Password += ":" + password
+		}
+	}
+	query := linkURL.Query()
+	if portRange := query.Get("mport"); portRange != "" {
+		for _, ports := range strings.Split(portRange, ",") {
+			options.ServerPorts = append(options.ServerPorts, strings.ReplaceAll(ports, "-", ":"))
+		}
+	}
+	if obfs := query.Get("obfs"); obfs != "" {
+		options.Obfs = &option.Hysteria2Obfs{
+			Type:     obfs,
+			Password: query.Get("obfs-password"),
+		}
+	}
+	options.TLS = &option.OutboundTLSOptions{
+		Enabled:    true,
+		ServerName: query.Get("sni"),
+		Insecure:   linkBool(query.Get("insecure")),
+	}
+	return option.Outbound{
+		Type:    C.TypeHysteria2,
+		Tag:     linkTag(linkURL.Fragment, options.ServerOptions),
+		Options: &options,
+	}, nil
+}
+
+func parseTUICLink(link string) (option.Outbound, error) {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	var options option.TUICOutboundOptions
+	options.ServerOptions, err = parseLinkServer(linkURL)
+	if err != nil {
+		return option.Outbound{}, err
+	}
+	if linkURL.User == nil {
+		return option.Outbound{}, E.New("missing uuid")
+	}
+	options.UUID = linkURL.User.Username()
+	options.Password, _ = linkURL.User.Password()
+	query := linkURL.Query()
+	options.CongestionControl = query.Get("congestion_control")
+	options.UDPRelayMode = query.Get("udp_relay_mode")
+	options.TLS = &option.OutboundTLSOptions{
+		Enabled:    true,
+		DisableSNI: linkBool(query.Get("disable_sni")),
+		ServerName: query.Get("sni"),
+		Insecure:   linkBool(query.Get("allow_insecure")) || linkBool(query.Get("insecure")),
+	}
+	if alpn := query.Get("alpn"); alpn != "" {
+		options.TLS.ALPN = strings.Split(alpn, ",")
+	}
+	return option.Outbound{
+		Type:    C.TypeTUIC,
+		Tag:     linkTag(linkURL.Fragment, options.ServerOptions),
+		Options: &options,
+	}, nil
+}
+
+func parseLinkTLS(query url.Values, defaultEnabled bool) *option.OutboundTLSOptions {
+	security := query.Get("security")
+	switch security {
+	case "tls", "reality", "xtls":
+	case "":
+		if !defaultEnabled {
+			return nil
+		}
+	default:
+		return nil
+	}
+	options := &option.OutboundTLSOptions{
+		Enabled:    true,
+		ServerName: query.Get("sni"),
+		Insecure:   linkBool(query.Get("allowInsecure")) || linkBool(query.Get("insecure")),
+	}
+	if options.ServerName == "" {
+		options.ServerName = query.Get("peer")
+	}
+	if alpn := query.Get("alpn"); alpn != "" {
+		options.ALPN = strings.Split(alpn, ",")
+	}
+	if fingerprint := query.Get("fp"); fingerprint != "" {
+		options.UTLS = &option.OutboundUTLSOptions{
+			Enabled:     true,
+			Fingerprint: fingerprint,
+		}
+	}
+	if security == "reality" {
+		options.Reality = &option.OutboundRealityOptions{
+			Enabled:   true,
+			PublicKey: query.Get("pbk"),
+			ShortID:   query.Get("sid"),
+		}
+	}
+	return options
+}
+
+func parseLinkTransport(network string, host string, path string, serviceName string) (*option.V2RayTransportOptions, error) {
+	switch network {
+	case "", "tcp", "raw":
+		return nil, nil
+	case C.V2RayTransportTypeWebsocket:
+		options := option.V2RayWebsocketOptions{
+			Path: path,
+		}
+		if host != "" {
+			options.Headers = badoption.HTTPHeader{"Host": {host}}
+		}
+		// early data is encoded into the path as ?ed=<length>
+		if wsPath, rawQuery, found := strings.Cut(path, "?"); found {
+			query, _ := url.ParseQuery(rawQuery)
+			if earlyData, err := strconv.ParseUint(query.Get("ed"), 10, 32); err == nil {
+				options.Path = wsPath
+				options.MaxEarlyData = uint32(earlyData)
+				options.EarlyDataHeaderName = "Sec-WebSocket-Protocol"
+			}
+		}
+		return &option.V2RayTransportOptions{
+			Type:             C.V2RayTransportTypeWebsocket,
+			WebsocketOptions: options,
+		}, nil
+	case C.V2RayTransportTypeGRPC:
+		return &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeGRPC,
+			GRPCOptions: option.V2RayGRPCOptions{
+				ServiceName: serviceName,
+			},
+		}, nil
+	case C.V2RayTransportTypeHTTP, "h2":
+		options := option.V2RayHTTPOptions{
+			Path: path,
+		}
+		if host != "" {
+			options.Host = strings.Split(host, ",")
+		}
+		return &option.V2RayTransportOptions{
+			Type:        C.V2RayTransportTypeHTTP,
+			HTTPOptions: options,
+		}, nil
+	case C.V2RayTransportTypeHTTPUpgrade:
+		return &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeHTTPUpgrade,
+			HTTPUpgradeOptions: option.V2RayHTTPUpgradeOptions{
+				Host: host,
+				Path: path,
+			},
+		}, nil
+	case C.V2RayTransportTypeQUIC:
+		return &option.V2RayTransportOptions{
+			Type: C.V2RayTransportTypeQUIC,
+		}, nil
+	default:
+		return nil, E.New("unsupported transport: ", network)
+	}
+}
+
+func parseLinkServer(linkURL *url.URL) (option.ServerOptions, error) {
+	port, err := strconv.ParseUint(linkURL.Port(), 10, 16)
+	if err != nil {
+		return option.ServerOptions{}, E.Cause(err, "parse server port")
+	}
+	return option.ServerOptions{
+		Server:     linkURL.Hostname(),
+		ServerPort: uint16(port),
+	}, nil
+}
+
+func parseServerAddress(address string) (option.ServerOptions, error) {
+	host, portString, err := net.SplitHostPort(address)
+	if err != nil {
+		return option.ServerOptions{}, E.Cause(err, "parse server address")
+	}
+	port, err := strconv.ParseUint(portString, 10, 16)
+	if err != nil {
+		return option.ServerOptions{}, E.Cause(err, "parse server port")
+	}
+	return option.ServerOptions{
+		Server:     host,
+		ServerPort: uint16(port),
+	}, nil
+}
+
+func linkTag(tag string, server option.ServerOptions) string {
+	if tag != "" {
+		return tag
+	}
+	return net.JoinHostPort(server.Server, F.ToString(server.ServerPort))
+}
+
+func linkBool(value string) bool {
+	return value == "1" || value == "true"
+}
+
+func decodeBase64(content string) (string, error) {
+	content = strings.Join(strings.Fields(content), "")
+	content = strings.TrimRight(content, "=")
+	content = strings.NewReplacer("-", "+", "_", "/").Replace(content)
+	decoded, err := base64.RawStdEncoding.DecodeString(content)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
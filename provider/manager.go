@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/taskmonitor"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ adapter.OutboundProviderManager = (*Manager)(nil)
+
+type Manager struct {
+	logger        log.ContextLogger
+	providers     []*RemoteProvider
+	providerByTag map[string]*RemoteProvider
+}
+
+func NewManager(ctx context.Context, logFactory log.Factory, options []option.OutboundProvider) (*Manager, error) {
+	manager := &Manager{
+		logger:        logFactory.NewLogger("outbound-provider"),
+		providerByTag: make(map[string]*RemoteProvider),
+	}
+	for i, providerOptions := range options {
+		if providerOptions.Tag == "" {
+			return nil, E.New("missing tag for outbound provider[", i, "]")
+		}
+		if _, loaded := manager.providerByTag[providerOptions.Tag]; loaded {
+			return nil, E.New("duplicate outbound provider tag: ", providerOptions.Tag)
+		}
+		provider, err := NewRemoteProvider(ctx, logFactory, providerOptions)
+		if err != nil {
+			return nil, E.Cause(err, "initialize outbound provider[", providerOptions.Tag, "]")
+		}
+		manager.providers = append(manager.providers, provider)
+		manager.providerByTag[providerOptions.Tag] = provider
+	}
+	return manager, nil
+}
+
+func (m *Manager) Start(stage adapter.StartStage) error {
+	monitor := taskmonitor.New(m.logger, C.StartTimeout)
+	for _, provider := range m.providers {
+		name := "outbound-provider[" + provider.Tag() + "]"
+		m.logger.Trace(stage, " ", name)
+		startTime := time.Now()
+		monitor.Start(stage, " ", name)
+		err := provider.Start(stage)
+		monitor.Finish()
+		if err != nil {
+			return E.Cause(err, stage, " ", name)
+		}
+		m.logger.Trace(stage, " ", name, " completed (", F.Seconds(time.Since(startTime).Seconds()), "s)")
+	}
+	return nil
+}
+
+func (m *Manager) Close() error {
+	var err error
+	for _, provider := range m.providers {
+		err = E.Append(err, provider.Close(), func(err error) error {
+			return E.Cause(err, "close outbound-provider[", provider.Tag(), "]")
+		})
+	}
+	return err
+}
+
+func (m *Manager) OutboundProviders() []adapter.OutboundProvider {
+	return common.Map(m.providers, func(it *RemoteProvider) adapter.OutboundProvider {
+		return it
+	})
+}
+
+func (m *Manager) OutboundProvider(tag string) (adapter.OutboundProvider, bool) {
+	provider, loaded := m.providerByTag[tag]
+	if !loaded {
+		return nil, false
+	}
+	return provider, true
+}
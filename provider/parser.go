@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/json"
+)
+
+// parseOutbounds accepts a sing-box configuration, a list of outbounds,
+// or share links separated by lines, optionally encoded in base64.
+// Links that fail to parse are returned as errors alongside the parsed outbounds.
+func parseOutbounds(ctx context.Context, content []byte) ([]option.Outbound, []error, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, nil, E.New("empty content")
+	}
+	switch content[0] {
+	case '{':
+		options, err := json.UnmarshalExtendedContext[option.Options](ctx, content)
+		if err != nil {
+			return nil, nil, E.Cause(err, "decode configuration")
+		}
+		return filterSpecialOutbounds(options.Outbounds), nil, nil
+	case '[':
+		outbounds, err := json.UnmarshalExtendedContext[[]option.Outbound](ctx, content)
+		if err != nil {
+			return nil, nil, E.Cause(err, "decode outbounds")
+		}
+		return filterSpecialOutbounds(outbounds), nil, nil
+	}
+	links := string(content)
+	if !strings.Contains(links, "://") {
+		decoded, err := decodeBase64(links)
+		if err != nil {
+			return nil, nil, E.Cause(err, "decode links")
+		}
+		links = decoded
+	}
+	var (
+		outbounds []option.Outbound
+		errors    []error
+	)
+	for _, link := range strings.Split(links, "\n") {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			continue
+		}
+		outbound, err := parseLink(link)
+		if err != nil {
+			errors = append(errors, E.Cause(err, "parse link: ", link))
+			continue
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	if len(outbounds) == 0 && len(errors) > 0 {
+		return nil, nil, E.Errors(errors...)
+	}
+	return outbounds, errors, nil
+}
+
+// filterSpecialOutbounds removes groups and built-in outbounds,
+// which reference other outbounds of the configuration they came from.
+func filterSpecialOutbounds(outbounds []option.Outbound) []option.Outbound {
+	var filtered []option.Outbound
+	for _, outbound := range outbounds {
+		switch outbound.Type {
+		case C.TypeSelector, C.TypeURLTest, C.TypeLoadBalance, C.TypeFallback, C.TypeDirect, C.TypeBlock, C.TypeDNS:
+			continue
+		}
+		filtered = append(filtered, outbound)
+	}
+	return filtered
+}
@@ -0,0 +1,356 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/log"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	"github.com/sagernet/sing/common/json"
+	M "github.com/sagernet/sing/common/metadata"
+	N "github.com/sagernet/sing/common/network"
+	"github.com/sagernet/sing/common/ntp"
+	"github.com/sagernet/sing/common/x/list"
+	"github.com/sagernet/sing/service"
+)
+
+var _ adapter.OutboundProvider = (*RemoteProvider)(nil)
+
+type RemoteProvider struct {
+	ctx            context.Context
+	cancel         context.CancelFunc
+	logger         log.ContextLogger
+	logFactory     log.Factory
+	router         adapter.Router
+	outbound       adapter.OutboundManager
+	options        option.OutboundProvider
+	filter         []*regexp.Regexp
+	exclude        []*regexp.Regexp
+	updateInterval time.Duration
+	updateAccess   sync.Mutex
+	dialer         N.Dialer
+	cacheFile      adapter.CacheFile
+	access         sync.RWMutex
+	outbounds      []adapter.Outbound
+	outboundByTag  map[string]adapter.Outbound
+	contentByTag   map[string][]byte
+	lastUpdated    time.Time
+	lastEtag       string
+	updateTicker   *time.Ticker
+	callbacks      list.List[adapter.OutboundProviderUpdateCallback]
+}
+
+func NewRemoteProvider(ctx context.Context, logFactory log.Factory, options option.OutboundProvider) (*RemoteProvider, error) {
+	if options.URL == "" {
+		return nil, E.New("missing url")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	var updateInterval time.Duration
+	if options.UpdateInterval > 0 {
+		updateInterval = time.Duration(options.UpdateInterval)
+	} else {
+		updateInterval = 24 * time.Hour
+	}
+	provider := &RemoteProvider{
+		ctx:            ctx,
+		cancel:         cancel,
+		logger:         logFactory.NewLogger(F.ToString("outbound-provider[", options.Tag, "]")),
+		logFactory:     logFactory,
+		router:         service.FromContext[adapter.Router](ctx),
+		outbound:       service.FromContext[adapter.OutboundManager](ctx),
+		options:        options,
+		updateInterval: updateInterval,
+		outboundByTag:  make(map[string]adapter.Outbound),
+		contentByTag:   make(map[string][]byte),
+	}
+	for i, expression := range options.Filter {
+		filter, err := regexp.Compile(expression)
+		if err != nil {
+			cancel()
+			return nil, E.Cause(err, "parse filter[", i, "]")
+		}
+		provider.filter = append(provider.filter, filter)
+	}
+	for i, expression := range options.Exclude {
+		exclude, err := regexp.Compile(expression)
+		if err != nil {
+			cancel()
+			return nil, E.Cause(err, "parse exclude[", i, "]")
+		}
+		provider.exclude = append(provider.exclude, exclude)
+	}
+	return provider, nil
+}
+
+func (s *RemoteProvider) Tag() string {
+	return s.options.Tag
+}
+
+func (s *RemoteProvider) Start(stage adapter.StartStage) error {
+	switch stage {
+	case adapter.StartStateInitialize:
+		s.cacheFile = service.FromContext[adapter.CacheFile](s.ctx)
+		if s.cacheFile != nil {
+			if savedProvider := s.cacheFile.LoadOutboundProvider(s.options.Tag); savedProvider != nil {
+				err := s.loadBytes(savedProvider.Content)
+				if err != nil {
+					return E.Cause(err, "restore cached outbound provider")
+				}
+				s.lastUpdated = savedProvider.LastUpdated
+				s.lastEtag = savedProvider.LastEtag
+			}
+		}
+	case adapter.StartStatePostStart:
+		if s.options.DownloadDetour != "" {
+			outbound, loaded := s.outbound.Outbound(s.options.DownloadDetour)
+			if !loaded {
+				return E.New("download detour not found: ", s.options.DownloadDetour)
+			}
+			s.dialer = outbound
+		} else {
+			s.dialer = s.outbound.Default()
+		}
+		s.updateTicker = time.NewTicker(s.updateInterval)
+		go s.loopUpdate()
+	}
+	return nil
+}
+
+func (s *RemoteProvider) Outbounds() []adapter.Outbound {
+	s.access.RLock()
+	defer s.access.RUnlock()
+	return s.outbounds
+}
+
+func (s *RemoteProvider) UpdatedAt() time.Time {
+	s.access.RLock()
+	defer s.access.RUnlock()
+	return s.lastUpdated
+}
+
+func (s *RemoteProvider) Update(ctx context.Context) error {
+	return s.fetch(ctx)
+}
+
+func (s *RemoteProvider) RegisterCallback(callback adapter.OutboundProviderUpdateCallback) *list.Element[adapter.OutboundProviderUpdateCallback] {
+	s.access.Lock()
+	defer s.access.Unlock()
+	return s.callbacks.PushBack(callback)
+}
+
+func (s *RemoteProvider) UnregisterCallback(element *list.Element[adapter.OutboundProviderUpdateCallback]) {
+	s.access.Lock()
+	defer s.access.Unlock()
+	s.callbacks.Remove(element)
+}
+
+func (s *RemoteProvider) loopUpdate() {
+	if time.Since(s.UpdatedAt()) > s.updateInterval {
+		s.updateOnce()
+	}
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.updateTicker.C:
+			s.updateOnce()
+		}
+	}
+}
+
+func (s *RemoteProvider) updateOnce() {
+	err := s.fetch(s.ctx)
+	if err != nil {
+		s.logger.Error("fetch outbound provider ", s.options.Tag, ": ", err)
+	}
+}
+
+func (s *RemoteProvider) fetch(ctx context.Context) error {
+	s.updateAccess.Lock()
+	defer s.updateAccess.Unlock()
+	s.logger.Debug("updating outbound provider ", s.options.Tag, " from URL: ", s.options.URL)
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: C.TCPTimeout,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return s.dialer.DialContext(ctx, network, M.ParseSocksaddr(addr))
+			},
+			TLSClientConfig: &tls.Config{
+				Time:    ntp.TimeFuncFromContext(s.ctx),
+				RootCAs: adapter.RootPoolFromContext(s.ctx),
+			},
+		},
+	}
+	defer httpClient.CloseIdleConnections()
+	request, err := http.NewRequest("GET", s.options.URL, nil)
+	if err != nil {
+		return err
+	}
+	if s.lastEtag != "" {
+		request.Header.Set("If-None-Match", s.lastEtag)
+	}
+	response, err := httpClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		s.access.Lock()
+		s.lastUpdated = time.Now()
+		s.access.Unlock()
+		if s.cacheFile != nil {
+			savedProvider := s.cacheFile.LoadOutboundProvider(s.options.Tag)
+			if savedProvider != nil {
+				savedProvider.LastUpdated = s.lastUpdated
+				err = s.cacheFile.SaveOutboundProvider(s.options.Tag, savedProvider)
+				if err != nil {
+					s.logger.Error("save outbound provider updated time: ", err)
+					return nil
+				}
+			}
+		}
+		s.logger.Info("update outbound provider ", s.options.Tag, ": not modified")
+		return nil
+	default:
+		return E.New("unexpected status: ", response.Status)
+	}
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	err = s.loadBytes(content)
+	if err != nil {
+		return err
+	}
+	eTagHeader := response.Header.Get("Etag")
+	if eTagHeader != "" {
+		s.lastEtag = eTagHeader
+	}
+	s.access.Lock()
+	s.lastUpdated = time.Now()
+	s.access.Unlock()
+	if s.cacheFile != nil {
+		err = s.cacheFile.SaveOutboundProvider(s.options.Tag, &adapter.SavedBinary{
+			LastUpdated: s.lastUpdated,
+			Content:     content,
+			LastEtag:    s.lastEtag,
+		})
+		if err != nil {
+			s.logger.Error("save outbound provider cache: ", err)
+		}
+	}
+	s.logger.Info("updated outbound provider ", s.options.Tag)
+	return nil
+}
+
+func (s *RemoteProvider) loadBytes(content []byte) error {
+	outboundOptions, parseErrors, err := parseOutbounds(s.ctx, content)
+	if err != nil {
+		return err
+	}
+	for _, parseErr := range parseErrors {
+		s.logger.Warn(parseErr)
+	}
+	var (
+		outbounds     []adapter.Outbound
+		outboundByTag = make(map[string]adapter.Outbound)
+		contentByTag  = make(map[string][]byte)
+	)
+	for _, options := range outboundOptions {
+		tag := options.Tag
+		if !s.match(tag) {
+			continue
+		}
+		if outboundByTag[tag] != nil {
+			s.logger.Warn("duplicate outbound tag: ", tag)
+			continue
+		}
+		optionsContent, err := json.MarshalContext(s.ctx, &options)
+		if err != nil {
+			return E.Cause(err, "encode outbound[", tag, "]")
+		}
+		s.access.RLock()
+		existsOutbound := s.outboundByTag[tag]
+		unchanged := existsOutbound != nil && bytes.Equal(s.contentByTag[tag], optionsContent)
+		s.access.RUnlock()
+		if !unchanged {
+			if existsOutbound == nil {
+				if _, loaded := s.outbound.Outbound(tag); loaded {
+					s.logger.Warn("outbound tag already exists: ", tag)
+					continue
+				}
+			}
+			err = s.outbound.Create(
+				adapter.WithContext(s.ctx, &adapter.InboundContext{
+					Outbound: tag,
+				}),
+				s.router,
+				s.logFactory.NewLogger(F.ToString("outbound/", options.Type, "[", tag, "]")),
+				tag,
+				options.Type,
+				options.Options,
+			)
+			if err != nil {
+				s.logger.Error("initialize outbound[", tag, "]: ", err)
+				continue
+			}
+			existsOutbound, _ = s.outbound.Outbound(tag)
+		}
+		outbounds = append(outbounds, existsOutbound)
+		outboundByTag[tag] = existsOutbound
+		contentByTag[tag] = optionsContent
+	}
+	s.access.Lock()
+	staleOutbounds := common.Filter(s.outbounds, func(it adapter.Outbound) bool {
+		return outboundByTag[it.Tag()] == nil
+	})
+	s.outbounds = outbounds
+	s.outboundByTag = outboundByTag
+	s.contentByTag = contentByTag
+	callbacks := s.callbacks.Array()
+	s.access.Unlock()
+	for _, callback := range callbacks {
+		callback(s)
+	}
+	for _, outbound := range staleOutbounds {
+		err := s.outbound.Remove(outbound.Tag())
+		if err != nil {
+			s.logger.Error("remove outbound[", outbound.Tag(), "]: ", err)
+		}
+	}
+	return nil
+}
+
+func (s *RemoteProvider) match(tag string) bool {
+	if len(s.filter) > 0 && !common.Any(s.filter, func(it *regexp.Regexp) bool {
+		return it.MatchString(tag)
+	}) {
+		return false
+	}
+	return !common.Any(s.exclude, func(it *regexp.Regexp) bool {
+		return it.MatchString(tag)
+	})
+}
+
+func (s *RemoteProvider) Close() error {
+	s.cancel()
+	if s.updateTicker != nil {
+		s.updateTicker.Stop()
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/sagernet/sing-box/adapter"
 	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	"github.com/sagernet/sing-box/common/tlsfragment"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing/common"
@@ -93,6 +94,10 @@ func (m *ConnectionManager) NewConnection(ctx context.Context, this N.Dialer, co
 	if metadata.TLSFragment || metadata.TLSRecordFragment {
 		remoteConn = tf.NewConn(remoteConn, ctx, metadata.TLSFragment, metadata.TLSRecordFragment, metadata.TLSFragmentFallbackDelay)
 	}
+	if speedLimiters := connectionSpeedLimiters(metadata); len(speedLimiters) > 0 {
+		conn = speedlimit.NewUploadConn(conn, speedLimiters)
+		remoteConn = speedlimit.NewDownloadConn(remoteConn, speedLimiters)
+	}
 	m.access.Lock()
 	element := m.connections.PushBack(conn)
 	m.access.Unlock()
@@ -212,7 +217,11 @@ func (m *ConnectionManager) NewPacketConnection(ctx context.Context, this N.Dial
 	if udpTimeout > 0 {
 		ctx, conn = canceler.NewPacketConn(ctx, conn, udpTimeout)
 	}
-	destination := bufio.NewPacketConn(remotePacketConn)
+	var destination N.PacketConn = bufio.NewPacketConn(remotePacketConn)
+	if speedLimiters := connectionSpeedLimiters(metadata); len(speedLimiters) > 0 {
+		conn = speedlimit.NewUploadPacketConn(conn, speedLimiters)
+		destination = speedlimit.NewDownloadPacketConn(destination, speedLimiters)
+	}
 	m.access.Lock()
 	element := m.connections.PushBack(conn)
 	m.access.Unlock()
@@ -246,8 +255,10 @@ func (m *ConnectionManager) preConnectionCopy(ctx context.Context, source net.Co
 			}
 			common.Close(source, destination)
 			if !direction {
+				reportConnectionHealth(destination, false)
 				m.logger.ErrorContext(ctx, "connection upload handshake: ", err)
 			} else {
+				reportConnectionHealth(source, false)
 				m.logger.ErrorContext(ctx, "connection download handshake: ", err)
 			}
 			return
@@ -260,7 +271,10 @@ func (m *ConnectionManager) connectionCopy(ctx context.Context, source net.Conn,
 		sourceReader      io.Reader = source
 		destinationWriter io.Writer = destination
 	)
-	var readCounters, writeCounters []N.CountFunc
+	var (
+		readCounters, writeCounters []N.CountFunc
+		cachedN                     int64
+	)
 	for {
 		sourceReader, readCounters = N.UnwrapCountReader(sourceReader, readCounters)
 		destinationWriter, writeCounters = N.UnwrapCountWriter(destinationWriter, writeCounters)
@@ -282,6 +296,7 @@ func (m *ConnectionManager) connectionCopy(ctx context.Context, source net.Conn,
 					}
 					return
 				}
+				cachedN += int64(dataLen)
 				for _, counter := range readCounters {
 					counter(int64(dataLen))
 				}
@@ -294,7 +309,15 @@ func (m *ConnectionManager) connectionCopy(ctx context.Context, source net.Conn,
 		break
 	}
 
-	_, err := bufio.CopyWithCounters(destinationWriter, sourceReader, source, readCounters, writeCounters, bufio.DefaultIncreaseBufferAfter, bufio.DefaultBatchSize)
+	n, err := bufio.CopyWithCounters(destinationWriter, sourceReader, source, readCounters, writeCounters, bufio.DefaultIncreaseBufferAfter, bufio.DefaultBatchSize)
+	if direction {
+		// a remote closed without any reply, or timed out, is scored as a failure
+		if cachedN+n > 0 {
+			reportConnectionHealth(source, true)
+		} else if err == nil || !E.IsClosedOrCanceled(err) {
+			reportConnectionHealth(source, false)
+		}
+	}
 	if err != nil {
 		common.Close(source, destination)
 	} else if duplexDst, isDuplex := destination.(N.WriteCloser); isDuplex {
@@ -393,3 +416,13 @@ func (m *ConnectionManager) packetConnectionCopy(ctx context.Context, source N.P
 	}
 	common.Close(source, destination)
 }
+
+func connectionSpeedLimiters(metadata adapter.InboundContext) []adapter.SpeedLimiter {
+	return common.FilterNotDefault([]adapter.SpeedLimiter{metadata.UserSpeedLimiter, metadata.SpeedLimiter})
+}
+
+func reportConnectionHealth(remoteConn net.Conn, success bool) {
+	if reporter, isReporter := common.Cast[adapter.ConnectionHealthReporter](remoteConn); isReporter {
+		reporter.ReportHealth(success)
+	}
+}
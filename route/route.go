@@ -149,13 +149,32 @@ func (r *Router) routeConnection(ctx context.Context, conn net.Conn, metadata ad
 		}
 		selectedOutbound = defaultOutbound
 	}
-
+	releaseLimiters, err := acquireConnectionLimiters(metadata)
+	if err != nil {
+		buf.ReleaseMulti(buffers)
+		return &R.RejectedError{Cause: err}
+	}
+	if r.trafficQuota != nil {
+		conn, err = r.trafficQuota.RoutedConnection(ctx, conn, metadata)
+		if err != nil {
+			releaseLimiters()
+			buf.ReleaseMulti(buffers)
+			return &R.RejectedError{Cause: err}
+		}
+	}
+	onClose = N.AppendClose(onClose, func(it error) {
+		releaseLimiters()
+	})
+	r.sortFastestIP(ctx, &metadata, selectedOutbound)
 	for _, buffer := range buffers {
 		conn = bufio.NewCachedConn(conn, buffer)
 	}
 	for _, tracker := range r.trackers {
 		conn = tracker.RoutedConnection(ctx, conn, metadata, selectedRule, selectedOutbound)
 	}
+	if len(r.trackers) > 0 {
+		onClose = appendTrackerCloseHandlers(conn, onClose)
+	}
 	if outboundHandler, isHandler := selectedOutbound.(adapter.ConnectionHandlerEx); isHandler {
 		outboundHandler.NewConnectionEx(ctx, conn, metadata, onClose)
 	} else {
@@ -277,6 +296,23 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 		}
 		selectedOutbound = defaultOutbound
 	}
+	releaseLimiters, err := acquireConnectionLimiters(metadata)
+	if err != nil {
+		N.ReleaseMultiPacketBuffer(packetBuffers)
+		return &R.RejectedError{Cause: err}
+	}
+	if r.trafficQuota != nil {
+		conn, err = r.trafficQuota.RoutedPacketConnection(ctx, conn, metadata)
+		if err != nil {
+			releaseLimiters()
+			N.ReleaseMultiPacketBuffer(packetBuffers)
+			return &R.RejectedError{Cause: err}
+		}
+	}
+	onClose = N.AppendClose(onClose, func(it error) {
+		releaseLimiters()
+	})
+	r.sortFastestIP(ctx, &metadata, selectedOutbound)
 	for _, buffer := range packetBuffers {
 		conn = bufio.NewCachedPacketConn(conn, buffer.Buffer, buffer.Destination)
 		N.PutPacketBuffer(buffer)
@@ -284,7 +320,10 @@ func (r *Router) routePacketConnection(ctx context.Context, conn N.PacketConn, m
 	for _, tracker := range r.trackers {
 		conn = tracker.RoutedPacketConnection(ctx, conn, metadata, selectedRule, selectedOutbound)
 	}
-	if metadata.FakeIP {
+	if len(r.trackers) > 0 {
+		onClose = appendTrackerCloseHandlers(conn, onClose)
+	}
+	if metadata.FakeIP || metadata.DNS64 {
 		conn = bufio.NewNATPacketConn(bufio.NewNetPacketConn(conn), metadata.OriginDestination, metadata.Destination)
 	}
 	if outboundHandler, isHandler := selectedOutbound.(adapter.PacketConnectionHandlerEx); isHandler {
@@ -413,14 +452,19 @@ func (r *Router) matchRule(
 	selectedRule adapter.Rule, selectedRuleIndex int,
 	buffers []*buf.Buffer, packetBuffers []*N.PacketBuffer, fatalErr error,
 ) {
-	if r.processSearcher != nil && metadata.ProcessInfo == nil {
+	r.access.RLock()
+	rules := r.rules
+	processSearcher := r.processSearcher
+	neighborResolver := r.neighborResolver
+	r.access.RUnlock()
+	if processSearcher != nil && metadata.ProcessInfo == nil {
 		var originDestination netip.AddrPort
 		if metadata.OriginDestination.IsValid() {
 			originDestination = metadata.OriginDestination.AddrPort()
 		} else if metadata.Destination.IsIP() {
 			originDestination = metadata.Destination.AddrPort()
 		}
-		processInfo, fErr := process.FindProcessInfo(r.processSearcher, ctx, metadata.Network, metadata.Source.AddrPort(), originDestination)
+		processInfo, fErr := process.FindProcessInfo(processSearcher, ctx, metadata.Network, metadata.Source.AddrPort(), originDestination)
 		if fErr != nil {
 			r.logger.InfoContext(ctx, "failed to search process: ", fErr)
 		} else {
@@ -444,6 +488,23 @@ func (r *Router) matchRule(
 			metadata.ProcessInfo = processInfo
 		}
 	}
+	if neighborResolver != nil && metadata.SourceMACAddress == nil && metadata.Source.Addr.IsValid() {
+		hardwareAddr, loaded := neighborResolver.LookupMAC(metadata.Source.Addr)
+		if loaded {
+			metadata.SourceMACAddress = hardwareAddr
+		}
+		hostname, loaded := neighborResolver.LookupHostname(metadata.Source.Addr, metadata.SourceMACAddress)
+		if loaded {
+			metadata.SourceHostname = hostname
+		}
+		if metadata.SourceMACAddress != nil && metadata.SourceHostname != "" {
+			r.logger.DebugContext(ctx, "found neighbor: ", metadata.SourceMACAddress, ", hostname: ", metadata.SourceHostname)
+		} else if metadata.SourceMACAddress != nil {
+			r.logger.DebugContext(ctx, "found neighbor: ", metadata.SourceMACAddress)
+		} else if metadata.SourceHostname != "" {
+			r.logger.DebugContext(ctx, "found neighbor hostname: ", metadata.SourceHostname)
+		}
+	}
 	if metadata.Destination.Addr.IsValid() && r.dnsTransport.FakeIP() != nil && r.dnsTransport.FakeIP().Store().Contains(metadata.Destination.Addr) {
 		domain, loaded := r.dnsTransport.FakeIP().Store().Lookup(metadata.Destination.Addr)
 		if !loaded {
@@ -466,6 +527,15 @@ func (r *Router) matchRule(
 			r.logger.DebugContext(ctx, "found reserve mapped domain: ", metadata.Domain)
 		}
 	}
+	if metadata.Destination.IsIPv6() {
+		address, loaded := r.dns.LookupDNS64(metadata.Destination.Addr)
+		if loaded {
+			metadata.OriginDestination = metadata.Destination
+			metadata.Destination = M.SocksaddrFrom(address, metadata.Destination.Port)
+			metadata.DNS64 = true
+			r.logger.DebugContext(ctx, "found DNS64 address: ", address)
+		}
+	}
 	if metadata.Destination.IsIPv4() {
 		metadata.IPVersion = 4
 	} else if metadata.Destination.IsIPv6() {
@@ -504,7 +574,7 @@ func (r *Router) matchRule(
 	}
 
 match:
-	for currentRuleIndex, currentRule := range r.rules {
+	for currentRuleIndex, currentRule := range rules {
 		metadata.ResetRuleCache()
 		if !currentRule.Match(metadata) {
 			continue
@@ -582,6 +652,12 @@ match:
 			if routeOptions.TLSRecordFragment {
 				metadata.TLSRecordFragment = true
 			}
+			if routeOptions.SpeedLimiter != nil {
+				metadata.SpeedLimiter = routeOptions.SpeedLimiter
+			}
+			if routeOptions.ConnectionLimiter != nil {
+				metadata.ConnectionLimiter = routeOptions.ConnectionLimiter
+			}
 		}
 		switch action := currentRule.Action().(type) {
 		case *R.RuleActionSniff:
@@ -686,6 +762,9 @@ func (r *Router) actionSniff(
 			} else {
 				r.logger.DebugContext(ctx, "sniffed protocol: ", metadata.Protocol)
 			}
+			if metadata.ClientJA4 != "" {
+				r.logger.DebugContext(ctx, "sniffed client fingerprint: ja3: ", metadata.ClientJA3, ", ja4: ", metadata.ClientJA4)
+			}
 		}
 		if !sniffBuffer.IsEmpty() {
 			buffer = sniffBuffer
@@ -820,6 +899,9 @@ func (r *Router) actionSniff(
 			} else {
 				r.logger.DebugContext(ctx, "sniffed packet protocol: ", metadata.Protocol)
 			}
+			if metadata.ClientJA4 != "" {
+				r.logger.DebugContext(ctx, "sniffed client fingerprint: ja3: ", metadata.ClientJA3, ", ja4: ", metadata.ClientJA4)
+			}
 		}
 	}
 	return
@@ -846,7 +928,58 @@ func (r *Router) actionResolve(ctx context.Context, metadata *adapter.InboundCon
 			return err
 		}
 		metadata.DestinationAddresses = addresses
+		metadata.FastestIP = action.FastestIP
 		r.logger.DebugContext(ctx, "resolved [", strings.Join(F.MapToString(metadata.DestinationAddresses), " "), "]")
 	}
 	return nil
 }
+
+// sortFastestIP orders resolved addresses by probes through the selected outbound.
+func (r *Router) sortFastestIP(ctx context.Context, metadata *adapter.InboundContext, outbound adapter.Outbound) {
+	if metadata.FastestIP == nil || len(metadata.DestinationAddresses) < 2 {
+		return
+	}
+	metadata.DestinationAddresses = r.prober.Sort(ctx, outbound.Tag(), outbound, metadata.DestinationAddresses, metadata.FastestIP)
+	r.logger.DebugContext(ctx, "sorted by probes through ", outbound.Tag(), " [", strings.Join(F.MapToString(metadata.DestinationAddresses), " "), "]")
+}
+
+// acquireConnectionLimiters counts the connection against the limits of the user and the route,
+// the returned function releases it.
+func acquireConnectionLimiters(metadata adapter.InboundContext) (func(), error) {
+	var releases []func()
+	releaseAll := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+	if metadata.UserConnectionLimiter != nil {
+		release, err := metadata.UserConnectionLimiter.Acquire(metadata.Source.Addr)
+		if err != nil {
+			return nil, E.Cause(err, "limit of user ", metadata.User)
+		}
+		releases = append(releases, release)
+	}
+	if metadata.ConnectionLimiter != nil {
+		release, err := metadata.ConnectionLimiter.Acquire(metadata.Source.Addr)
+		if err != nil {
+			releaseAll()
+			return nil, E.Cause(err, "limit of route")
+		}
+		releases = append(releases, release)
+	}
+	return releaseAll, nil
+}
+
+// appendTrackerCloseHandlers notifies connections wrapped by trackers of the error they finished with.
+func appendTrackerCloseHandlers(conn any, onClose N.CloseHandlerFunc) N.CloseHandlerFunc {
+	for {
+		if handler, isHandler := conn.(adapter.ConnectionCloseHandler); isHandler {
+			onClose = N.AppendClose(onClose, handler.RoutedConnectionClosed)
+		}
+		upstream, hasUpstream := conn.(common.WithUpstream)
+		if !hasUpstream {
+			return onClose
+		}
+		conn = upstream.Upstream()
+	}
+}
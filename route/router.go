@@ -4,17 +4,23 @@ import (
 	"context"
 	"os"
 	"runtime"
+	"sync"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/asn"
+	"github.com/sagernet/sing-box/common/fastestip"
+	"github.com/sagernet/sing-box/common/neighbor"
 	"github.com/sagernet/sing-box/common/process"
 	"github.com/sagernet/sing-box/common/taskmonitor"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/log"
 	"github.com/sagernet/sing-box/option"
 	R "github.com/sagernet/sing-box/route/rule"
+	"github.com/sagernet/sing/common"
 	E "github.com/sagernet/sing/common/exceptions"
 	"github.com/sagernet/sing/common/task"
 	"github.com/sagernet/sing/service"
+	"github.com/sagernet/sing/service/filemanager"
 	"github.com/sagernet/sing/service/pause"
 )
 
@@ -29,14 +35,23 @@ type Router struct {
 	dnsTransport      adapter.DNSTransportManager
 	connection        adapter.ConnectionManager
 	network           adapter.NetworkManager
+	trafficQuota      adapter.TrafficQuotaManager
+	access            sync.RWMutex
 	rules             []adapter.Rule
 	needFindProcess   bool
+	needFindNeighbor  bool
+	needSniffHTTP     bool
 	ruleSets          []adapter.RuleSet
 	ruleSetMap        map[string]adapter.RuleSet
 	processSearcher   process.Searcher
+	leaseFiles        []string
+	neighborResolver  *neighbor.Resolver
 	pauseManager      pause.Manager
 	trackers          []adapter.ConnectionTracker
 	platformInterface adapter.PlatformInterface
+	prober            *fastestip.Prober
+	asnPath           string
+	asnReader         *asn.Reader
 	started           bool
 }
 
@@ -50,15 +65,28 @@ func NewRouter(ctx context.Context, logFactory log.Factory, options option.Route
 		dnsTransport:      service.FromContext[adapter.DNSTransportManager](ctx),
 		connection:        service.FromContext[adapter.ConnectionManager](ctx),
 		network:           service.FromContext[adapter.NetworkManager](ctx),
+		trafficQuota:      service.FromContext[adapter.TrafficQuotaManager](ctx),
 		rules:             make([]adapter.Rule, 0, len(options.Rules)),
 		ruleSetMap:        make(map[string]adapter.RuleSet),
 		needFindProcess:   hasRule(options.Rules, isProcessRule) || hasDNSRule(dnsOptions.Rules, isProcessDNSRule) || options.FindProcess,
+		needFindNeighbor:  hasRule(options.Rules, isNeighborRule) || hasDNSRule(dnsOptions.Rules, isNeighborDNSRule) || options.FindNeighbor,
+		needSniffHTTP:     hasRule(options.Rules, isHTTPRule),
+		leaseFiles:        options.DHCPLeaseFiles,
 		pauseManager:      service.FromContext[pause.Manager](ctx),
 		platformInterface: service.FromContext[adapter.PlatformInterface](ctx),
+		prober:            fastestip.NewProber(),
+		asnPath:           common.PtrValueOrDefault(options.ASN).Path,
 	}
 }
 
 func (r *Router) Initialize(rules []option.Rule, ruleSets []option.RuleSet) error {
+	if r.asnPath != "" {
+		reader, err := asn.Open(filemanager.BasePath(r.ctx, r.asnPath))
+		if err != nil {
+			return E.Cause(err, "open ASN database")
+		}
+		r.asnReader = reader
+	}
 	for i, options := range rules {
 		rule, err := R.NewRule(r.ctx, r.logger, options, false)
 		if err != nil {
@@ -123,23 +151,14 @@ func (r *Router) Start(stage adapter.StartStage) error {
 		}
 		r.needFindProcess = needFindProcess
 		if needFindProcess {
-			if r.platformInterface != nil && r.platformInterface.UsePlatformConnectionOwnerFinder() {
-				r.processSearcher = newPlatformSearcher(r.platformInterface)
-			} else {
-				monitor.Start("initialize process searcher")
-				searcher, err := process.NewSearcher(process.Config{
-					Logger:         r.logger,
-					PackageManager: r.network.PackageManager(),
-				})
-				monitor.Finish()
-				if err != nil {
-					if err != os.ErrInvalid {
-						r.logger.Warn(E.Cause(err, "create process searcher"))
-					}
-				} else {
-					r.processSearcher = searcher
-				}
-			}
+			monitor.Start("initialize process searcher")
+			r.initializeProcessSearcher()
+			monitor.Finish()
+		}
+		if r.needFindNeighbor {
+			monitor.Start("initialize neighbor resolver")
+			r.initializeNeighborResolver()
+			monitor.Finish()
 		}
 	case adapter.StartStatePostStart:
 		for i, rule := range r.rules {
@@ -169,6 +188,184 @@ func (r *Router) Start(stage adapter.StartStage) error {
 	return nil
 }
 
+func (r *Router) initializeProcessSearcher() {
+	if r.platformInterface != nil && r.platformInterface.UsePlatformConnectionOwnerFinder() {
+		r.processSearcher = newPlatformSearcher(r.platformInterface)
+	} else {
+		searcher, err := process.NewSearcher(process.Config{
+			Logger:         r.logger,
+			PackageManager: r.network.PackageManager(),
+		})
+		if err != nil {
+			if err != os.ErrInvalid {
+				r.logger.Warn(E.Cause(err, "create process searcher"))
+			}
+		} else {
+			r.processSearcher = searcher
+		}
+	}
+}
+
+func (r *Router) initializeNeighborResolver() {
+	resolver, err := neighbor.NewResolver(neighbor.Options{
+		Logger:       r.logger,
+		FindNeighbor: C.IsLinux,
+		LeaseFiles: common.Map(r.leaseFiles, func(it string) string {
+			return filemanager.BasePath(r.ctx, it)
+		}),
+	})
+	if err != nil {
+		r.logger.Warn(E.Cause(err, "create neighbor resolver"))
+		return
+	}
+	err = resolver.Start()
+	if err != nil {
+		resolver.Close()
+		r.logger.Warn(E.Cause(err, "start neighbor resolver"))
+		return
+	}
+	r.neighborResolver = resolver
+}
+
+// ReloadRuleSets creates and starts rule-sets not listed in unchanged,
+// then swaps them in. Replaced rule-sets are returned to be closed
+// by the caller after all rules referencing them are reloaded.
+func (r *Router) ReloadRuleSets(ruleSets []option.RuleSet, unchanged map[string]bool) ([]adapter.RuleSet, error) {
+	r.access.RLock()
+	oldRuleSetMap := r.ruleSetMap
+	r.access.RUnlock()
+	newRuleSets := make([]adapter.RuleSet, 0, len(ruleSets))
+	newRuleSetMap := make(map[string]adapter.RuleSet)
+	var createdRuleSets []adapter.RuleSet
+	for i, options := range ruleSets {
+		if _, exists := newRuleSetMap[options.Tag]; exists {
+			return nil, E.New("duplicate rule-set tag: ", options.Tag)
+		}
+		ruleSet, loaded := oldRuleSetMap[options.Tag]
+		if !loaded || !unchanged[options.Tag] {
+			var err error
+			ruleSet, err = R.NewRuleSet(r.ctx, r.logger, options)
+			if err != nil {
+				return nil, E.Cause(err, "parse rule-set[", i, "]")
+			}
+			createdRuleSets = append(createdRuleSets, ruleSet)
+		}
+		newRuleSets = append(newRuleSets, ruleSet)
+		newRuleSetMap[options.Tag] = ruleSet
+	}
+	if len(createdRuleSets) > 0 {
+		cacheContext := adapter.NewHTTPStartContext(r.ctx)
+		var ruleSetStartGroup task.Group
+		for _, ruleSet := range createdRuleSets {
+			ruleSetInPlace := ruleSet
+			ruleSetStartGroup.Append0(func(ctx context.Context) error {
+				err := ruleSetInPlace.StartContext(ctx, cacheContext)
+				if err != nil {
+					return E.Cause(err, "initialize rule-set[", ruleSetInPlace.Name(), "]")
+				}
+				return nil
+			})
+		}
+		ruleSetStartGroup.Concurrency(5)
+		ruleSetStartGroup.FastFail()
+		err := ruleSetStartGroup.Run(r.ctx)
+		cacheContext.Close()
+		if err == nil {
+			for _, ruleSet := range createdRuleSets {
+				err = ruleSet.PostStart()
+				if err != nil {
+					err = E.Cause(err, "post start rule_set[", ruleSet.Name(), "]")
+					break
+				}
+			}
+		}
+		if err != nil {
+			for _, ruleSet := range createdRuleSets {
+				ruleSet.Close()
+			}
+			return nil, err
+		}
+	}
+	var staleRuleSets []adapter.RuleSet
+	for tag, ruleSet := range oldRuleSetMap {
+		if newRuleSetMap[tag] != ruleSet {
+			staleRuleSets = append(staleRuleSets, ruleSet)
+		}
+	}
+	r.access.Lock()
+	r.ruleSets = newRuleSets
+	r.ruleSetMap = newRuleSetMap
+	r.access.Unlock()
+	for _, ruleSet := range newRuleSets {
+		if ruleSet.Metadata().ContainsProcessRule {
+			r.enableFindProcess()
+			break
+		}
+	}
+	return staleRuleSets, nil
+}
+
+// ReloadRules replaces route rules atomically, connections already routed are not affected.
+func (r *Router) ReloadRules(rules []option.Rule) error {
+	newRules := make([]adapter.Rule, 0, len(rules))
+	closeRules := func(rules []adapter.Rule) {
+		for i, rule := range rules {
+			err := rule.Close()
+			if err != nil {
+				r.logger.Error(E.Cause(err, "close rule[", i, "]"))
+			}
+		}
+	}
+	for i, options := range rules {
+		rule, err := R.NewRule(r.ctx, r.logger, options, false)
+		if err != nil {
+			closeRules(newRules)
+			return E.Cause(err, "parse rule[", i, "]")
+		}
+		newRules = append(newRules, rule)
+	}
+	for i, rule := range newRules {
+		err := rule.Start()
+		if err != nil {
+			closeRules(newRules)
+			return E.Cause(err, "initialize rule[", i, "]")
+		}
+	}
+	if hasRule(rules, isProcessRule) {
+		r.enableFindProcess()
+	}
+	if hasRule(rules, isNeighborRule) {
+		r.enableFindNeighbor()
+	}
+	r.access.Lock()
+	oldRules := r.rules
+	r.rules = newRules
+	r.needSniffHTTP = hasRule(rules, isHTTPRule)
+	r.access.Unlock()
+	closeRules(oldRules)
+	return nil
+}
+
+func (r *Router) enableFindProcess() {
+	r.access.Lock()
+	defer r.access.Unlock()
+	if r.needFindProcess {
+		return
+	}
+	r.needFindProcess = true
+	r.initializeProcessSearcher()
+}
+
+func (r *Router) enableFindNeighbor() {
+	r.access.Lock()
+	defer r.access.Unlock()
+	if r.needFindNeighbor {
+		return
+	}
+	r.needFindNeighbor = true
+	r.initializeNeighborResolver()
+}
+
 func (r *Router) Close() error {
 	monitor := taskmonitor.New(r.logger, C.StopTimeout)
 	var err error
@@ -186,15 +383,35 @@ func (r *Router) Close() error {
 		})
 		monitor.Finish()
 	}
+	if r.asnReader != nil {
+		err = E.Append(err, r.asnReader.Close(), func(err error) error {
+			return E.Cause(err, "close ASN database")
+		})
+	}
+	if r.neighborResolver != nil {
+		err = E.Append(err, r.neighborResolver.Close(), func(err error) error {
+			return E.Cause(err, "close neighbor resolver")
+		})
+	}
 	return err
 }
 
 func (r *Router) RuleSet(tag string) (adapter.RuleSet, bool) {
+	r.access.RLock()
+	defer r.access.RUnlock()
 	ruleSet, loaded := r.ruleSetMap[tag]
 	return ruleSet, loaded
 }
 
+func (r *Router) RuleSets() []adapter.RuleSet {
+	r.access.RLock()
+	defer r.access.RUnlock()
+	return r.ruleSets
+}
+
 func (r *Router) Rules() []adapter.Rule {
+	r.access.RLock()
+	defer r.access.RUnlock()
 	return r.rules
 }
 
@@ -202,10 +419,26 @@ func (r *Router) AppendTracker(tracker adapter.ConnectionTracker) {
 	r.trackers = append(r.trackers, tracker)
 }
 
+func (r *Router) ASNReader() adapter.ASNReader {
+	if r.asnReader == nil {
+		return nil
+	}
+	return r.asnReader
+}
+
 func (r *Router) NeedFindProcess() bool {
+	r.access.RLock()
+	defer r.access.RUnlock()
 	return r.needFindProcess
 }
 
+// NeedSniffHTTP returns whether route rules match HTTP requests, which are sniffed by HTTP proxy inbounds only if needed.
+func (r *Router) NeedSniffHTTP() bool {
+	r.access.RLock()
+	defer r.access.RUnlock()
+	return r.needSniffHTTP
+}
+
 func (r *Router) ResetNetwork() {
 	r.network.ResetNetwork()
 	r.dns.ResetNetwork()
@@ -38,7 +38,7 @@ func (r *abstractDefaultRule) Start() error {
 			}
 		}
 	}
-	return nil
+	return startAction(r.action)
 }
 
 func (r *abstractDefaultRule) Close() error {
@@ -176,7 +176,7 @@ func (r *abstractLogicalRule) Start() error {
 			return err
 		}
 	}
-	return nil
+	return startAction(r.action)
 }
 
 func (r *abstractLogicalRule) Close() error {
@@ -224,3 +224,12 @@ func (r *abstractLogicalRule) String() string {
 		return "!(" + strings.Join(F.MapToString(r.rules), " "+op+" ") + ")"
 	}
 }
+
+func startAction(action adapter.RuleAction) error {
+	if starter, isStarter := action.(interface {
+		Start() error
+	}); isStarter {
+		return starter.Start()
+	}
+	return nil
+}
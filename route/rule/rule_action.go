@@ -9,8 +9,11 @@ import (
 	"time"
 
 	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/common/connlimit"
 	"github.com/sagernet/sing-box/common/dialer"
+	"github.com/sagernet/sing-box/common/fastestip"
 	"github.com/sagernet/sing-box/common/sniff"
+	"github.com/sagernet/sing-box/common/speedlimit"
 	C "github.com/sagernet/sing-box/constant"
 	"github.com/sagernet/sing-box/option"
 	"github.com/sagernet/sing-tun"
@@ -41,6 +44,8 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 				TLSFragment:               action.RouteOptions.TLSFragment,
 				TLSFragmentFallbackDelay:  time.Duration(action.RouteOptions.TLSFragmentFallbackDelay),
 				TLSRecordFragment:         action.RouteOptions.TLSRecordFragment,
+				SpeedLimiter:              speedlimit.New(action.RouteOptions.SpeedLimit),
+				ConnectionLimiter:         connlimit.New(action.RouteOptions.MaxConnections, 0),
 			},
 		}, nil
 	case C.RuleActionTypeRouteOptions:
@@ -55,6 +60,8 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 			TLSFragment:               action.RouteOptionsOptions.TLSFragment,
 			TLSFragmentFallbackDelay:  time.Duration(action.RouteOptionsOptions.TLSFragmentFallbackDelay),
 			TLSRecordFragment:         action.RouteOptionsOptions.TLSRecordFragment,
+			SpeedLimiter:              speedlimit.New(action.RouteOptionsOptions.SpeedLimit),
+			ConnectionLimiter:         connlimit.New(action.RouteOptionsOptions.MaxConnections, 0),
 		}, nil
 	case C.RuleActionTypeBypass:
 		return &RuleActionBypass{
@@ -106,57 +113,89 @@ func NewRuleAction(ctx context.Context, logger logger.ContextLogger, action opti
 		}
 		return sniffAction, sniffAction.build()
 	case C.RuleActionTypeResolve:
+		fastestIP, err := newFastestIPOptions(action.ResolveOptions.FastestIP)
+		if err != nil {
+			return nil, err
+		}
 		return &RuleActionResolve{
 			Server:       action.ResolveOptions.Server,
 			Strategy:     C.DomainStrategy(action.ResolveOptions.Strategy),
 			DisableCache: action.ResolveOptions.DisableCache,
 			RewriteTTL:   action.ResolveOptions.RewriteTTL,
 			ClientSubnet: action.ResolveOptions.ClientSubnet.Build(netip.Prefix{}),
+			FastestIP:    fastestIP,
 		}, nil
 	default:
 		panic(F.ToString("unknown rule action: ", action.Action))
 	}
 }
 
-func NewDNSRuleAction(logger logger.ContextLogger, action option.DNSRuleAction) adapter.RuleAction {
+func NewDNSRuleAction(ctx context.Context, logger logger.ContextLogger, action option.DNSRuleAction) (adapter.RuleAction, error) {
 	switch action.Action {
 	case "":
-		return nil
+		return nil, nil
 	case C.RuleActionTypeRoute:
+		fastestIP, err := newFastestIPOptions(action.RouteOptions.FastestIP)
+		if err != nil {
+			return nil, err
+		}
 		return &RuleActionDNSRoute{
 			Server: action.RouteOptions.Server,
 			RuleActionDNSRouteOptions: RuleActionDNSRouteOptions{
-				Strategy:     C.DomainStrategy(action.RouteOptions.Strategy),
-				DisableCache: action.RouteOptions.DisableCache,
-				RewriteTTL:   action.RouteOptions.RewriteTTL,
-				ClientSubnet: netip.Prefix(common.PtrValueOrDefault(action.RouteOptions.ClientSubnet)),
+				Strategy:       C.DomainStrategy(action.RouteOptions.Strategy),
+				DisableCache:   action.RouteOptions.DisableCache,
+				RewriteTTL:     action.RouteOptions.RewriteTTL,
+				ClientSubnet:   netip.Prefix(common.PtrValueOrDefault(action.RouteOptions.ClientSubnet)),
+				ValidateDNSSEC: action.RouteOptions.ValidateDNSSEC,
+				FastestIP:      fastestIP,
 			},
-		}
+			DNS64:       common.PtrValueOrDefault(action.RouteOptions.DNS64).Enabled,
+			DNS64Prefix: common.PtrValueOrDefault(action.RouteOptions.DNS64).Prefix.Build(netip.Prefix{}),
+		}, nil
 	case C.RuleActionTypeRouteOptions:
-		return &RuleActionDNSRouteOptions{
-			Strategy:     C.DomainStrategy(action.RouteOptionsOptions.Strategy),
-			DisableCache: action.RouteOptionsOptions.DisableCache,
-			RewriteTTL:   action.RouteOptionsOptions.RewriteTTL,
-			ClientSubnet: netip.Prefix(common.PtrValueOrDefault(action.RouteOptionsOptions.ClientSubnet)),
+		fastestIP, err := newFastestIPOptions(action.RouteOptionsOptions.FastestIP)
+		if err != nil {
+			return nil, err
 		}
+		return &RuleActionDNSRouteOptions{
+			Strategy:       C.DomainStrategy(action.RouteOptionsOptions.Strategy),
+			DisableCache:   action.RouteOptionsOptions.DisableCache,
+			RewriteTTL:     action.RouteOptionsOptions.RewriteTTL,
+			ClientSubnet:   netip.Prefix(common.PtrValueOrDefault(action.RouteOptionsOptions.ClientSubnet)),
+			ValidateDNSSEC: action.RouteOptionsOptions.ValidateDNSSEC,
+			FastestIP:      fastestIP,
+		}, nil
 	case C.RuleActionTypeReject:
 		return &RuleActionReject{
 			Method: action.RejectOptions.Method,
 			NoDrop: action.RejectOptions.NoDrop,
 			logger: logger,
-		}
+		}, nil
 	case C.RuleActionTypePredefined:
 		return &RuleActionPredefined{
 			Rcode:  action.PredefinedOptions.Rcode.Build(),
 			Answer: common.Map(action.PredefinedOptions.Answer, option.DNSRecordOptions.Build),
 			Ns:     common.Map(action.PredefinedOptions.Ns, option.DNSRecordOptions.Build),
 			Extra:  common.Map(action.PredefinedOptions.Extra, option.DNSRecordOptions.Build),
-		}
+		}, nil
+	case C.RuleActionTypeRewrite:
+		return NewRuleActionDNSRewrite(ctx, action.RewriteOptions)
 	default:
 		panic(F.ToString("unknown rule action: ", action.Action))
 	}
 }
 
+func newFastestIPOptions(options *option.FastestIPOptions) (*adapter.FastestIPOptions, error) {
+	if options == nil || !options.Enabled {
+		return nil, nil
+	}
+	fastestIP, err := fastestip.NewOptions(options.Method, options.Port, time.Duration(options.Timeout), options.MaxAddresses)
+	if err != nil {
+		return nil, E.Cause(err, "fastest_ip")
+	}
+	return fastestIP, nil
+}
+
 type RuleActionRoute struct {
 	Outbound string
 	RuleActionRouteOptions
@@ -205,6 +244,8 @@ type RuleActionRouteOptions struct {
 	TLSFragment               bool
 	TLSFragmentFallbackDelay  time.Duration
 	TLSRecordFragment         bool
+	SpeedLimiter              adapter.SpeedLimiter
+	ConnectionLimiter         adapter.ConnectionLimiter
 }
 
 func (r *RuleActionRouteOptions) Type() string {
@@ -253,12 +294,20 @@ func (r *RuleActionRouteOptions) Descriptions() []string {
 	if r.TLSRecordFragment {
 		descriptions = append(descriptions, "tls-record-fragment")
 	}
+	if r.SpeedLimiter != nil {
+		descriptions = append(descriptions, F.ToString("speed-limit=", r.SpeedLimiter))
+	}
+	if r.ConnectionLimiter != nil {
+		descriptions = append(descriptions, r.ConnectionLimiter.String())
+	}
 	return descriptions
 }
 
 type RuleActionDNSRoute struct {
 	Server string
 	RuleActionDNSRouteOptions
+	DNS64       bool
+	DNS64Prefix netip.Prefix
 }
 
 func (r *RuleActionDNSRoute) Type() string {
@@ -277,14 +326,29 @@ func (r *RuleActionDNSRoute) String() string {
 	if r.ClientSubnet.IsValid() {
 		descriptions = append(descriptions, F.ToString("client-subnet=", r.ClientSubnet))
 	}
+	if r.ValidateDNSSEC {
+		descriptions = append(descriptions, "validate-dnssec")
+	}
+	if r.DNS64 {
+		if r.DNS64Prefix.IsValid() {
+			descriptions = append(descriptions, F.ToString("dns64=", r.DNS64Prefix))
+		} else {
+			descriptions = append(descriptions, "dns64")
+		}
+	}
+	if r.FastestIP != nil {
+		descriptions = append(descriptions, "fastest-ip")
+	}
 	return F.ToString("route(", strings.Join(descriptions, ","), ")")
 }
 
 type RuleActionDNSRouteOptions struct {
-	Strategy     C.DomainStrategy
-	DisableCache bool
-	RewriteTTL   *uint32
-	ClientSubnet netip.Prefix
+	Strategy       C.DomainStrategy
+	DisableCache   bool
+	RewriteTTL     *uint32
+	ClientSubnet   netip.Prefix
+	ValidateDNSSEC bool
+	FastestIP      *adapter.FastestIPOptions
 }
 
 func (r *RuleActionDNSRouteOptions) Type() string {
@@ -302,6 +366,12 @@ func (r *RuleActionDNSRouteOptions) String() string {
 	if r.ClientSubnet.IsValid() {
 		descriptions = append(descriptions, F.ToString("client-subnet=", r.ClientSubnet))
 	}
+	if r.ValidateDNSSEC {
+		descriptions = append(descriptions, "validate-dnssec")
+	}
+	if r.FastestIP != nil {
+		descriptions = append(descriptions, "fastest-ip")
+	}
 	return F.ToString("route-options(", strings.Join(descriptions, ","), ")")
 }
 
@@ -476,6 +546,7 @@ type RuleActionResolve struct {
 	DisableCache bool
 	RewriteTTL   *uint32
 	ClientSubnet netip.Prefix
+	FastestIP    *adapter.FastestIPOptions
 }
 
 func (r *RuleActionResolve) Type() string {
@@ -499,6 +570,9 @@ func (r *RuleActionResolve) String() string {
 	if r.ClientSubnet.IsValid() {
 		options = append(options, F.ToString("client_subnet=", r.ClientSubnet))
 	}
+	if r.FastestIP != nil {
+		options = append(options, "fastest_ip")
+	}
 	if len(options) == 0 {
 		return "resolve"
 	} else {
@@ -0,0 +1,213 @@
+package rule
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	C "github.com/sagernet/sing-box/constant"
+	"github.com/sagernet/sing-box/option"
+	"github.com/sagernet/sing/common"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+	M "github.com/sagernet/sing/common/metadata"
+	"github.com/sagernet/sing/service"
+
+	"github.com/miekg/dns"
+)
+
+var _ adapter.DNSResponseRewriter = (*RuleActionDNSRewrite)(nil)
+
+type RuleActionDNSRewrite struct {
+	DropType    []uint16
+	StripECH    bool
+	Invert      bool
+	MinTTL      uint32
+	MaxTTL      uint32
+	Replace     []DNSRewriteReplace
+	filterItems []RuleItem
+}
+
+type DNSRewriteReplace struct {
+	From netip.Prefix
+	To   netip.Addr
+}
+
+func NewRuleActionDNSRewrite(ctx context.Context, options option.DNSRouteActionRewrite) (*RuleActionDNSRewrite, error) {
+	action := &RuleActionDNSRewrite{
+		DropType: common.Map(options.DropType, func(it option.DNSQueryType) uint16 {
+			return uint16(it)
+		}),
+		StripECH: options.StripECH,
+		Invert:   options.AnswerInvert,
+		MinTTL:   options.MinTTL,
+		MaxTTL:   options.MaxTTL,
+		Replace: common.Map(options.Replace, func(it option.DNSRewriteReplaceOptions) DNSRewriteReplace {
+			return DNSRewriteReplace{
+				From: it.From.Build(netip.Prefix{}),
+				To:   it.To.Build(netip.Addr{}),
+			}
+		}),
+	}
+	if len(options.AnswerIPCIDR) > 0 {
+		item, err := NewIPCIDRItem(false, options.AnswerIPCIDR)
+		if err != nil {
+			return nil, E.Cause(err, "answer_ip_cidr")
+		}
+		action.filterItems = append(action.filterItems, item)
+	}
+	if len(options.AnswerRuleSet) > 0 {
+		action.filterItems = append(action.filterItems, NewRuleSetItem(service.FromContext[adapter.Router](ctx), options.AnswerRuleSet, false, false))
+	}
+	return action, nil
+}
+
+func (r *RuleActionDNSRewrite) Type() string {
+	return C.RuleActionTypeRewrite
+}
+
+func (r *RuleActionDNSRewrite) String() string {
+	var descriptions []string
+	for _, queryType := range r.DropType {
+		descriptions = append(descriptions, "drop-"+dns.Type(queryType).String())
+	}
+	if r.StripECH {
+		descriptions = append(descriptions, "strip-ech")
+	}
+	for _, item := range r.filterItems {
+		if r.Invert {
+			descriptions = append(descriptions, F.ToString("keep[", item, "]"))
+		} else {
+			descriptions = append(descriptions, F.ToString("drop[", item, "]"))
+		}
+	}
+	if r.MinTTL > 0 {
+		descriptions = append(descriptions, F.ToString("min-ttl=", r.MinTTL))
+	}
+	if r.MaxTTL > 0 {
+		descriptions = append(descriptions, F.ToString("max-ttl=", r.MaxTTL))
+	}
+	for _, replace := range r.Replace {
+		descriptions = append(descriptions, F.ToString("replace[", replace.From, "=>", replace.To, "]"))
+	}
+	return F.ToString("rewrite(", strings.Join(descriptions, ","), ")")
+}
+
+func (r *RuleActionDNSRewrite) Start() error {
+	for _, item := range r.filterItems {
+		if starter, isStarter := item.(interface {
+			Start() error
+		}); isStarter {
+			err := starter.Start()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RuleActionDNSRewrite) RewriteResponse(response *dns.Msg) {
+	if len(r.DropType) > 0 {
+		response.Answer = common.Filter(response.Answer, r.keepType)
+		response.Extra = common.Filter(response.Extra, r.keepType)
+	}
+	if r.StripECH {
+		for _, record := range append(response.Answer, response.Extra...) {
+			switch svcb := record.(type) {
+			case *dns.HTTPS:
+				svcb.Value = stripECH(svcb.Value)
+			case *dns.SVCB:
+				svcb.Value = stripECH(svcb.Value)
+			}
+		}
+	}
+	if len(r.filterItems) > 0 {
+		response.Answer = common.Filter(response.Answer, func(it dns.RR) bool {
+			address, isAddress := recordAddress(it)
+			if !isAddress {
+				return true
+			}
+			return r.matchAddress(address) == r.Invert
+		})
+	}
+	if len(r.Replace) > 0 {
+		for _, record := range response.Answer {
+			switch addressRecord := record.(type) {
+			case *dns.A:
+				if address, replaced := r.replaceAddress(M.AddrFromIP(addressRecord.A)); replaced {
+					addressRecord.A = address.AsSlice()
+				}
+			case *dns.AAAA:
+				if address, replaced := r.replaceAddress(M.AddrFromIP(addressRecord.AAAA)); replaced {
+					addressRecord.AAAA = address.AsSlice()
+				}
+			}
+		}
+	}
+	if r.MinTTL > 0 || r.MaxTTL > 0 {
+		for _, recordList := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+			for _, record := range recordList {
+				header := record.Header()
+				if header.Rrtype == dns.TypeOPT {
+					continue
+				}
+				header.Ttl = r.RewriteTTL(header.Ttl)
+			}
+		}
+	}
+}
+
+func (r *RuleActionDNSRewrite) RewriteTTL(ttl uint32) uint32 {
+	if ttl < r.MinTTL {
+		ttl = r.MinTTL
+	}
+	if r.MaxTTL > 0 && ttl > r.MaxTTL {
+		ttl = r.MaxTTL
+	}
+	return ttl
+}
+
+func (r *RuleActionDNSRewrite) keepType(record dns.RR) bool {
+	return !common.Contains(r.DropType, record.Header().Rrtype)
+}
+
+func (r *RuleActionDNSRewrite) matchAddress(address netip.Addr) bool {
+	metadata := adapter.InboundContext{
+		Destination: M.SocksaddrFrom(address, 0),
+	}
+	for _, item := range r.filterItems {
+		metadata.ResetRuleCache()
+		if item.Match(&metadata) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RuleActionDNSRewrite) replaceAddress(address netip.Addr) (netip.Addr, bool) {
+	for _, replace := range r.Replace {
+		if replace.From.Contains(address) {
+			return replace.To, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+func recordAddress(record dns.RR) (netip.Addr, bool) {
+	switch addressRecord := record.(type) {
+	case *dns.A:
+		return M.AddrFromIP(addressRecord.A), true
+	case *dns.AAAA:
+		return M.AddrFromIP(addressRecord.AAAA), true
+	default:
+		return netip.Addr{}, false
+	}
+}
+
+func stripECH(values []dns.SVCBKeyValue) []dns.SVCBKeyValue {
+	return common.Filter(values, func(it dns.SVCBKeyValue) bool {
+		return it.Key() != dns.SVCB_ECHCONFIG
+	})
+}
@@ -101,6 +101,34 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.ClientJA3) > 0 {
+		item := NewClientJA3Item(options.ClientJA3)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.ClientJA4) > 0 {
+		item := NewClientJA4Item(options.ClientJA4)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPMethod) > 0 {
+		item := NewHTTPMethodItem(options.HTTPMethod)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPPathRegex) > 0 {
+		item, err := NewHTTPPathRegexItem(options.HTTPPathRegex)
+		if err != nil {
+			return nil, E.Cause(err, "http_path_regex")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.HTTPUserAgent) > 0 {
+		item := NewHTTPUserAgentItem(options.HTTPUserAgent)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.Domain) > 0 || len(options.DomainSuffix) > 0 {
 		item, err := NewDomainItem(options.Domain, options.DomainSuffix)
 		if err != nil {
@@ -144,6 +172,27 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.SourceIPASN) > 0 {
+		item, err := NewASNItem(router, true, options.SourceIPASN)
+		if err != nil {
+			return nil, E.Cause(err, "source_ip_asn")
+		}
+		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.SourceMACAddress) > 0 {
+		item, err := NewSourceMACAddressItem(options.SourceMACAddress)
+		if err != nil {
+			return nil, E.Cause(err, "source_mac_address")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.SourceHostname) > 0 {
+		item := NewSourceHostnameItem(options.SourceHostname)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.IPCIDR) > 0 {
 		item, err := NewIPCIDRItem(false, options.IPCIDR)
 		if err != nil {
@@ -157,6 +206,14 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.destinationIPCIDRItems = append(rule.destinationIPCIDRItems, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.IPASN) > 0 {
+		item, err := NewASNItem(router, false, options.IPASN)
+		if err != nil {
+			return nil, E.Cause(err, "ip_asn")
+		}
+		rule.destinationIPCIDRItems = append(rule.destinationIPCIDRItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.SourcePort) > 0 {
 		item := NewPortItem(true, options.SourcePort)
 		rule.sourcePortItems = append(rule.sourcePortItems, item)
@@ -261,6 +318,14 @@ func NewDefaultRule(ctx context.Context, logger log.ContextLogger, options optio
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if options.TimeRange != nil {
+		item, err := NewTimeRangeItem(ctx, options.TimeRange)
+		if err != nil {
+			return nil, E.Cause(err, "time_range")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.PreferredBy) > 0 {
 		item := NewPreferredByItem(ctx, options.PreferredBy)
 		rule.items = append(rule.items, item)
@@ -49,10 +49,14 @@ type DefaultDNSRule struct {
 }
 
 func NewDefaultDNSRule(ctx context.Context, logger log.ContextLogger, options option.DefaultDNSRule) (*DefaultDNSRule, error) {
+	action, err := NewDNSRuleAction(ctx, logger, options.DNSRuleAction)
+	if err != nil {
+		return nil, E.Cause(err, "action")
+	}
 	rule := &DefaultDNSRule{
 		abstractDefaultRule: abstractDefaultRule{
 			invert: options.Invert,
-			action: NewDNSRuleAction(logger, options.DNSRuleAction),
+			action: action,
 		},
 	}
 	if len(options.Inbound) > 0 {
@@ -148,6 +152,35 @@ func NewDefaultDNSRule(ctx context.Context, logger log.ContextLogger, options op
 		rule.destinationIPCIDRItems = append(rule.destinationIPCIDRItems, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if len(options.SourceIPASN) > 0 {
+		item, err := NewASNItem(router, true, options.SourceIPASN)
+		if err != nil {
+			return nil, E.Cause(err, "source_ip_asn")
+		}
+		rule.sourceAddressItems = append(rule.sourceAddressItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.SourceMACAddress) > 0 {
+		item, err := NewSourceMACAddressItem(options.SourceMACAddress)
+		if err != nil {
+			return nil, E.Cause(err, "source_mac_address")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.SourceHostname) > 0 {
+		item := NewSourceHostnameItem(options.SourceHostname)
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
+	if len(options.IPASN) > 0 {
+		item, err := NewASNItem(router, false, options.IPASN)
+		if err != nil {
+			return nil, E.Cause(err, "ip_asn")
+		}
+		rule.destinationIPCIDRItems = append(rule.destinationIPCIDRItems, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if options.IPAcceptAny {
 		item := NewIPAcceptAnyItem()
 		rule.destinationIPCIDRItems = append(rule.destinationIPCIDRItems, item)
@@ -262,6 +295,14 @@ func NewDefaultDNSRule(ctx context.Context, logger log.ContextLogger, options op
 		rule.items = append(rule.items, item)
 		rule.allItems = append(rule.allItems, item)
 	}
+	if options.TimeRange != nil {
+		item, err := NewTimeRangeItem(ctx, options.TimeRange)
+		if err != nil {
+			return nil, E.Cause(err, "time_range")
+		}
+		rule.items = append(rule.items, item)
+		rule.allItems = append(rule.allItems, item)
+	}
 	if len(options.RuleSet) > 0 {
 		var matchSource bool
 		if options.RuleSetIPCIDRMatchSource {
@@ -318,11 +359,15 @@ type LogicalDNSRule struct {
 }
 
 func NewLogicalDNSRule(ctx context.Context, logger log.ContextLogger, options option.LogicalDNSRule) (*LogicalDNSRule, error) {
+	action, err := NewDNSRuleAction(ctx, logger, options.DNSRuleAction)
+	if err != nil {
+		return nil, E.Cause(err, "action")
+	}
 	r := &LogicalDNSRule{
 		abstractLogicalRule: abstractLogicalRule{
 			rules:  make([]adapter.HeadlessRule, len(options.Rules)),
 			invert: options.Invert,
-			action: NewDNSRuleAction(logger, options.DNSRuleAction),
+			action: action,
 		},
 	}
 	switch options.Mode {
@@ -0,0 +1,77 @@
+package rule
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*ASNItem)(nil)
+
+type ASNItem struct {
+	reader   adapter.ASNReader
+	asnList  []uint32
+	asnMap   map[uint32]bool
+	isSource bool
+}
+
+func NewASNItem(router adapter.Router, isSource bool, asnList []uint32) (*ASNItem, error) {
+	reader := router.ASNReader()
+	if reader == nil {
+		return nil, E.New("missing ASN database, set `route.asn.path` to a MaxMind-format ASN database")
+	}
+	asnMap := make(map[uint32]bool)
+	for _, number := range asnList {
+		asnMap[number] = true
+	}
+	return &ASNItem{
+		reader:   reader,
+		asnList:  asnList,
+		asnMap:   asnMap,
+		isSource: isSource,
+	}, nil
+}
+
+func (r *ASNItem) Match(metadata *adapter.InboundContext) bool {
+	if r.isSource || metadata.IPCIDRMatchSource {
+		return r.match(metadata.Source.Addr)
+	}
+	if metadata.Destination.IsIP() {
+		return r.match(metadata.Destination.Addr)
+	}
+	if len(metadata.DestinationAddresses) > 0 {
+		for _, address := range metadata.DestinationAddresses {
+			if r.match(address) {
+				return true
+			}
+		}
+		return false
+	}
+	return metadata.IPCIDRAcceptEmpty
+}
+
+func (r *ASNItem) match(address netip.Addr) bool {
+	if !address.IsValid() {
+		return false
+	}
+	number, loaded := r.reader.Lookup(address)
+	return loaded && r.asnMap[number]
+}
+
+func (r *ASNItem) String() string {
+	var description string
+	if r.isSource {
+		description = "source_ip_asn="
+	} else {
+		description = "ip_asn="
+	}
+	if len(r.asnList) == 1 {
+		description += F.ToString(r.asnList[0])
+	} else {
+		description += "[" + strings.Join(F.MapToString(r.asnList), " ") + "]"
+	}
+	return description
+}
@@ -0,0 +1,66 @@
+package rule
+
+import (
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var _ RuleItem = (*ClientFingerprintItem)(nil)
+
+type ClientFingerprintItem struct {
+	isJA4          bool
+	fingerprints   []string
+	fingerprintMap map[string]bool
+}
+
+func NewClientJA3Item(fingerprints []string) *ClientFingerprintItem {
+	fingerprintMap := make(map[string]bool)
+	for _, fingerprint := range fingerprints {
+		// JA3 fingerprints are lowercase hex MD5 hashes
+		fingerprintMap[strings.ToLower(fingerprint)] = true
+	}
+	return &ClientFingerprintItem{
+		fingerprints:   fingerprints,
+		fingerprintMap: fingerprintMap,
+	}
+}
+
+func NewClientJA4Item(fingerprints []string) *ClientFingerprintItem {
+	fingerprintMap := make(map[string]bool)
+	for _, fingerprint := range fingerprints {
+		fingerprintMap[fingerprint] = true
+	}
+	return &ClientFingerprintItem{
+		isJA4:          true,
+		fingerprints:   fingerprints,
+		fingerprintMap: fingerprintMap,
+	}
+}
+
+func (r *ClientFingerprintItem) Match(metadata *adapter.InboundContext) bool {
+	var fingerprint string
+	if r.isJA4 {
+		fingerprint = metadata.ClientJA4
+	} else {
+		fingerprint = metadata.ClientJA3
+	}
+	if fingerprint == "" {
+		return false
+	}
+	return r.fingerprintMap[fingerprint]
+}
+
+func (r *ClientFingerprintItem) String() string {
+	var description string
+	if r.isJA4 {
+		description = "client_ja4="
+	} else {
+		description = "client_ja3="
+	}
+	if len(r.fingerprints) == 1 {
+		return F.ToString(description, r.fingerprints[0])
+	}
+	return F.ToString(description, "[", strings.Join(r.fingerprints, " "), "]")
+}
@@ -0,0 +1,112 @@
+package rule
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var (
+	_ RuleItem = (*HTTPMethodItem)(nil)
+	_ RuleItem = (*HTTPPathRegexItem)(nil)
+	_ RuleItem = (*HTTPUserAgentItem)(nil)
+)
+
+type HTTPMethodItem struct {
+	methods   []string
+	methodMap map[string]bool
+}
+
+func NewHTTPMethodItem(methods []string) *HTTPMethodItem {
+	methodMap := make(map[string]bool)
+	for _, method := range methods {
+		methodMap[strings.ToUpper(method)] = true
+	}
+	return &HTTPMethodItem{
+		methods:   methods,
+		methodMap: methodMap,
+	}
+}
+
+func (r *HTTPMethodItem) Match(metadata *adapter.InboundContext) bool {
+	return r.methodMap[metadata.HTTPMethod]
+}
+
+func (r *HTTPMethodItem) String() string {
+	if len(r.methods) == 1 {
+		return F.ToString("http_method=", r.methods[0])
+	}
+	return F.ToString("http_method=[", strings.Join(r.methods, " "), "]")
+}
+
+type HTTPPathRegexItem struct {
+	matchers    []*regexp.Regexp
+	description string
+}
+
+func NewHTTPPathRegexItem(expressions []string) (*HTTPPathRegexItem, error) {
+	matchers := make([]*regexp.Regexp, 0, len(expressions))
+	for i, regex := range expressions {
+		matcher, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, E.Cause(err, "parse expression ", i)
+		}
+		matchers = append(matchers, matcher)
+	}
+	description := "http_path_regex="
+	eLen := len(expressions)
+	if eLen == 1 {
+		description += expressions[0]
+	} else if eLen > 3 {
+		description += F.ToString("[", strings.Join(expressions[:3], " "), "]")
+	} else {
+		description += F.ToString("[", strings.Join(expressions, " "), "]")
+	}
+	return &HTTPPathRegexItem{matchers, description}, nil
+}
+
+func (r *HTTPPathRegexItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.HTTPMethod == "" {
+		return false
+	}
+	for _, matcher := range r.matchers {
+		if matcher.MatchString(metadata.HTTPPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPPathRegexItem) String() string {
+	return r.description
+}
+
+type HTTPUserAgentItem struct {
+	keywords []string
+}
+
+func NewHTTPUserAgentItem(keywords []string) *HTTPUserAgentItem {
+	return &HTTPUserAgentItem{keywords}
+}
+
+func (r *HTTPUserAgentItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.HTTPUserAgent == "" {
+		return false
+	}
+	for _, keyword := range r.keywords {
+		if strings.Contains(metadata.HTTPUserAgent, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *HTTPUserAgentItem) String() string {
+	if len(r.keywords) == 1 {
+		return F.ToString("http_user_agent=", r.keywords[0])
+	}
+	return F.ToString("http_user_agent=[", strings.Join(r.keywords, " "), "]")
+}
@@ -0,0 +1,79 @@
+package rule
+
+import (
+	"net"
+	"strings"
+
+	"github.com/sagernet/sing-box/adapter"
+	E "github.com/sagernet/sing/common/exceptions"
+	F "github.com/sagernet/sing/common/format"
+)
+
+var (
+	_ RuleItem = (*SourceMACAddressItem)(nil)
+	_ RuleItem = (*SourceHostnameItem)(nil)
+)
+
+type SourceMACAddressItem struct {
+	addresses  []string
+	addressMap map[string]bool
+}
+
+func NewSourceMACAddressItem(addresses []string) (*SourceMACAddressItem, error) {
+	addressMap := make(map[string]bool)
+	for _, address := range addresses {
+		hardwareAddr, err := net.ParseMAC(address)
+		if err != nil {
+			return nil, E.Cause(err, "parse MAC address")
+		}
+		addressMap[hardwareAddr.String()] = true
+	}
+	return &SourceMACAddressItem{
+		addresses:  addresses,
+		addressMap: addressMap,
+	}, nil
+}
+
+func (r *SourceMACAddressItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.SourceMACAddress == nil {
+		return false
+	}
+	return r.addressMap[metadata.SourceMACAddress.String()]
+}
+
+func (r *SourceMACAddressItem) String() string {
+	if len(r.addresses) == 1 {
+		return F.ToString("source_mac_address=", r.addresses[0])
+	}
+	return F.ToString("source_mac_address=[", strings.Join(r.addresses, " "), "]")
+}
+
+type SourceHostnameItem struct {
+	hostnames   []string
+	hostnameMap map[string]bool
+}
+
+func NewSourceHostnameItem(hostnames []string) *SourceHostnameItem {
+	hostnameMap := make(map[string]bool)
+	for _, hostname := range hostnames {
+		hostnameMap[strings.ToLower(hostname)] = true
+	}
+	return &SourceHostnameItem{
+		hostnames:   hostnames,
+		hostnameMap: hostnameMap,
+	}
+}
+
+func (r *SourceHostnameItem) Match(metadata *adapter.InboundContext) bool {
+	if metadata.SourceHostname == "" {
+		return false
+	}
+	return r.hostnameMap[strings.ToLower(metadata.SourceHostname)]
+}
+
+func (r *SourceHostnameItem) String() string {
+	if len(r.hostnames) == 1 {
+		return F.ToString("source_hostname=", r.hostnames[0])
+	}
+	return F.ToString("source_hostname=[", strings.Join(r.hostnames, " "), "]")
+}
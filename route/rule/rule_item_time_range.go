@@ -0,0 +1,165 @@
+package rule
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sagernet/sing-box/adapter"
+	"github.com/sagernet/sing-box/option"
+	E "github.com/sagernet/sing/common/exceptions"
+	"github.com/sagernet/sing/common/ntp"
+)
+
+var _ RuleItem = (*TimeRangeItem)(nil)
+
+const secondsPerDay = 24 * 60 * 60
+
+type TimeRangeItem struct {
+	ctx         context.Context
+	timeFunc    func() time.Time
+	location    *time.Location
+	weekdays    [7]bool
+	anyWeekday  bool
+	start       int
+	end         int
+	description string
+}
+
+func NewTimeRangeItem(ctx context.Context, options *option.TimeRangeOptions) (*TimeRangeItem, error) {
+	if options.Timezone == "" && len(options.Weekday) == 0 && options.Start == "" && options.End == "" {
+		return nil, E.New("missing conditions")
+	}
+	item := &TimeRangeItem{
+		ctx:      ctx,
+		location: time.Local,
+		end:      secondsPerDay,
+	}
+	var descriptions []string
+	if options.Timezone != "" {
+		location, err := time.LoadLocation(options.Timezone)
+		if err != nil {
+			return nil, E.Cause(err, "load timezone")
+		}
+		item.location = location
+	}
+	if len(options.Weekday) > 0 {
+		for _, weekdayString := range options.Weekday {
+			weekday, err := parseWeekday(weekdayString)
+			if err != nil {
+				return nil, err
+			}
+			item.weekdays[weekday] = true
+		}
+		var weekdayDescriptions []string
+		for weekday, enabled := range item.weekdays {
+			if enabled {
+				weekdayDescriptions = append(weekdayDescriptions, strings.ToLower(time.Weekday(weekday).String()[:3]))
+			}
+		}
+		if len(weekdayDescriptions) == 1 {
+			descriptions = append(descriptions, weekdayDescriptions[0])
+		} else {
+			descriptions = append(descriptions, "["+strings.Join(weekdayDescriptions, " ")+"]")
+		}
+	} else {
+		item.anyWeekday = true
+	}
+	if options.Start != "" {
+		start, err := parseTimeOfDay(options.Start)
+		if err != nil {
+			return nil, E.Cause(err, "parse start")
+		}
+		item.start = start
+	}
+	if options.End != "" {
+		end, err := parseTimeOfDay(options.End)
+		if err != nil {
+			return nil, E.Cause(err, "parse end")
+		}
+		item.end = end
+	}
+	if item.start == item.end {
+		return nil, E.New("start and end must not be equal")
+	}
+	if options.Start != "" || options.End != "" {
+		descriptions = append(descriptions, formatTimeOfDay(item.start)+"-"+formatTimeOfDay(item.end))
+	}
+	if options.Timezone != "" {
+		descriptions = append(descriptions, options.Timezone)
+	}
+	item.description = "time_range=" + strings.Join(descriptions, " ")
+	return item, nil
+}
+
+func (r *TimeRangeItem) Start() error {
+	r.timeFunc = ntp.TimeFuncFromContext(r.ctx)
+	if r.timeFunc == nil {
+		r.timeFunc = time.Now
+	}
+	return nil
+}
+
+func (r *TimeRangeItem) Match(metadata *adapter.InboundContext) bool {
+	var now time.Time
+	if r.timeFunc != nil {
+		now = r.timeFunc()
+	} else {
+		now = time.Now()
+	}
+	now = now.In(r.location)
+	hour, minute, second := now.Clock()
+	current := hour*3600 + minute*60 + second
+	weekday := now.Weekday()
+	if r.start < r.end {
+		return current >= r.start && current < r.end && r.matchWeekday(weekday)
+	}
+	// ranges ending before they start cross midnight and belong to the weekday they start on
+	if current >= r.start {
+		return r.matchWeekday(weekday)
+	}
+	return current < r.end && r.matchWeekday((weekday+6)%7)
+}
+
+func (r *TimeRangeItem) matchWeekday(weekday time.Weekday) bool {
+	return r.anyWeekday || r.weekdays[weekday]
+}
+
+func (r *TimeRangeItem) String() string {
+	return r.description
+}
+
+func parseWeekday(weekdayString string) (time.Weekday, error) {
+	name := strings.ToLower(weekdayString)
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		fullName := strings.ToLower(weekday.String())
+		if name == fullName || name == fullName[:3] {
+			return weekday, nil
+		}
+	}
+	return 0, E.New("unknown weekday: ", weekdayString)
+}
+
+func parseTimeOfDay(timeString string) (int, error) {
+	if timeString == "24:00" {
+		return secondsPerDay, nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		timeOfDay, err := time.Parse(layout, timeString)
+		if err == nil {
+			return timeOfDay.Hour()*3600 + timeOfDay.Minute()*60 + timeOfDay.Second(), nil
+		}
+	}
+	return 0, E.New("invalid time of day: ", timeString, ", expected HH:MM or HH:MM:SS")
+}
+
+func formatTimeOfDay(seconds int) string {
+	if seconds == secondsPerDay {
+		return "24:00"
+	}
+	timeOfDay := time.Date(0, 1, 1, 0, 0, seconds, 0, time.UTC)
+	if timeOfDay.Second() != 0 {
+		return timeOfDay.Format("15:04:05")
+	}
+	return timeOfDay.Format("15:04")
+}
@@ -32,7 +32,7 @@ import (
 	"go4.org/netipx"
 )
 
-var _ adapter.RuleSet = (*RemoteRuleSet)(nil)
+var _ adapter.RemoteRuleSet = (*RemoteRuleSet)(nil)
 
 type RemoteRuleSet struct {
 	ctx            context.Context
@@ -47,6 +47,7 @@ type RemoteRuleSet struct {
 	metadata       adapter.RuleSetMetadata
 	lastUpdated    time.Time
 	lastEtag       string
+	lastError      error
 	updateTicker   *time.Ticker
 	cacheFile      adapter.CacheFile
 	pauseManager   pause.Manager
@@ -202,14 +203,17 @@ func (s *RemoteRuleSet) loadBytes(content []byte) error {
 	return nil
 }
 
+func (s *RemoteRuleSet) LastUpdated() time.Time {
+	return s.lastUpdated
+}
+
+func (s *RemoteRuleSet) LastUpdateError() error {
+	return s.lastError
+}
+
 func (s *RemoteRuleSet) loopUpdate() {
 	if time.Since(s.lastUpdated) > s.updateInterval {
-		err := s.fetch(s.ctx, nil)
-		if err != nil {
-			s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
-		} else if s.refs.Load() == 0 {
-			s.rules = nil
-		}
+		s.updateOnce()
 	}
 	for {
 		runtime.GC()
@@ -224,6 +228,7 @@ func (s *RemoteRuleSet) loopUpdate() {
 
 func (s *RemoteRuleSet) updateOnce() {
 	err := s.fetch(s.ctx, nil)
+	s.lastError = err
 	if err != nil {
 		s.logger.Error("fetch rule-set ", s.options.Tag, ": ", err)
 	} else if s.refs.Load() == 0 {